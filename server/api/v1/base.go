@@ -4,7 +4,9 @@ import (
 	"net/http"
 	"server/global"
 	"server/model/system"
+	systemService "server/service/system"
 	"server/utils"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 )
@@ -25,6 +27,8 @@ type LoginResponse struct {
 func Login(c *gin.Context) {
 	var req LoginRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
+		// 参数校验失败时已解析出的用户名仍然记录，便于排查
+		recordLoginLog(c, systemService.LoginActionLogin, 0, req.Username, "", "参数错误")
 		c.JSON(http.StatusBadRequest, gin.H{
 			"code": 400,
 			"msg":  "参数错误: " + err.Error(),
@@ -35,6 +39,7 @@ func Login(c *gin.Context) {
 	// 查找用户
 	var user system.SysUser
 	if err := global.DB.Where("username = ?", req.Username).First(&user).Error; err != nil {
+		recordLoginLog(c, systemService.LoginActionLogin, 0, req.Username, "", "用户不存在")
 		c.JSON(http.StatusUnauthorized, gin.H{
 			"code": 401,
 			"msg":  "用户名或密码错误",
@@ -44,6 +49,7 @@ func Login(c *gin.Context) {
 
 	// 验证密码
 	if !checkPassword(req.Password, user.Password) {
+		recordLoginLog(c, systemService.LoginActionLogin, user.ID, user.Username, "", "密码错误")
 		c.JSON(http.StatusUnauthorized, gin.H{
 			"code": 401,
 			"msg":  "用户名或密码错误",
@@ -53,6 +59,7 @@ func Login(c *gin.Context) {
 
 	// 检查用户是否被禁用
 	if user.Enable != 1 {
+		recordLoginLog(c, systemService.LoginActionLogin, user.ID, user.Username, "", "用户已被禁用")
 		c.JSON(http.StatusUnauthorized, gin.H{
 			"code": 401,
 			"msg":  "用户已被禁用",
@@ -60,15 +67,28 @@ func Login(c *gin.Context) {
 		return
 	}
 
+	// 创建登录会话
+	sessionId, err := sessionService.CreateSession(user, c.ClientIP(), c.Request.UserAgent())
+	if err != nil {
+		recordLoginLog(c, systemService.LoginActionLogin, user.ID, user.Username, "", "创建会话失败")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code": 500,
+			"msg":  "创建会话失败",
+		})
+		return
+	}
+
 	// 生成JWT token
-	token, err := utils.GenerateToken(user.ID, user.Username, user.AuthorityId)
+	token, _, err := utils.GenerateToken(user.ID, user.Username, user.AuthorityId, sessionId)
 	if err != nil {
+		recordLoginLog(c, systemService.LoginActionLogin, user.ID, user.Username, sessionId, "生成token失败")
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"code": 500,
 			"msg":  "生成token失败",
 		})
 		return
 	}
+	recordLoginLog(c, systemService.LoginActionLogin, user.ID, user.Username, sessionId, "")
 
 	// 清除密码字段
 	user.Password = ""
@@ -88,14 +108,96 @@ func checkPassword(password, hashedPassword string) bool {
 	return utils.BcryptCheck(password, hashedPassword)
 }
 
-// Logout 用户登出
+// Logout 用户登出，token有效时使对应会话失效
 func Logout(c *gin.Context) {
+	token := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+	claims, err := utils.ParseToken(token)
+	if token == "" || err != nil || claims.ID == "" {
+		recordLoginLog(c, systemService.LoginActionLogout, 0, "", "", "token无效")
+	} else {
+		c.Set("userID", claims.UserID)
+		c.Set("username", claims.Username)
+		reason := ""
+		if err := sessionService.RevokeSession(claims.ID, systemService.SessionRevokeLogout, claims.Username); err != nil {
+			reason = err.Error()
+		}
+		recordLoginLog(c, systemService.LoginActionLogout, claims.UserID, claims.Username, claims.ID, reason)
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"code": 0,
 		"msg":  "登出成功",
 	})
 }
 
+// RefreshToken 刷新token，沿用当前会话并延长有效期
+// 不使用JWTAuth中间件，以便记录刷新失败的尝试
+func RefreshToken(c *gin.Context) {
+	token := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+	claims, err := utils.ParseToken(token)
+	if token == "" || err != nil {
+		recordLoginLog(c, systemService.LoginActionRefresh, 0, "", "", "token无效")
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code": 401,
+			"msg":  "无效的token",
+		})
+		return
+	}
+	c.Set("userID", claims.UserID)
+	c.Set("username", claims.Username)
+
+	if err := sessionService.TouchSession(claims.ID); err != nil {
+		recordLoginLog(c, systemService.LoginActionRefresh, claims.UserID, claims.Username, claims.ID, err.Error())
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code": 401,
+			"msg":  err.Error(),
+		})
+		return
+	}
+
+	newToken, expiresAt, err := utils.GenerateToken(claims.UserID, claims.Username, claims.AuthorityId, claims.ID)
+	if err != nil {
+		recordLoginLog(c, systemService.LoginActionRefresh, claims.UserID, claims.Username, claims.ID, "生成token失败")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code": 500,
+			"msg":  "生成token失败",
+		})
+		return
+	}
+	if err := sessionService.RenewSession(claims.ID, claims.AuthorityId, expiresAt); err != nil {
+		recordLoginLog(c, systemService.LoginActionRefresh, claims.UserID, claims.Username, claims.ID, err.Error())
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code": 401,
+			"msg":  err.Error(),
+		})
+		return
+	}
+	recordLoginLog(c, systemService.LoginActionRefresh, claims.UserID, claims.Username, claims.ID, "")
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"msg":  "刷新成功",
+		"data": gin.H{
+			"token":     newToken,
+			"expiresAt": expiresAt,
+		},
+	})
+}
+
+// recordLoginLog 记录登录日志，reason为空表示成功
+func recordLoginLog(c *gin.Context, action string, userID uint, username, sessionId, reason string) {
+	loginLogService.RecordLoginLog(system.SysLoginLog{
+		UserID:    userID,
+		Username:  username,
+		Action:    action,
+		Success:   reason == "",
+		Reason:    reason,
+		SessionId: sessionId,
+		IP:        c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	})
+}
+
 // Captcha 获取验证码（暂时返回固定值）
 func Captcha(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
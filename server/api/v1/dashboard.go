@@ -13,7 +13,7 @@ type DashboardStats struct {
 	UserCount   int64 `json:"userCount"`   // 用户总数
 	RoleCount   int64 `json:"roleCount"`   // 角色总数
 	MenuCount   int64 `json:"menuCount"`   // 菜单总数
-	OnlineCount int64 `json:"onlineCount"` // 在线用户数（暂时模拟）
+	OnlineCount int64 `json:"onlineCount"` // 在线用户数
 }
 
 // SystemInfo 系统信息结构
@@ -38,8 +38,8 @@ func GetDashboardStats(c *gin.Context) {
 	// 获取菜单总数
 	global.DB.Model(&system.SysBaseMenu{}).Count(&stats.MenuCount)
 
-	// 在线用户数（这里暂时使用模拟数据，实际可以通过Redis或其他方式统计）
-	stats.OnlineCount = 12
+	// 在线用户数，按空闲时间内有活动的会话统计
+	stats.OnlineCount, _ = sessionService.CountOnlineUsers()
 
 	c.JSON(http.StatusOK, gin.H{
 		"code": 0,
@@ -50,12 +50,14 @@ func GetDashboardStats(c *gin.Context) {
 
 // GetSystemInfo 获取系统信息
 func GetSystemInfo(c *gin.Context) {
+	build := monitorService.GetBuildInfo()
 	info := SystemInfo{
-		SystemVersion: "v1.0.0",
-		GoVersion:     "1.21",
-		GinVersion:    "1.9.1",
-		VueVersion:    "3.4.21",
-		ElementPlus:   "2.6.3",
+		SystemVersion: build.Version,
+		GoVersion:     build.GoVersion,
+		GinVersion:    build.Dependencies["gin"],
+		// 前端依赖版本由前端构建决定，这里只作展示
+		VueVersion:  "3.4.21",
+		ElementPlus: "2.6.3",
 	}
 
 	c.JSON(http.StatusOK, gin.H{
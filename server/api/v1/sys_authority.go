@@ -4,12 +4,17 @@ import (
 	"fmt"
 	"net/http"
 	"server/global"
+	"server/middleware"
 	"server/model/system"
+	systemService "server/service/system"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
+var authorityService = systemService.AuthorityService{}
+
 // CreateAuthority 创建角色
 func CreateAuthority(c *gin.Context) {
 	var authority system.SysAuthority
@@ -38,6 +43,14 @@ func CreateAuthority(c *gin.Context) {
 		return
 	}
 
+	if authority.FileQuota != nil && *authority.FileQuota < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "文件存储配额不能为负数",
+		})
+		return
+	}
+
 	// 检查角色编码是否已存在
 	var existingRole system.SysAuthority
 	if err := global.DB.Where("authority_code = ?", authority.AuthorityCode).First(&existingRole).Error; err == nil {
@@ -68,7 +81,7 @@ func CreateAuthority(c *gin.Context) {
 	// AuthorityId由数据库自动生成，不需要前端提供
 	authority.AuthorityId = 0
 
-	if err := global.DB.Create(&authority).Error; err != nil {
+	if err := global.DB.WithContext(c).Create(&authority).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"code": 500,
 			"msg":  "创建失败: " + err.Error(),
@@ -172,6 +185,14 @@ func UpdateAuthority(c *gin.Context) {
 		return
 	}
 
+	if updateData.FileQuota != nil && *updateData.FileQuota < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "文件存储配额不能为负数",
+		})
+		return
+	}
+
 	// 如果更新角色编码，检查是否已存在
 	if updateData.AuthorityCode != "" && updateData.AuthorityCode != authority.AuthorityCode {
 		var existingRole system.SysAuthority
@@ -214,7 +235,23 @@ func UpdateAuthority(c *gin.Context) {
 		}
 	}
 
-	if err := global.DB.Model(&authority).Updates(updateData).Error; err != nil {
+	// 更换父角色时，收回本角色及子孙角色超出新父角色范围的权限
+	parentChanged := updateData.ParentId != nil &&
+		(authority.ParentId == nil || *authority.ParentId != *updateData.ParentId)
+
+	var pruned []systemService.AuthorityPrune
+	err := global.DB.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&authority).Updates(updateData).Error; err != nil {
+			return err
+		}
+		if parentChanged {
+			var err error
+			pruned, err = authorityService.PruneToParent(tx, authority, *updateData.ParentId)
+			return err
+		}
+		return nil
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"code": 500,
 			"msg":  "更新失败: " + err.Error(),
@@ -225,6 +262,9 @@ func UpdateAuthority(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
 		"code": 0,
 		"msg":  "更新成功",
+		"data": gin.H{
+			"pruned": pruned,
+		},
 	})
 }
 
@@ -262,8 +302,14 @@ func DeleteAuthority(c *gin.Context) {
 	}
 
 	// 检查是否有用户关联此角色
-	var userCount int64
-	global.DB.Model(&system.SysUser{}).Where("authority_id = ?", id).Count(&userCount)
+	userCount, err := userService.CountAuthorityUsers(authority.AuthorityId)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code": 500,
+			"msg":  "查询角色用户失败: " + err.Error(),
+		})
+		return
+	}
 	if userCount > 0 {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"code": 400,
@@ -272,7 +318,18 @@ func DeleteAuthority(c *gin.Context) {
 		return
 	}
 
-	if err := global.DB.Delete(&authority).Error; err != nil {
+	// 检查是否有子角色
+	var childCount int64
+	global.DB.Model(&system.SysAuthority{}).Where("parent_id = ?", authority.AuthorityId).Count(&childCount)
+	if childCount > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "该角色下有子角色，无法删除",
+		})
+		return
+	}
+
+	if err := global.DB.WithContext(c).Delete(&authority).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"code": 500,
 			"msg":  "删除失败: " + err.Error(),
@@ -311,6 +368,7 @@ func AssignMenus(c *gin.Context) {
 
 	var req struct {
 		MenuIds []uint `json:"menuIds" binding:"required"`
+		DryRun  bool   `json:"dryRun"` // 仅预览变更，不提交
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -361,8 +419,37 @@ func AssignMenus(c *gin.Context) {
 		}
 	}
 
+	// 获取变更前的权限，用于预览和审计快照
+	beforeMenuIds, err := authorityService.LoadAuthorityMenuIds(global.DB, authority.AuthorityId)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code": 500,
+			"msg":  "查询原权限失败: " + err.Error(),
+		})
+		return
+	}
+
+	// 预览模式：返回新增/移除的菜单和按钮、受影响的用户数及子孙角色
+	if req.DryRun || c.Query("dryRun") == "true" {
+		preview, err := authorityService.PreviewAssignMenus(authority, beforeMenuIds, req.MenuIds)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"code": 500,
+				"msg":  "预览失败: " + err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"code": 0,
+			"msg":  "预览成功",
+			"data": preview,
+		})
+		return
+	}
+
 	// 开启事务
-	tx := global.DB.Begin()
+	tx := global.DB.WithContext(c).Begin()
 
 	// 删除原有权限
 	if err := tx.Where("authority_id = ?", authorityId).Delete(&system.SysAuthorityMenu{}).Error; err != nil {
@@ -390,11 +477,100 @@ func AssignMenus(c *gin.Context) {
 		}
 	}
 
-	tx.Commit()
+	// 父角色权限收缩时，同步收回子孙角色的对应权限
+	pruned, err := authorityService.PruneDescendantMenus(tx, authority.AuthorityId)
+	if err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code": 500,
+			"msg":  "收回子角色权限失败: " + err.Error(),
+		})
+		return
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code": 500,
+			"msg":  "分配权限失败: " + err.Error(),
+		})
+		return
+	}
+
+	// 记录变更前后快照，便于审计
+	middleware.SetOperationSnapshot(c, gin.H{
+		"menuIds": beforeMenuIds,
+	}, gin.H{
+		"menuIds": req.MenuIds,
+		"pruned":  pruned,
+	})
 
 	c.JSON(http.StatusOK, gin.H{
 		"code": 0,
 		"msg":  "权限分配成功",
+		"data": gin.H{
+			"pruned": pruned,
+		},
+	})
+}
+
+// GetAuthorityTree 获取角色树
+func GetAuthorityTree(c *gin.Context) {
+	var authorities []system.SysAuthority
+
+	if err := global.DB.Order("authority_id ASC").Find(&authorities).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code": 500,
+			"msg":  "查询失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"msg":  "获取成功",
+		"data": authorityService.BuildAuthorityTree(authorities),
+	})
+}
+
+// GetEffectivePermissions 获取角色的有效权限及来源
+func GetEffectivePermissions(c *gin.Context) {
+	authorityId := c.Param("id")
+
+	var authority system.SysAuthority
+	if err := global.DB.Where("authority_id = ?", authorityId).First(&authority).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"code": 404,
+			"msg":  "角色不存在",
+		})
+		return
+	}
+
+	ancestors, err := authorityService.GetAncestors(authority)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code": 500,
+			"msg":  "查询祖先角色失败: " + err.Error(),
+		})
+		return
+	}
+
+	permissions, err := authorityService.GetEffectivePermissions(authority)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code": 500,
+			"msg":  "查询有效权限失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"msg":  "获取成功",
+		"data": gin.H{
+			"authority":   authority,
+			"ancestors":   ancestors,
+			"permissions": permissions,
+		},
 	})
 }
 
@@ -486,3 +662,61 @@ func buildMenuTreeWithPermission(menus []system.SysBaseMenu, parentId *uint, ass
 
 	return tree
 }
+
+// CopyAuthority 复制角色及其权限
+func CopyAuthority(c *gin.Context) {
+	id := c.Param("id")
+
+	var source system.SysAuthority
+	if err := global.DB.Where("authority_id = ?", id).First(&source).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"code": 404,
+			"msg":  "角色不存在",
+		})
+		return
+	}
+
+	var req struct {
+		AuthorityName string `json:"authorityName" binding:"required"`
+		AuthorityCode string `json:"authorityCode" binding:"required"`
+		ParentId      *uint  `json:"parentId"`
+		DefaultRouter string `json:"defaultRouter"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	// 未指定父角色时与源角色保持同级
+	if req.ParentId == nil {
+		req.ParentId = source.ParentId
+	}
+
+	authority := system.SysAuthority{
+		AuthorityName: req.AuthorityName,
+		AuthorityCode: req.AuthorityCode,
+		ParentId:      req.ParentId,
+		DefaultRouter: req.DefaultRouter,
+	}
+
+	skipped, err := authorityService.CopyAuthority(c, source, &authority)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "复制失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"msg":  "复制成功",
+		"data": gin.H{
+			"authority":      authority,
+			"skippedMenuIds": skipped,
+		},
+	})
+}
@@ -0,0 +1,51 @@
+package v1
+
+import (
+	"net/http"
+	system2 "server/model/system"
+	"server/service/system"
+
+	"github.com/gin-gonic/gin"
+)
+
+var entityChangeService = system.EntityChangeService{}
+
+// GetEntityChanges 获取实体的变更历史
+// @Tags      实体变更
+// @Summary   获取实体的变更历史
+// @Security  ApiKeyAuth
+// @accept    application/json
+// @Produce   application/json
+// @Param     data  query     system.EntityChangeRequest  true  "查询参数"
+// @Success   200   {object}  response.Response{data=system.EntityChangeResponse,msg=string}  "获取成功"
+// @Router    /system/entity-change/list [get]
+func GetEntityChanges(c *gin.Context) {
+	var req system2.EntityChangeRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	list, err := entityChangeService.GetEntityChanges(req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code": 500,
+			"msg":  "获取失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"msg":  "获取成功",
+		"data": gin.H{
+			"list":     list.List,
+			"total":    list.Total,
+			"page":     list.Page,
+			"pageSize": list.PageSize,
+		},
+	})
+}
@@ -0,0 +1,292 @@
+package v1
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"server/middleware"
+	system2 "server/model/system"
+	systemService "server/service/system"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+var fileService = systemService.FileService{}
+
+// GetFileList 获取文件列表
+// @Tags      文件管理
+// @Summary   获取文件列表
+// @Security  ApiKeyAuth
+// @Produce   application/json
+// @Param     data  query     system.FileRequest  true  "查询参数"
+// @Success   200   {object}  response.Response{data=map[string]interface{},msg=string}  "获取成功"
+// @Router    /system/file/list [get]
+func GetFileList(c *gin.Context) {
+	var req system2.FileRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	// 非管理员只能查看自己上传的文件
+	userID, _, _, ok := middleware.GetCurrentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code": 401,
+			"msg":  "未登录",
+		})
+		return
+	}
+	if !middleware.IsAdmin(c) {
+		req.UploaderId = userID
+	}
+
+	list, total, err := fileService.GetFileList(req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code": 500,
+			"msg":  "获取失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"msg":  "获取成功",
+		"data": gin.H{
+			"list":     list,
+			"total":    total,
+			"page":     req.Page,
+			"pageSize": req.PageSize,
+		},
+	})
+}
+
+// UploadFile 上传文件
+// @Tags      文件管理
+// @Summary   上传文件，受角色的文件存储配额限制，相同内容的文件只存储一份
+// @Security  ApiKeyAuth
+// @Accept    multipart/form-data
+// @Produce   application/json
+// @Param     file  formData  file  true  "文件"
+// @Success   200   {object}  response.Response{data=system.SysFile,msg=string}  "上传成功"
+// @Router    /system/file/upload [post]
+func UploadFile(c *gin.Context) {
+	userID, username, _, ok := middleware.GetCurrentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code": 401,
+			"msg":  "未登录",
+		})
+		return
+	}
+
+	// 预留表单字段的空间
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, systemService.FileMaxSize()+1<<20)
+	header, err := c.FormFile("file")
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code": 400,
+				"msg":  fmt.Sprintf("单个文件不能超过%dMB", systemService.FileMaxSize()>>20),
+			})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "请选择要上传的文件",
+		})
+		return
+	}
+
+	file, err := fileService.UploadFile(c.Request.Context(), header, userID, username)
+	if err != nil {
+		if errors.Is(err, systemService.ErrFileQuotaExceeded) || errors.Is(err, systemService.ErrFileTooLarge) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code": 400,
+				"msg":  err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code": 500,
+			"msg":  "上传失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"msg":  "上传成功",
+		"data": file,
+	})
+}
+
+// GetMyFileQuota 获取当前用户的文件存储配额
+// @Tags      文件管理
+// @Summary   获取当前用户的文件存储配额
+// @Security  ApiKeyAuth
+// @Produce   application/json
+// @Success   200  {object}  response.Response{data=system.FileQuota,msg=string}  "获取成功"
+// @Router    /system/file/quota [get]
+func GetMyFileQuota(c *gin.Context) {
+	userID, _, _, ok := middleware.GetCurrentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code": 401,
+			"msg":  "未登录",
+		})
+		return
+	}
+
+	quota, err := fileService.GetUserQuota(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code": 500,
+			"msg":  "获取失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"msg":  "获取成功",
+		"data": quota,
+	})
+}
+
+// DownloadFile 下载文件
+// @Tags      文件管理
+// @Summary   下载文件
+// @Security  ApiKeyAuth
+// @Produce   application/octet-stream
+// @Param     id  path  int  true  "文件ID"
+// @Success   200  {file}  file  "文件内容"
+// @Router    /system/file/{id}/download [get]
+func DownloadFile(c *gin.Context) {
+	id, ok := fileIdParam(c)
+	if !ok {
+		return
+	}
+
+	file, ok := accessibleFile(c, id)
+	if !ok {
+		return
+	}
+
+	reader, err := fileService.OpenFile(c.Request.Context(), file)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code": 500,
+			"msg":  "读取文件失败: " + err.Error(),
+		})
+		return
+	}
+	defer reader.Close()
+
+	c.Header("Content-Type", file.MimeType)
+	c.Header("Content-Length", strconv.FormatInt(file.Size, 10))
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename*=UTF-8''%s", url.PathEscape(file.Name)))
+	c.Status(http.StatusOK)
+
+	// 响应头已发送，传输中途出错只能记录日志
+	if _, err := io.Copy(c.Writer, reader); err != nil {
+		fmt.Printf("下载文件 %d 失败: %v\n", file.ID, err)
+	}
+}
+
+// DeleteFile 删除文件
+// @Tags      文件管理
+// @Summary   删除文件，没有其他记录引用同一内容时同时删除存储中的文件
+// @Security  ApiKeyAuth
+// @Produce   application/json
+// @Param     id  path  int  true  "文件ID"
+// @Success   200  {object}  response.Response{msg=string}  "删除成功"
+// @Router    /system/file/{id} [delete]
+func DeleteFile(c *gin.Context) {
+	id, ok := fileIdParam(c)
+	if !ok {
+		return
+	}
+
+	if _, ok := accessibleFile(c, id); !ok {
+		return
+	}
+
+	if err := fileService.DeleteFile(c.Request.Context(), id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"code": 404,
+				"msg":  "文件不存在",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code": 500,
+			"msg":  "删除失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"msg":  "删除成功",
+	})
+}
+
+// accessibleFile 获取当前用户可以访问的文件，只有上传人和管理员可以下载、删除
+func accessibleFile(c *gin.Context, id uint) (system2.SysFile, bool) {
+	userID, _, _, ok := middleware.GetCurrentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code": 401,
+			"msg":  "未登录",
+		})
+		return system2.SysFile{}, false
+	}
+
+	file, err := fileService.GetFileById(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"code": 404,
+				"msg":  "文件不存在",
+			})
+			return file, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code": 500,
+			"msg":  "获取文件失败: " + err.Error(),
+		})
+		return file, false
+	}
+	if file.UploaderId != userID && !middleware.IsAdmin(c) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"code": 403,
+			"msg":  "无权访问该文件",
+		})
+		return file, false
+	}
+	return file, true
+}
+
+// fileIdParam 解析路径中的文件ID，格式错误时直接返回400
+func fileIdParam(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "ID格式错误",
+		})
+		return 0, false
+	}
+	return uint(id), true
+}
@@ -0,0 +1,230 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+	"server/middleware"
+	system2 "server/model/system"
+	systemService "server/service/system"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// InitChunkUpload 初始化分片上传
+// @Tags      文件管理
+// @Summary   初始化分片上传，已有相同文件的未完成上传时返回该上传及已接收的分片，用于断点续传
+// @Security  ApiKeyAuth
+// @Accept    application/json
+// @Produce   application/json
+// @Param     data  body      system.ChunkInitRequest  true  "文件名、大小、SHA-256"
+// @Success   200   {object}  response.Response{data=system.ChunkUploadStatus,msg=string}  "初始化成功"
+// @Router    /system/file/chunk/init [post]
+func InitChunkUpload(c *gin.Context) {
+	userID, username, _, ok := middleware.GetCurrentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code": 401,
+			"msg":  "未登录",
+		})
+		return
+	}
+
+	var req system2.ChunkInitRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	status, err := fileService.InitChunkUpload(req, userID, username)
+	if err != nil {
+		chunkUploadError(c, "初始化失败", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"msg":  "初始化成功",
+		"data": status,
+	})
+}
+
+// GetChunkUpload 查询分片上传进度
+// @Tags      文件管理
+// @Summary   查询分片上传进度
+// @Security  ApiKeyAuth
+// @Produce   application/json
+// @Param     uploadId  path      string  true  "上传ID"
+// @Success   200       {object}  response.Response{data=system.ChunkUploadStatus,msg=string}  "获取成功"
+// @Router    /system/file/chunk/{uploadId} [get]
+func GetChunkUpload(c *gin.Context) {
+	userID, _, _, ok := middleware.GetCurrentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code": 401,
+			"msg":  "未登录",
+		})
+		return
+	}
+
+	status, err := fileService.GetChunkUpload(c.Param("uploadId"), userID)
+	if err != nil {
+		chunkUploadError(c, "获取失败", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"msg":  "获取成功",
+		"data": status,
+	})
+}
+
+// UploadChunk 上传分片
+// @Tags      文件管理
+// @Summary   上传分片，请求体为分片内容，X-Chunk-Checksum 为分片的SHA-256
+// @Security  ApiKeyAuth
+// @Accept    application/octet-stream
+// @Produce   application/json
+// @Param     uploadId          path      string  true  "上传ID"
+// @Param     index             path      int     true  "分片序号，从0开始"
+// @Param     X-Chunk-Checksum  header    string  true  "分片的SHA-256"
+// @Success   200               {object}  response.Response{msg=string}  "上传成功"
+// @Router    /system/file/chunk/{uploadId}/{index} [put]
+func UploadChunk(c *gin.Context) {
+	userID, _, _, ok := middleware.GetCurrentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code": 401,
+			"msg":  "未登录",
+		})
+		return
+	}
+
+	index, err := strconv.Atoi(c.Param("index"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "分片序号格式错误",
+		})
+		return
+	}
+	checksum := c.GetHeader("X-Chunk-Checksum")
+	if checksum == "" {
+		checksum = c.Query("checksum")
+	}
+	if checksum == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "缺少分片校验和",
+		})
+		return
+	}
+
+	body := http.MaxBytesReader(c.Writer, c.Request.Body, systemService.ChunkSize()+1)
+	if err := fileService.UploadChunk(c.Request.Context(), c.Param("uploadId"), userID, index, checksum, body); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			err = systemService.ErrChunkInvalid
+		}
+		chunkUploadError(c, "上传失败", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"msg":  "上传成功",
+	})
+}
+
+// CompleteChunkUpload 完成分片上传
+// @Tags      文件管理
+// @Summary   校验并合并分片，重复调用返回同一个文件
+// @Security  ApiKeyAuth
+// @Produce   application/json
+// @Param     uploadId  path      string  true  "上传ID"
+// @Success   200       {object}  response.Response{data=system.SysFile,msg=string}  "上传成功"
+// @Router    /system/file/chunk/{uploadId}/complete [post]
+func CompleteChunkUpload(c *gin.Context) {
+	userID, _, _, ok := middleware.GetCurrentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code": 401,
+			"msg":  "未登录",
+		})
+		return
+	}
+
+	file, err := fileService.CompleteChunkUpload(c.Request.Context(), c.Param("uploadId"), userID)
+	if err != nil {
+		chunkUploadError(c, "合并失败", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"msg":  "上传成功",
+		"data": file,
+	})
+}
+
+// AbortChunkUpload 取消分片上传
+// @Tags      文件管理
+// @Summary   取消分片上传并删除已上传的分片
+// @Security  ApiKeyAuth
+// @Produce   application/json
+// @Param     uploadId  path      string  true  "上传ID"
+// @Success   200       {object}  response.Response{msg=string}  "取消成功"
+// @Router    /system/file/chunk/{uploadId} [delete]
+func AbortChunkUpload(c *gin.Context) {
+	userID, _, _, ok := middleware.GetCurrentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code": 401,
+			"msg":  "未登录",
+		})
+		return
+	}
+
+	if err := fileService.AbortChunkUpload(c.Request.Context(), c.Param("uploadId"), userID); err != nil {
+		chunkUploadError(c, "取消失败", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"msg":  "取消成功",
+	})
+}
+
+// chunkUploadError 按错误类型返回分片上传的错误响应
+func chunkUploadError(c *gin.Context, prefix string, err error) {
+	switch {
+	case errors.Is(err, systemService.ErrUploadNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"code": 404,
+			"msg":  err.Error(),
+		})
+	case errors.Is(err, systemService.ErrUploadMerging):
+		c.JSON(http.StatusConflict, gin.H{
+			"code": 409,
+			"msg":  err.Error(),
+		})
+	case errors.Is(err, systemService.ErrChunkInvalid),
+		errors.Is(err, systemService.ErrUploadIncomplete),
+		errors.Is(err, systemService.ErrFileTooLarge),
+		errors.Is(err, systemService.ErrFileQuotaExceeded):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  err.Error(),
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code": 500,
+			"msg":  prefix + ": " + err.Error(),
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package v1
+
+import (
+	"net/http"
+	systemService "server/service/system"
+
+	"github.com/gin-gonic/gin"
+)
+
+var healthService = systemService.HealthService{}
+
+// HealthLive 存活检查，进程能处理请求即返回成功，不检查外部依赖
+// @Tags      健康检查
+// @Summary   存活检查
+// @Produce   application/json
+// @Success   200  {object}  map[string]interface{}  "存活"
+// @Router    /health/live [get]
+func HealthLive(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"message": "ok",
+		"status":  "running",
+	})
+}
+
+// HealthReady 就绪检查，检查数据库、Redis、表迁移和上传目录，任一失败返回503
+// @Tags      健康检查
+// @Summary   就绪检查
+// @Produce   application/json
+// @Success   200  {object}  response.Response{data=systemService.HealthReport,msg=string}  "就绪"
+// @Failure   503  {object}  response.Response{data=systemService.HealthReport,msg=string}  "未就绪"
+// @Router    /health/ready [get]
+func HealthReady(c *gin.Context) {
+	report := healthService.CheckReadiness(c.Request.Context())
+	if report.Status != systemService.HealthStatusUp {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"code": 503,
+			"msg":  "服务未就绪",
+			"data": report,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"msg":  "服务就绪",
+		"data": report,
+	})
+}
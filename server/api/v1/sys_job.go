@@ -0,0 +1,337 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+	system2 "server/model/system"
+	"server/service/system"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+var jobService = system.JobService{}
+
+// GetJobList 获取定时任务列表
+// @Tags      定时任务
+// @Summary   获取定时任务列表
+// @Security  ApiKeyAuth
+// @Produce   application/json
+// @Param     data  query     system.JobRequest  true  "查询参数"
+// @Success   200   {object}  response.Response{data=map[string]interface{},msg=string}  "获取成功"
+// @Router    /system/job/list [get]
+func GetJobList(c *gin.Context) {
+	var req system2.JobRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	list, total, err := jobService.GetJobList(req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code": 500,
+			"msg":  "获取失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"msg":  "获取成功",
+		"data": gin.H{
+			"list":     list,
+			"total":    total,
+			"page":     req.Page,
+			"pageSize": req.PageSize,
+		},
+	})
+}
+
+// GetJobTypes 获取已注册的任务类型
+// @Tags      定时任务
+// @Summary   获取已注册的任务类型
+// @Security  ApiKeyAuth
+// @Produce   application/json
+// @Success   200  {object}  response.Response{data=[]system.JobType,msg=string}  "获取成功"
+// @Router    /system/job/types [get]
+func GetJobTypes(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"msg":  "获取成功",
+		"data": system.GetJobTypes(),
+	})
+}
+
+// GetJobLogs 获取定时任务执行记录
+// @Tags      定时任务
+// @Summary   获取定时任务执行记录
+// @Security  ApiKeyAuth
+// @Produce   application/json
+// @Param     data  query     system.JobLogRequest  true  "查询参数"
+// @Success   200   {object}  response.Response{data=map[string]interface{},msg=string}  "获取成功"
+// @Router    /system/job/logs [get]
+func GetJobLogs(c *gin.Context) {
+	var req system2.JobLogRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	list, total, err := jobService.GetJobLogs(req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code": 500,
+			"msg":  "获取失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"msg":  "获取成功",
+		"data": gin.H{
+			"list":     list,
+			"total":    total,
+			"page":     req.Page,
+			"pageSize": req.PageSize,
+		},
+	})
+}
+
+// GetJobById 根据ID获取定时任务
+// @Tags      定时任务
+// @Summary   根据ID获取定时任务
+// @Security  ApiKeyAuth
+// @Produce   application/json
+// @Param     id   path      int  true  "任务ID"
+// @Success   200  {object}  response.Response{data=system.SysJob,msg=string}  "获取成功"
+// @Router    /system/job/{id} [get]
+func GetJobById(c *gin.Context) {
+	id, ok := jobIdParam(c)
+	if !ok {
+		return
+	}
+
+	job, err := jobService.GetJobById(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"code": 404,
+			"msg":  "任务不存在",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"msg":  "获取成功",
+		"data": job,
+	})
+}
+
+// CreateJob 创建定时任务
+// @Tags      定时任务
+// @Summary   创建定时任务
+// @Security  ApiKeyAuth
+// @accept    application/json
+// @Produce   application/json
+// @Param     data  body      system.SysJob  true  "任务名称、类型、cron表达式、参数、是否启用"
+// @Success   200   {object}  response.Response{data=system.SysJob,msg=string}  "创建成功"
+// @Router    /system/job [post]
+func CreateJob(c *gin.Context) {
+	var job system2.SysJob
+	if err := c.ShouldBindJSON(&job); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	if err := jobService.CreateJob(&job); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "创建失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"msg":  "创建成功",
+		"data": job,
+	})
+}
+
+// UpdateJob 更新定时任务
+// @Tags      定时任务
+// @Summary   更新定时任务
+// @Security  ApiKeyAuth
+// @accept    application/json
+// @Produce   application/json
+// @Param     id    path      int            true  "任务ID"
+// @Param     data  body      system.SysJob  true  "任务名称、类型、cron表达式、参数、是否启用"
+// @Success   200   {object}  response.Response{data=system.SysJob,msg=string}  "更新成功"
+// @Router    /system/job/{id} [put]
+func UpdateJob(c *gin.Context) {
+	id, ok := jobIdParam(c)
+	if !ok {
+		return
+	}
+
+	var data system2.SysJob
+	if err := c.ShouldBindJSON(&data); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	job, err := jobService.UpdateJob(id, data)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "更新失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"msg":  "更新成功",
+		"data": job,
+	})
+}
+
+// DeleteJob 删除定时任务
+// @Tags      定时任务
+// @Summary   删除定时任务
+// @Security  ApiKeyAuth
+// @Produce   application/json
+// @Param     id   path      int  true  "任务ID"
+// @Success   200  {object}  response.Response{msg=string}  "删除成功"
+// @Router    /system/job/{id} [delete]
+func DeleteJob(c *gin.Context) {
+	id, ok := jobIdParam(c)
+	if !ok {
+		return
+	}
+
+	if err := jobService.DeleteJob(id); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code": 500,
+			"msg":  "删除失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"msg":  "删除成功",
+	})
+}
+
+// PauseJob 暂停定时任务
+// @Tags      定时任务
+// @Summary   暂停定时任务
+// @Security  ApiKeyAuth
+// @Produce   application/json
+// @Param     id   path      int  true  "任务ID"
+// @Success   200  {object}  response.Response{data=system.SysJob,msg=string}  "已暂停"
+// @Router    /system/job/{id}/pause [post]
+func PauseJob(c *gin.Context) {
+	setJobEnabled(c, false, "已暂停")
+}
+
+// ResumeJob 恢复定时任务
+// @Tags      定时任务
+// @Summary   恢复定时任务
+// @Security  ApiKeyAuth
+// @Produce   application/json
+// @Param     id   path      int  true  "任务ID"
+// @Success   200  {object}  response.Response{data=system.SysJob,msg=string}  "已恢复"
+// @Router    /system/job/{id}/resume [post]
+func ResumeJob(c *gin.Context) {
+	setJobEnabled(c, true, "已恢复")
+}
+
+func setJobEnabled(c *gin.Context, enabled bool, msg string) {
+	id, ok := jobIdParam(c)
+	if !ok {
+		return
+	}
+
+	job, err := jobService.SetJobEnabled(id, enabled)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code": 500,
+			"msg":  "操作失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"msg":  msg,
+		"data": job,
+	})
+}
+
+// RunJob 立即执行一次定时任务
+// @Tags      定时任务
+// @Summary   立即执行一次定时任务
+// @Security  ApiKeyAuth
+// @Produce   application/json
+// @Param     id   path      int  true  "任务ID"
+// @Success   200  {object}  response.Response{data=system.SysJobLog,msg=string}  "已触发"
+// @Router    /system/job/{id}/run [post]
+func RunJob(c *gin.Context) {
+	id, ok := jobIdParam(c)
+	if !ok {
+		return
+	}
+
+	operator, ok := currentOperator(c)
+	if !ok {
+		return
+	}
+
+	log, err := jobService.RunJob(id, operator)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, system.ErrJobRunning) {
+			status = http.StatusConflict
+		}
+		c.JSON(status, gin.H{
+			"code": status,
+			"msg":  "执行失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"msg":  "已触发",
+		"data": log,
+	})
+}
+
+// jobIdParam 解析路径中的任务ID，格式错误时直接返回400
+func jobIdParam(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "ID格式错误",
+		})
+		return 0, false
+	}
+	return uint(id), true
+}
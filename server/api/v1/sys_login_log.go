@@ -0,0 +1,156 @@
+package v1
+
+import (
+	"fmt"
+	"net/http"
+	"server/middleware"
+	system2 "server/model/system"
+	systemService "server/service/system"
+	"server/utils"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+var loginLogService = systemService.LoginLogService{}
+
+// GetLoginLogList 获取登录日志列表
+// @Tags      登录日志
+// @Summary   获取登录日志列表
+// @Security  ApiKeyAuth
+// @Produce   application/json
+// @Param     data  query     system.LoginLogRequest  true  "查询参数"
+// @Success   200   {object}  response.Response{data=map[string]interface{},msg=string}  "获取成功"
+// @Router    /system/login-log/list [get]
+func GetLoginLogList(c *gin.Context) {
+	var req system2.LoginLogRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	list, total, err := loginLogService.GetLoginLogList(req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code": 500,
+			"msg":  "获取失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"msg":  "获取成功",
+		"data": gin.H{
+			"list":     list,
+			"total":    total,
+			"page":     req.Page,
+			"pageSize": req.PageSize,
+		},
+	})
+}
+
+// ExportLoginLogs 导出登录日志
+// @Tags      登录日志
+// @Summary   导出登录日志
+// @Security  ApiKeyAuth
+// @Produce   application/octet-stream
+// @Param     data  query     system.LoginLogExportRequest  true  "查询参数"
+// @Success   200   {file}    file  "导出文件"
+// @Router    /system/login-log/export [get]
+func ExportLoginLogs(c *gin.Context) {
+	var req system2.LoginLogExportRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	if req.Format == "" {
+		req.Format = utils.TableFormatCSV
+	}
+	if req.Format != utils.TableFormatCSV && req.Format != utils.TableFormatXLSX {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "只支持 csv、xlsx 格式",
+		})
+		return
+	}
+
+	extendExportDeadline(c)
+	c.Header("Content-Type", utils.TableContentType(req.Format))
+	c.Header("Content-Disposition", "attachment; filename=login_logs."+req.Format)
+	c.Status(http.StatusOK)
+
+	// 响应头已发送，导出中途出错只能记录日志
+	if err := loginLogService.ExportLoginLogs(req.LoginLogRequest, req.Format, c.Writer); err != nil {
+		fmt.Printf("导出登录日志失败: %v\n", err)
+	}
+}
+
+// GetLoginStats 获取登录统计
+// @Tags      登录日志
+// @Summary   获取登录统计（每日成功/失败次数、失败最多的用户名和IP）
+// @Security  ApiKeyAuth
+// @Produce   application/json
+// @Param     days  query     int  false  "统计天数，默认7天"
+// @Success   200   {object}  response.Response{data=map[string]interface{},msg=string}  "获取成功"
+// @Router    /system/login-log/stats [get]
+func GetLoginStats(c *gin.Context) {
+	days, _ := strconv.Atoi(c.DefaultQuery("days", "7"))
+
+	stats, err := loginLogService.GetLoginStats(days)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code": 500,
+			"msg":  "获取失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"msg":  "获取成功",
+		"data": stats,
+	})
+}
+
+// GetMyLoginLogs 获取当前用户最近的登录记录
+// @Tags      登录日志
+// @Summary   获取当前用户最近的登录记录
+// @Security  ApiKeyAuth
+// @Produce   application/json
+// @Param     limit  query     int  false  "条数，默认20"
+// @Success   200    {object}  response.Response{data=[]system.SysLoginLog,msg=string}  "获取成功"
+// @Router    /system/login-log/mine [get]
+func GetMyLoginLogs(c *gin.Context) {
+	userID, _, _, ok := middleware.GetCurrentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code": 401,
+			"msg":  "未登录",
+		})
+		return
+	}
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	logs, err := loginLogService.GetUserLoginLogs(userID, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code": 500,
+			"msg":  "获取失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"msg":  "获取成功",
+		"data": logs,
+	})
+}
@@ -20,7 +20,7 @@ func CreateMenu(c *gin.Context) {
 		return
 	}
 
-	if err := global.DB.Create(&menu).Error; err != nil {
+	if err := global.DB.WithContext(c).Create(&menu).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"code": 500,
 			"msg":  "创建菜单失败: " + err.Error(),
@@ -122,7 +122,7 @@ func UpdateMenu(c *gin.Context) {
 		return
 	}
 
-	if err := global.DB.Model(&menu).Updates(updateData).Error; err != nil {
+	if err := global.DB.WithContext(c).Model(&menu).Updates(updateData).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"code": 500,
 			"msg":  "更新失败: " + err.Error(),
@@ -160,7 +160,7 @@ func DeleteMenu(c *gin.Context) {
 		return
 	}
 
-	if err := global.DB.Delete(&menu).Error; err != nil {
+	if err := global.DB.WithContext(c).Delete(&menu).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"code": 500,
 			"msg":  "删除失败: " + err.Error(),
@@ -0,0 +1,25 @@
+package v1
+
+import (
+	"net/http"
+	systemService "server/service/system"
+
+	"github.com/gin-gonic/gin"
+)
+
+var monitorService = systemService.MonitorService{}
+
+// GetServerMonitor 获取服务监控信息
+// @Tags      服务监控
+// @Summary   获取构建信息、运行时状态、主机资源、数据库连接池和Redis状态
+// @Security  ApiKeyAuth
+// @Produce   application/json
+// @Success   200  {object}  response.Response{data=system.ServerMonitor,msg=string}  "获取成功"
+// @Router    /system/monitor/server [get]
+func GetServerMonitor(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"msg":  "获取成功",
+		"data": monitorService.GetServerMonitor(),
+	})
+}
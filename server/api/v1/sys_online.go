@@ -0,0 +1,140 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+	"server/middleware"
+	system2 "server/model/system"
+	systemService "server/service/system"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+var sessionService = systemService.SessionService{}
+
+// GetOnlineUsers 获取在线用户列表
+// @Tags      在线用户
+// @Summary   获取在线用户列表
+// @Security  ApiKeyAuth
+// @Produce   application/json
+// @Param     data  query     system.OnlineUserRequest  true  "查询参数"
+// @Success   200   {object}  response.Response{data=map[string]interface{},msg=string}  "获取成功"
+// @Router    /system/online/list [get]
+func GetOnlineUsers(c *gin.Context) {
+	var req system2.OnlineUserRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	// 非管理员只能查看自己的会话
+	userID, _, _, ok := middleware.GetCurrentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code": 401,
+			"msg":  "未登录",
+		})
+		return
+	}
+	if !middleware.IsAdmin(c) {
+		req.UserId = userID
+	}
+
+	list, total, err := sessionService.GetOnlineSessions(req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code": 500,
+			"msg":  "获取失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"msg":  "获取成功",
+		"data": gin.H{
+			"list":     list,
+			"total":    total,
+			"page":     req.Page,
+			"pageSize": req.PageSize,
+		},
+	})
+}
+
+// KickSession 踢出指定会话
+// @Tags      在线用户
+// @Summary   踢出指定会话
+// @Security  ApiKeyAuth
+// @Produce   application/json
+// @Param     sessionId  path      string  true  "会话ID"
+// @Success   200        {object}  response.Response{msg=string}  "踢出成功"
+// @Router    /system/online/{sessionId} [delete]
+func KickSession(c *gin.Context) {
+	operator, ok := currentOperator(c)
+	if !ok {
+		return
+	}
+
+	err := sessionService.RevokeSession(c.Param("sessionId"), systemService.SessionRevokeKicked, operator)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, systemService.ErrSessionInvalid) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{
+			"code": status,
+			"msg":  "踢出失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"msg":  "踢出成功",
+	})
+}
+
+// KickUser 踢出用户的全部会话
+// @Tags      在线用户
+// @Summary   踢出用户的全部会话
+// @Security  ApiKeyAuth
+// @Produce   application/json
+// @Param     userId  path      int  true  "用户ID"
+// @Success   200     {object}  response.Response{data=map[string]interface{},msg=string}  "踢出成功"
+// @Router    /system/online/user/{userId} [delete]
+func KickUser(c *gin.Context) {
+	userId, err := strconv.ParseUint(c.Param("userId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "ID格式错误",
+		})
+		return
+	}
+
+	operator, ok := currentOperator(c)
+	if !ok {
+		return
+	}
+
+	count, err := sessionService.RevokeUserSessions(uint(userId), systemService.SessionRevokeKicked, operator)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code": 500,
+			"msg":  "踢出失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"msg":  "踢出成功",
+		"data": gin.H{
+			"count": count,
+		},
+	})
+}
@@ -1,11 +1,16 @@
 package v1
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
+	"server/global"
+	"server/middleware"
 	system2 "server/model/system"
 	"server/service/system"
+	"server/utils"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -90,52 +95,87 @@ func GetOperationLogById(c *gin.Context) {
 }
 
 // DeleteOperationLog 删除操作日志
+// 操作日志通过哈希链防篡改，不允许删除单条日志，只能按时间归档清理
 // @Tags      操作日志
-// @Summary   删除操作日志
+// @Summary   删除操作日志（已禁用）
 // @Security  ApiKeyAuth
 // @accept    application/json
 // @Produce   application/json
 // @Param     id   path      int             true  "操作日志ID"
-// @Success   200  {object}  response.Response{msg=string}  "删除成功"
+// @Success   403  {object}  response.Response{msg=string}  "不允许删除"
 // @Router    /system/operation-log/{id} [delete]
 func DeleteOperationLog(c *gin.Context) {
-	idStr := c.Param("id")
-	id, err := strconv.ParseUint(idStr, 10, 32)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"code": 400,
-			"msg":  "ID格式错误",
-		})
+	rejectOperationLogDeletion(c)
+}
+
+// DeleteOperationLogsByIds 批量删除操作日志
+// @Tags      操作日志
+// @Summary   批量删除操作日志（已禁用）
+// @Security  ApiKeyAuth
+// @accept    application/json
+// @Produce   application/json
+// @Param     data  body      request.IdsReq  true  "操作日志ID列表"
+// @Success   403   {object}  response.Response{msg=string}  "不允许删除"
+// @Router    /system/operation-log/batch [delete]
+func DeleteOperationLogsByIds(c *gin.Context) {
+	rejectOperationLogDeletion(c)
+}
+
+// rejectOperationLogDeletion 拒绝删除单条或部分操作日志
+func rejectOperationLogDeletion(c *gin.Context) {
+	c.JSON(http.StatusForbidden, gin.H{
+		"code": 403,
+		"msg":  "操作日志受哈希链保护，不支持单独删除，请使用清理功能归档后清理",
+	})
+}
+
+// ClearOperationLogs 归档并清空所有操作日志
+// @Tags      操作日志
+// @Summary   归档并清空所有操作日志
+// @Security  ApiKeyAuth
+// @accept    application/json
+// @Produce   application/json
+// @Success   200  {object}  response.Response{data=system.SysOperationLogCheckpoint,msg=string}  "清空成功"
+// @Router    /system/operation-log/clear [delete]
+func ClearOperationLogs(c *gin.Context) {
+	operator, ok := currentOperator(c)
+	if !ok {
 		return
 	}
 
-	err = operationLogService.DeleteOperationLog(uint(id))
+	checkpoint, err := operationLogService.ArchiveOperationLogs(nil, operator, "清空操作日志")
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"code": 500,
-			"msg":  "删除失败: " + err.Error(),
+			"msg":  "清空失败: " + err.Error(),
 		})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"code": 0,
-		"msg":  "删除成功",
+		"msg":  "清空成功",
+		"data": checkpoint,
 	})
 }
 
-// DeleteOperationLogsByIds 批量删除操作日志
+// ClearOperationLogsByDays 归档并清理指定天数前的操作日志
 // @Tags      操作日志
-// @Summary   批量删除操作日志
+// @Summary   归档并清理指定天数前的操作日志
 // @Security  ApiKeyAuth
 // @accept    application/json
 // @Produce   application/json
-// @Param     data  body      request.IdsReq  true  "操作日志ID列表"
-// @Success   200   {object}  response.Response{msg=string}  "删除成功"
-// @Router    /system/operation-log/batch [delete]
-func DeleteOperationLogsByIds(c *gin.Context) {
+// @Param     data  body      request.DaysReq  true  "保留天数"
+// @Success   200   {object}  response.Response{data=system.SysOperationLogCheckpoint,msg=string}  "清理成功"
+// @Router    /system/operation-log/clear-by-days [delete]
+func ClearOperationLogsByDays(c *gin.Context) {
+	operator, ok := currentOperator(c)
+	if !ok {
+		return
+	}
+
 	var req struct {
-		Ids []uint `json:"ids" binding:"required"`
+		Days int `json:"days" binding:"required,min=1"`
 	}
 	err := c.ShouldBindJSON(&req)
 	if err != nil {
@@ -146,82 +186,84 @@ func DeleteOperationLogsByIds(c *gin.Context) {
 		return
 	}
 
-	err = operationLogService.DeleteOperationLogsByIds(req.Ids)
+	cutoff := time.Now().AddDate(0, 0, -req.Days)
+	checkpoint, err := operationLogService.ArchiveOperationLogs(&cutoff, operator, fmt.Sprintf("清理%d天前的操作日志", req.Days))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"code": 500,
-			"msg":  "删除失败: " + err.Error(),
+			"msg":  "清理失败: " + err.Error(),
 		})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"code": 0,
-		"msg":  "删除成功",
+		"msg":  "清理成功",
+		"data": checkpoint,
 	})
 }
 
-// ClearOperationLogs 清空所有操作日志
+// VerifyOperationLogs 校验操作日志哈希链
 // @Tags      操作日志
-// @Summary   清空所有操作日志
+// @Summary   校验操作日志哈希链
 // @Security  ApiKeyAuth
-// @accept    application/json
 // @Produce   application/json
-// @Success   200  {object}  response.Response{msg=string}  "清空成功"
-// @Router    /system/operation-log/clear [delete]
-func ClearOperationLogs(c *gin.Context) {
-	err := operationLogService.ClearOperationLogs()
+// @Success   200  {object}  response.Response{data=system.OperationLogVerifyReport,msg=string}  "校验完成"
+// @Router    /system/operation-log/verify [get]
+func VerifyOperationLogs(c *gin.Context) {
+	report, err := operationLogService.VerifyOperationLogChain()
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"code": 500,
-			"msg":  "清空失败: " + err.Error(),
+			"msg":  "校验失败: " + err.Error(),
 		})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"code": 0,
-		"msg":  "清空成功",
+		"msg":  "校验完成",
+		"data": report,
 	})
 }
 
-// ClearOperationLogsByDays 清理指定天数前的操作日志
+// GetOperationLogCheckpoints 获取操作日志归档检查点
 // @Tags      操作日志
-// @Summary   清理指定天数前的操作日志
+// @Summary   获取操作日志归档检查点
 // @Security  ApiKeyAuth
-// @accept    application/json
 // @Produce   application/json
-// @Param     data  body      request.DaysReq  true  "保留天数"
-// @Success   200   {object}  response.Response{msg=string}  "清理成功"
-// @Router    /system/operation-log/clear-by-days [delete]
-func ClearOperationLogsByDays(c *gin.Context) {
-	var req struct {
-		Days int `json:"days" binding:"required,min=1"`
-	}
-	err := c.ShouldBindJSON(&req)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"code": 400,
-			"msg":  "参数错误: " + err.Error(),
-		})
-		return
-	}
-
-	err = operationLogService.ClearOperationLogsByDays(req.Days)
+// @Success   200  {object}  response.Response{data=[]system.SysOperationLogCheckpoint,msg=string}  "获取成功"
+// @Router    /system/operation-log/checkpoints [get]
+func GetOperationLogCheckpoints(c *gin.Context) {
+	checkpoints, err := operationLogService.GetOperationLogCheckpoints()
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"code": 500,
-			"msg":  "清理失败: " + err.Error(),
+			"msg":  "获取失败: " + err.Error(),
 		})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"code": 0,
-		"msg":  "清理成功",
+		"msg":  "获取成功",
+		"data": checkpoints,
 	})
 }
 
+// currentOperator 获取当前操作人用户名，未登录时返回401
+func currentOperator(c *gin.Context) (string, bool) {
+	_, username, _, ok := middleware.GetCurrentUser(c)
+	if !ok || username == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code": 401,
+			"msg":  "未登录或登录已过期",
+		})
+		return "", false
+	}
+	return username, true
+}
+
 // GetOperationStats 获取操作统计信息
 // @Tags      操作日志
 // @Summary   获取操作统计信息
@@ -252,12 +294,12 @@ func GetOperationStats(c *gin.Context) {
 // @Summary   导出操作日志
 // @Security  ApiKeyAuth
 // @accept    application/json
-// @Produce   application/json
-// @Param     data  query     system.OperationLogRequest  true  "查询参数"
-// @Success   200   {object}  response.Response{msg=string}  "导出成功"
+// @Produce   application/octet-stream
+// @Param     data  query     system.OperationLogExportRequest  true  "查询参数"
+// @Success   200   {file}    file  "导出文件"
 // @Router    /system/operation-log/export [get]
 func ExportOperationLogs(c *gin.Context) {
-	var req system2.OperationLogRequest
+	var req system2.OperationLogExportRequest
 	err := c.ShouldBindQuery(&req)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
@@ -267,43 +309,197 @@ func ExportOperationLogs(c *gin.Context) {
 		return
 	}
 
-	// 设置大的页面大小以获取所有数据
-	req.PageSize = 10000
-	req.Page = 1
+	if req.Format == "" {
+		req.Format = utils.TableFormatCSV
+	}
+	if req.Format != utils.TableFormatCSV && req.Format != utils.TableFormatXLSX && req.Format != system.OperationLogFormatNDJSON {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "只支持 csv、xlsx、ndjson 格式",
+		})
+		return
+	}
 
-	list, err := operationLogService.GetOperationLogList(req)
+	extendExportDeadline(c)
+	c.Header("Content-Type", system.OperationLogExportContentType(req.Format))
+	c.Header("Content-Disposition", "attachment; filename=operation_logs."+req.Format)
+	c.Status(http.StatusOK)
+
+	// 响应头已发送，导出中途出错只能记录日志
+	if err := operationLogService.ExportOperationLogs(req.OperationLogRequest, req.Format, req.IncludeBody, c.Writer); err != nil {
+		fmt.Printf("导出操作日志失败: %v\n", err)
+	}
+}
+
+// exportWriteTimeout 流式导出的写超时，导出不限行数，耗时可能超过服务默认的写超时
+const exportWriteTimeout = 30 * time.Minute
+
+// extendExportDeadline 延长流式导出的写超时，避免文件写到一半被服务的 WriteTimeout 截断
+func extendExportDeadline(c *gin.Context) {
+	if err := http.NewResponseController(c.Writer).SetWriteDeadline(time.Now().Add(exportWriteTimeout)); err != nil {
+		fmt.Printf("延长导出写超时失败: %v\n", err)
+	}
+}
+
+// GetOperationRoutes 获取已登记操作日志的路由及其模块、操作类型
+// @Tags      操作日志
+// @Summary   获取已登记操作日志的路由
+// @Security  ApiKeyAuth
+// @Produce   application/json
+// @Success   200   {object}  response.Response{data=[]middleware.OperationRoute,msg=string}  "获取成功"
+// @Router    /system/operation-log/routes [get]
+func GetOperationRoutes(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"data": middleware.GetOperationRoutes(),
+		"msg":  "获取成功",
+	})
+}
+
+// GetOperationLogRetention 预览保留策略将要归档清理的日志
+// @Tags      操作日志
+// @Summary   预览保留策略
+// @Security  ApiKeyAuth
+// @Produce   application/json
+// @Param     keepDays  query     int  false  "保留天数，默认使用配置"
+// @Param     maxRows   query     int  false  "最大保留条数，默认使用配置"
+// @Success   200       {object}  response.Response{data=system.OperationLogRetentionReport,msg=string}  "获取成功"
+// @Router    /system/operation-log/retention [get]
+func GetOperationLogRetention(c *gin.Context) {
+	operator, ok := currentOperator(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		KeepDays *int `form:"keepDays" binding:"omitempty,min=0"`
+		MaxRows  *int `form:"maxRows" binding:"omitempty,min=0"`
+	}
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	policy := global.CONFIG.OperationLog.Retention
+	if req.KeepDays != nil {
+		policy.KeepDays = *req.KeepDays
+	}
+	if req.MaxRows != nil {
+		policy.MaxRows = *req.MaxRows
+	}
+
+	report, err := operationLogService.RunOperationLogRetention(policy, true, operator)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code": 500,
+			"msg":  "获取失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"msg":  "获取成功",
+		"data": report,
+	})
+}
+
+// RunOperationLogRetention 按配置的保留策略立即执行归档清理
+// @Tags      操作日志
+// @Summary   执行保留策略
+// @Security  ApiKeyAuth
+// @accept    application/json
+// @Produce   application/json
+// @Param     data  body      object  false  "dryRun: 只预览不执行"
+// @Success   200   {object}  response.Response{data=system.OperationLogRetentionReport,msg=string}  "执行成功"
+// @Router    /system/operation-log/retention/run [post]
+func RunOperationLogRetention(c *gin.Context) {
+	operator, ok := currentOperator(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		DryRun bool `json:"dryRun"`
+	}
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code": 400,
+				"msg":  "参数错误: " + err.Error(),
+			})
+			return
+		}
+	}
+
+	report, err := operationLogService.RunOperationLogRetention(global.CONFIG.OperationLog.Retention, req.DryRun, operator)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"code": 500,
-			"msg":  "导出失败: " + err.Error(),
+			"msg":  "执行失败: " + err.Error(),
 		})
 		return
 	}
 
-	// 设置响应头为CSV文件
-	c.Header("Content-Type", "text/csv")
-	c.Header("Content-Disposition", "attachment; filename=operation_logs.csv")
-
-	// 写入CSV头部
-	c.Writer.WriteString("\xEF\xBB\xBF") // UTF-8 BOM
-	c.Writer.WriteString("ID,用户名,操作类型,请求方法,请求路径,操作描述,状态码,IP地址,耗时(ms),操作时间,错误信息\n")
-
-	// 写入数据
-	for _, log := range list.List {
-		c.Writer.WriteString(fmt.Sprintf("%d,%s,%s,%s,%s,%s,%d,%s,%d,%s,%s\n",
-			log.ID,
-			log.Username,
-			log.OperationType,
-			log.Method,
-			log.Path,
-			log.Description,
-			log.Status,
-			log.IP,
-			log.Latency,
-			log.OperationTime.Format("2006-01-02 15:04:05"),
-			log.ErrorMessage,
-		))
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"msg":  "执行成功",
+		"data": report,
+	})
+}
+
+// GetOperationLogArchives 获取操作日志归档文件列表
+// @Tags      操作日志
+// @Summary   获取操作日志归档文件列表
+// @Security  ApiKeyAuth
+// @Produce   application/json
+// @Success   200  {object}  response.Response{data=[]system.OperationLogArchive,msg=string}  "获取成功"
+// @Router    /system/operation-log/archives [get]
+func GetOperationLogArchives(c *gin.Context) {
+	archives, err := operationLogService.ListOperationLogArchives()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code": 500,
+			"msg":  "获取失败: " + err.Error(),
+		})
+		return
 	}
 
-	c.Status(http.StatusOK)
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"msg":  "获取成功",
+		"data": archives,
+	})
+}
+
+// DownloadOperationLogArchive 下载操作日志归档文件
+// @Tags      操作日志
+// @Summary   下载操作日志归档文件
+// @Security  ApiKeyAuth
+// @Produce   application/gzip
+// @Param     name  path      string  true  "归档文件名"
+// @Success   200   {file}    file    "归档文件"
+// @Router    /system/operation-log/archives/{name}/download [get]
+func DownloadOperationLogArchive(c *gin.Context) {
+	name := c.Param("name")
+	reader, size, err := operationLogService.OpenOperationLogArchive(c.Request.Context(), name)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, system.ErrOperationLogArchiveNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{
+			"code": status,
+			"msg":  err.Error(),
+		})
+		return
+	}
+	defer reader.Close()
+
+	c.DataFromReader(http.StatusOK, size, "application/gzip", reader, map[string]string{
+		"Content-Disposition": "attachment; filename=" + name,
+	})
 }
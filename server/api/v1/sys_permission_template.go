@@ -0,0 +1,307 @@
+package v1
+
+import (
+	"net/http"
+	"server/global"
+	"server/model/system"
+	systemService "server/service/system"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+var permissionTemplateService = systemService.PermissionTemplateService{}
+
+// CreatePermissionTemplate 创建权限模板
+func CreatePermissionTemplate(c *gin.Context) {
+	var req struct {
+		Name            string `json:"name" binding:"required"`
+		Description     string `json:"description"`
+		MenuIds         []uint `json:"menuIds"`
+		FromAuthorityId uint   `json:"fromAuthorityId"` // 以指定角色的当前权限创建模板
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	// 检查模板名称是否已存在
+	var existTemplate system.SysPermissionTemplate
+	if err := global.DB.Where("name = ?", req.Name).First(&existTemplate).Error; err == nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "模板名称已存在",
+		})
+		return
+	}
+
+	menuIds := req.MenuIds
+	if req.FromAuthorityId != 0 {
+		ids, err := authorityService.LoadAuthorityMenuIds(global.DB, req.FromAuthorityId)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"code": 500,
+				"msg":  "查询角色权限失败: " + err.Error(),
+			})
+			return
+		}
+		menuIds = ids
+	}
+
+	if err := permissionTemplateService.CheckMenusExist(menuIds); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  err.Error(),
+		})
+		return
+	}
+
+	template := system.SysPermissionTemplate{
+		Name:        req.Name,
+		Description: req.Description,
+	}
+	err := global.DB.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&template).Error; err != nil {
+			return err
+		}
+		return permissionTemplateService.SetTemplateMenus(tx, template.ID, menuIds)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code": 500,
+			"msg":  "创建模板失败: " + err.Error(),
+		})
+		return
+	}
+
+	template.MenuIds = menuIds
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"msg":  "创建成功",
+		"data": template,
+	})
+}
+
+// GetPermissionTemplateList 获取权限模板列表
+func GetPermissionTemplateList(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "10"))
+	name := c.Query("name")
+
+	var templates []system.SysPermissionTemplate
+	var total int64
+
+	db := global.DB.Model(&system.SysPermissionTemplate{})
+
+	// 搜索条件
+	if name != "" {
+		db = db.Where("name LIKE ?", "%"+name+"%")
+	}
+
+	// 获取总数
+	db.Count(&total)
+
+	// 分页查询
+	offset := (page - 1) * pageSize
+	if err := db.Offset(offset).Limit(pageSize).Find(&templates).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code": 500,
+			"msg":  "查询失败: " + err.Error(),
+		})
+		return
+	}
+
+	for i := range templates {
+		templates[i].MenuIds, _ = permissionTemplateService.GetTemplateMenuIds(global.DB, templates[i].ID)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"msg":  "获取成功",
+		"data": gin.H{
+			"list":     templates,
+			"total":    total,
+			"page":     page,
+			"pageSize": pageSize,
+		},
+	})
+}
+
+// GetPermissionTemplateById 根据ID获取权限模板
+func GetPermissionTemplateById(c *gin.Context) {
+	id := c.Param("id")
+	var template system.SysPermissionTemplate
+
+	if err := global.DB.Where("id = ?", id).First(&template).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"code": 404,
+			"msg":  "模板不存在",
+		})
+		return
+	}
+
+	menuIds, err := permissionTemplateService.GetTemplateMenuIds(global.DB, template.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code": 500,
+			"msg":  "查询模板菜单失败: " + err.Error(),
+		})
+		return
+	}
+	template.MenuIds = menuIds
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"msg":  "获取成功",
+		"data": template,
+	})
+}
+
+// UpdatePermissionTemplate 更新权限模板
+func UpdatePermissionTemplate(c *gin.Context) {
+	id := c.Param("id")
+	var template system.SysPermissionTemplate
+
+	if err := global.DB.Where("id = ?", id).First(&template).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"code": 404,
+			"msg":  "模板不存在",
+		})
+		return
+	}
+
+	var updateData system.SysPermissionTemplate
+	if err := c.ShouldBindJSON(&updateData); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	// 如果更新模板名称，检查是否已存在
+	if updateData.Name != "" && updateData.Name != template.Name {
+		var existTemplate system.SysPermissionTemplate
+		if err := global.DB.Where("name = ? AND id != ?", updateData.Name, template.ID).First(&existTemplate).Error; err == nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code": 400,
+				"msg":  "模板名称已存在",
+			})
+			return
+		}
+	}
+
+	if err := permissionTemplateService.CheckMenusExist(updateData.MenuIds); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  err.Error(),
+		})
+		return
+	}
+
+	err := global.DB.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&template).Updates(system.SysPermissionTemplate{
+			Name:        updateData.Name,
+			Description: updateData.Description,
+		}).Error; err != nil {
+			return err
+		}
+		// 传入menuIds时整体替换模板菜单
+		if updateData.MenuIds != nil {
+			return permissionTemplateService.SetTemplateMenus(tx, template.ID, updateData.MenuIds)
+		}
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code": 500,
+			"msg":  "更新失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"msg":  "更新成功",
+	})
+}
+
+// DeletePermissionTemplate 删除权限模板
+func DeletePermissionTemplate(c *gin.Context) {
+	id := c.Param("id")
+	var template system.SysPermissionTemplate
+
+	if err := global.DB.Where("id = ?", id).First(&template).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"code": 404,
+			"msg":  "模板不存在",
+		})
+		return
+	}
+
+	err := global.DB.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		// 物理删除，避免软删除的记录占用唯一的模板名称
+		if err := tx.Unscoped().Delete(&template).Error; err != nil {
+			return err
+		}
+		return permissionTemplateService.SetTemplateMenus(tx, template.ID, nil)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code": 500,
+			"msg":  "删除失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"msg":  "删除成功",
+	})
+}
+
+// ApplyPermissionTemplate 将权限模板应用到一个或多个角色
+func ApplyPermissionTemplate(c *gin.Context) {
+	id := c.Param("id")
+	var template system.SysPermissionTemplate
+
+	if err := global.DB.Where("id = ?", id).First(&template).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"code": 404,
+			"msg":  "模板不存在",
+		})
+		return
+	}
+
+	var req struct {
+		AuthorityIds []uint `json:"authorityIds" binding:"required"`
+		Mode         string `json:"mode"` // replace:覆盖 merge:合并
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	results, err := permissionTemplateService.ApplyTemplate(c, template.ID, req.AuthorityIds, req.Mode)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "应用模板失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"msg":  "应用成功",
+		"data": results,
+	})
+}
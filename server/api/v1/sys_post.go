@@ -0,0 +1,246 @@
+package v1
+
+import (
+	"net/http"
+	"server/global"
+	"server/model/system"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreatePost 创建岗位
+func CreatePost(c *gin.Context) {
+	var post system.SysPost
+	if err := c.ShouldBindJSON(&post); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	// 验证必填字段
+	if post.PostCode == "" || post.PostName == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "岗位编码和岗位名称不能为空",
+		})
+		return
+	}
+
+	// 检查岗位编码是否已存在
+	var existPost system.SysPost
+	if err := global.DB.Where("post_code = ?", post.PostCode).First(&existPost).Error; err == nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "岗位编码已存在",
+		})
+		return
+	}
+
+	if post.Status == 0 {
+		post.Status = 1
+	}
+
+	if err := global.DB.Create(&post).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code": 500,
+			"msg":  "创建岗位失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"msg":  "创建成功",
+		"data": post,
+	})
+}
+
+// GetPostList 获取岗位列表
+func GetPostList(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "10"))
+	postCode := c.Query("postCode")
+	postName := c.Query("postName")
+	status, _ := strconv.Atoi(c.Query("status"))
+
+	var posts []system.SysPost
+	var total int64
+
+	db := global.DB.Model(&system.SysPost{})
+
+	// 搜索条件
+	if postCode != "" {
+		db = db.Where("post_code LIKE ?", "%"+postCode+"%")
+	}
+	if postName != "" {
+		db = db.Where("post_name LIKE ?", "%"+postName+"%")
+	}
+	if status != 0 {
+		db = db.Where("status = ?", status)
+	}
+
+	// 获取总数
+	db.Count(&total)
+
+	// 分页查询
+	offset := (page - 1) * pageSize
+	if err := db.Order("sort ASC").Offset(offset).Limit(pageSize).Find(&posts).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code": 500,
+			"msg":  "查询失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"msg":  "获取成功",
+		"data": gin.H{
+			"list":     posts,
+			"total":    total,
+			"page":     page,
+			"pageSize": pageSize,
+		},
+	})
+}
+
+// GetAllPosts 获取所有启用的岗位（下拉选项用）
+func GetAllPosts(c *gin.Context) {
+	var posts []system.SysPost
+
+	if err := global.DB.Where("status = ?", 1).Order("sort ASC").Find(&posts).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code": 500,
+			"msg":  "查询失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"msg":  "获取成功",
+		"data": posts,
+	})
+}
+
+// GetPostById 根据ID获取岗位
+func GetPostById(c *gin.Context) {
+	id := c.Param("id")
+	var post system.SysPost
+
+	if err := global.DB.Where("id = ?", id).First(&post).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"code": 404,
+			"msg":  "岗位不存在",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"msg":  "获取成功",
+		"data": post,
+	})
+}
+
+// UpdatePost 更新岗位
+func UpdatePost(c *gin.Context) {
+	id := c.Param("id")
+	var post system.SysPost
+
+	if err := global.DB.Where("id = ?", id).First(&post).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"code": 404,
+			"msg":  "岗位不存在",
+		})
+		return
+	}
+
+	var updateData system.SysPost
+	if err := c.ShouldBindJSON(&updateData); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	if updateData.PostCode == "" || updateData.PostName == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "岗位编码和岗位名称不能为空",
+		})
+		return
+	}
+
+	// 如果更新岗位编码，检查是否已存在
+	if updateData.PostCode != post.PostCode {
+		var existPost system.SysPost
+		if err := global.DB.Where("post_code = ? AND id != ?", updateData.PostCode, post.ID).First(&existPost).Error; err == nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code": 400,
+				"msg":  "岗位编码已存在",
+			})
+			return
+		}
+	}
+
+	if updateData.Status == 0 {
+		updateData.Status = post.Status
+	}
+
+	// 使用Select明确指定要更新的字段，这样可以更新零值字段
+	if err := global.DB.Model(&post).Select("post_code", "post_name", "sort", "status", "remark").Updates(updateData).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code": 500,
+			"msg":  "更新失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"msg":  "更新成功",
+	})
+}
+
+// DeletePost 删除岗位
+func DeletePost(c *gin.Context) {
+	id := c.Param("id")
+	var post system.SysPost
+
+	if err := global.DB.Where("id = ?", id).First(&post).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"code": 404,
+			"msg":  "岗位不存在",
+		})
+		return
+	}
+
+	// 检查是否有用户担任此岗位
+	var userCount int64
+	global.DB.Model(&system.SysUserPost{}).Where("sys_post_id = ?", post.ID).Count(&userCount)
+	if userCount > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "该岗位下有用户，无法删除",
+		})
+		return
+	}
+
+	if err := global.DB.Delete(&post).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code": 500,
+			"msg":  "删除失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"msg":  "删除成功",
+	})
+}
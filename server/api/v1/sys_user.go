@@ -5,14 +5,19 @@ import (
 	"net/http"
 	"server/global"
 	"server/model/system"
-	"strconv"
-
+	systemService "server/service/system"
 	"server/utils"
+	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+var userService = systemService.UserService{}
+
 // CreateUser 创建用户
 func CreateUser(c *gin.Context) {
 	var user system.SysUser
@@ -34,11 +39,44 @@ func CreateUser(c *gin.Context) {
 		return
 	}
 
+	// 整理用户角色，未指定时沿用默认角色
+	if len(user.AuthorityIds) == 0 && user.AuthorityId == 0 {
+		user.AuthorityId = 888
+	}
+	authorityIds, activeId := userService.NormalizeAuthorityIds(user.AuthorityIds, user.AuthorityId)
+	if err := userService.CheckAuthoritiesExist(authorityIds); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  err.Error(),
+		})
+		return
+	}
+	user.AuthorityId = activeId
+	user.Authorities = nil
+
+	if err := userService.CheckPostsExist(user.PostIds); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  err.Error(),
+		})
+		return
+	}
+	user.Posts = nil
+
 	// 加密密码
 	user.UUID = uuid.New()
 	user.Password = utils.BcryptHash(user.Password)
 
-	if err := global.DB.Create(&user).Error; err != nil {
+	err := global.DB.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit(clause.Associations).Create(&user).Error; err != nil {
+			return err
+		}
+		if err := userService.SetUserPosts(tx, user.ID, user.PostIds); err != nil {
+			return err
+		}
+		return userService.SetUserAuthorities(tx, user.ID, authorityIds)
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"code": 500,
 			"msg":  "创建用户失败: " + err.Error(),
@@ -48,6 +86,7 @@ func CreateUser(c *gin.Context) {
 
 	// 清除密码后返回用户信息
 	user.Password = ""
+	user.AuthorityIds = authorityIds
 
 	c.JSON(http.StatusOK, gin.H{
 		"code": 0,
@@ -60,28 +99,19 @@ func CreateUser(c *gin.Context) {
 func GetUserList(c *gin.Context) {
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "10"))
-	username := c.Query("username")
-	nickName := c.Query("nickName")
 
 	var users []system.SysUser
 	var total int64
 
-	db := global.DB.Model(&system.SysUser{})
-
 	// 搜索条件
-	if username != "" {
-		db = db.Where("username LIKE ?", "%"+username+"%")
-	}
-	if nickName != "" {
-		db = db.Where("nick_name LIKE ?", "%"+nickName+"%")
-	}
+	db := userService.BuildUserQuery(userListFilter(c))
 
 	// 获取总数
 	db.Count(&total)
 
 	// 分页查询
 	offset := (page - 1) * pageSize
-	if err := db.Offset(offset).Limit(pageSize).Find(&users).Error; err != nil {
+	if err := db.Preload("Authorities").Preload("Posts").Offset(offset).Limit(pageSize).Find(&users).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"code": 500,
 			"msg":  "查询失败: " + err.Error(),
@@ -111,7 +141,7 @@ func GetUserById(c *gin.Context) {
 	id := c.Param("id")
 	var user system.SysUser
 
-	if err := global.DB.Where("id = ?", id).First(&user).Error; err != nil {
+	if err := global.DB.Preload("Authorities").Preload("Posts").Where("id = ?", id).First(&user).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{
 			"code": 404,
 			"msg":  "用户不存在",
@@ -159,7 +189,7 @@ func UpdateUser(c *gin.Context) {
 		// 特殊标识，表示重置密码为123456
 		updateData.Password = utils.BcryptHash("123456")
 
-		if err := global.DB.Model(&user).Update("password", updateData.Password).Error; err != nil {
+		if err := global.DB.WithContext(c).Model(&user).Update("password", updateData.Password).Error; err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{
 				"code": 500,
 				"msg":  "密码重置失败: " + err.Error(),
@@ -179,7 +209,69 @@ func UpdateUser(c *gin.Context) {
 		updateData.Password = utils.BcryptHash(updateData.Password)
 	}
 
-	if err := global.DB.Model(&user).Updates(updateData).Error; err != nil {
+	// 整理用户角色：传入authorityIds时整体替换，仅传入authorityId时追加到已有角色中
+	var authorityIds []uint
+	if len(updateData.AuthorityIds) > 0 || updateData.AuthorityId != 0 {
+		requestIds := updateData.AuthorityIds
+		if len(requestIds) == 0 {
+			heldIds, err := userService.GetUserAuthorityIds(user)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{
+					"code": 500,
+					"msg":  "查询用户角色失败: " + err.Error(),
+				})
+				return
+			}
+			requestIds = heldIds
+		}
+
+		activeId := updateData.AuthorityId
+		if activeId == 0 {
+			activeId = user.AuthorityId
+			if !utils.ContainsId(requestIds, activeId) {
+				activeId = 0
+			}
+		}
+
+		authorityIds, activeId = userService.NormalizeAuthorityIds(requestIds, activeId)
+		if err := userService.CheckAuthoritiesExist(authorityIds); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code": 400,
+				"msg":  err.Error(),
+			})
+			return
+		}
+		updateData.AuthorityId = activeId
+	}
+	updateData.Authorities = nil
+
+	// 传入postIds时整体替换用户岗位
+	if updateData.PostIds != nil {
+		if err := userService.CheckPostsExist(updateData.PostIds); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code": 400,
+				"msg":  err.Error(),
+			})
+			return
+		}
+	}
+	updateData.Posts = nil
+
+	err := global.DB.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&user).Omit(clause.Associations).Updates(updateData).Error; err != nil {
+			return err
+		}
+		if updateData.PostIds != nil {
+			if err := userService.SetUserPosts(tx, user.ID, updateData.PostIds); err != nil {
+				return err
+			}
+		}
+		if authorityIds != nil {
+			return userService.SetUserAuthorities(tx, user.ID, authorityIds)
+		}
+		return nil
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"code": 500,
 			"msg":  "更新失败: " + err.Error(),
@@ -206,7 +298,16 @@ func DeleteUser(c *gin.Context) {
 		return
 	}
 
-	if err := global.DB.Delete(&user).Error; err != nil {
+	err := global.DB.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&user).Error; err != nil {
+			return err
+		}
+		if err := userService.SetUserPosts(tx, user.ID, nil); err != nil {
+			return err
+		}
+		return userService.SetUserAuthorities(tx, user.ID, nil)
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"code": 500,
 			"msg":  "删除失败: " + err.Error(),
@@ -242,7 +343,7 @@ func GetUserInfo(c *gin.Context) {
 	}
 
 	var user system.SysUser
-	if err := global.DB.Where("id = ?", userID).First(&user).Error; err != nil {
+	if err := global.DB.Preload("Authorities").Preload("Posts").Where("id = ?", userID).First(&user).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{
 			"code": 404,
 			"msg":  "用户不存在",
@@ -309,7 +410,8 @@ func UpdateUserInfo(c *gin.Context) {
 		fieldsToUpdate = append(fieldsToUpdate, "password")
 	}
 
-	if err := global.DB.Model(&user).Select(fieldsToUpdate).Updates(updateData).Error; err != nil {
+	oldHeaderImg := user.HeaderImg
+	if err := global.DB.WithContext(c).Model(&user).Select(fieldsToUpdate).Updates(updateData).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"code": 500,
 			"msg":  "更新失败: " + err.Error(),
@@ -317,6 +419,13 @@ func UpdateUserInfo(c *gin.Context) {
 		return
 	}
 
+	// 更换头像后删除旧头像文件，删除失败不影响本次更新
+	if oldHeaderImg != updateData.HeaderImg {
+		if err := avatarService.DeleteAvatar(c.Request.Context(), oldHeaderImg, userID); err != nil {
+			fmt.Printf("删除旧头像失败: %v\n", err)
+		}
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"code": 0,
 		"msg":  "更新成功",
@@ -389,7 +498,7 @@ func ChangePassword(c *gin.Context) {
 	hashedPassword := utils.BcryptHash(req.NewPassword)
 
 	// 更新密码
-	if err := global.DB.Model(&user).Update("password", hashedPassword).Error; err != nil {
+	if err := global.DB.WithContext(c).Model(&user).Update("password", hashedPassword).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"code": 500,
 			"msg":  "密码修改失败: " + err.Error(),
@@ -433,9 +542,18 @@ func GetUserMenus(c *gin.Context) {
 		}
 	}
 
-	// 获取用户角色的菜单权限
+	// 获取用户所有角色的菜单权限（取并集）
+	authorityIds, err := userService.GetUserAuthorityIds(user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code": 500,
+			"msg":  "查询用户角色失败: " + err.Error(),
+		})
+		return
+	}
+
 	var authorityMenus []system.SysAuthorityMenu
-	if err := global.DB.Where("authority_id = ?", user.AuthorityId).Find(&authorityMenus).Error; err != nil {
+	if err := global.DB.Where("authority_id IN ?", authorityIds).Find(&authorityMenus).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"code": 500,
 			"msg":  "查询用户权限失败: " + err.Error(),
@@ -443,13 +561,10 @@ func GetUserMenus(c *gin.Context) {
 		return
 	}
 
-	// 调试信息
-	fmt.Printf("用户: %s, 角色ID: %d, 权限数量: %d\n", username, user.AuthorityId, len(authorityMenus))
-
 	// 如果没有配置权限，根据角色类型决定返回内容
 	if len(authorityMenus) == 0 {
 		// 只有管理员角色（888）才能看到所有菜单
-		if user.AuthorityId == 888 {
+		if utils.ContainsId(authorityIds, 888) {
 			var allMenus []system.SysBaseMenu
 			if err := global.DB.Order("sort ASC").Find(&allMenus).Error; err != nil {
 				c.JSON(http.StatusInternalServerError, gin.H{
@@ -487,15 +602,16 @@ func GetUserMenus(c *gin.Context) {
 		}
 	}
 
-	// 获取有权限的菜单ID列表
+	// 获取有权限的菜单ID列表（多个角色可能授予同一菜单，需去重）
 	var menuIds []uint
+	menuIdSet := make(map[uint]bool)
 	for _, am := range authorityMenus {
-		menuIds = append(menuIds, am.BaseMenuId)
+		if !menuIdSet[am.BaseMenuId] {
+			menuIdSet[am.BaseMenuId] = true
+			menuIds = append(menuIds, am.BaseMenuId)
+		}
 	}
 
-	// 调试信息
-	fmt.Printf("菜单ID列表: %v\n", menuIds)
-
 	// 查询对应的菜单信息
 	var menus []system.SysBaseMenu
 	if err := global.DB.Where("id IN ?", menuIds).Order("sort ASC").Find(&menus).Error; err != nil {
@@ -534,6 +650,247 @@ func buildUserMenuTree(menus []system.SysBaseMenu, parentId *uint) []system.SysB
 	return tree
 }
 
+// SwitchAuthority 切换当前角色并重新签发token
+func SwitchAuthority(c *gin.Context) {
+	var req struct {
+		AuthorityId uint `json:"authorityId" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	// 从JWT token中获取用户ID
+	userIDInterface, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code": 401,
+			"msg":  "未找到用户信息",
+		})
+		return
+	}
+
+	userID, ok := userIDInterface.(uint)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code": 401,
+			"msg":  "用户信息格式错误",
+		})
+		return
+	}
+
+	var user system.SysUser
+	if err := global.DB.Where("id = ?", userID).First(&user).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"code": 404,
+			"msg":  "用户不存在",
+		})
+		return
+	}
+
+	// 只能切换到自己拥有的角色
+	held, err := userService.HasAuthority(user, req.AuthorityId)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code": 500,
+			"msg":  "查询用户角色失败: " + err.Error(),
+		})
+		return
+	}
+	if !held {
+		c.JSON(http.StatusForbidden, gin.H{
+			"code": 403,
+			"msg":  "您没有该角色，无法切换",
+		})
+		return
+	}
+
+	var authority system.SysAuthority
+	if err := global.DB.Where("authority_id = ?", req.AuthorityId).First(&authority).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"code": 404,
+			"msg":  "角色不存在",
+		})
+		return
+	}
+
+	// 记录当前角色，下次登录时沿用
+	if err := global.DB.WithContext(c).Model(&user).Update("authority_id", req.AuthorityId).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code": 500,
+			"msg":  "切换角色失败: " + err.Error(),
+		})
+		return
+	}
+
+	// 沿用当前会话，只更新会话中的角色
+	sessionId := c.GetString("sessionId")
+	token, expiresAt, err := utils.GenerateToken(user.ID, user.Username, req.AuthorityId, sessionId)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code": 500,
+			"msg":  "生成token失败",
+		})
+		return
+	}
+	if err := sessionService.RenewSession(sessionId, req.AuthorityId, expiresAt); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code": 401,
+			"msg":  err.Error(),
+		})
+		return
+	}
+
+	user.Password = ""
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"msg":  "切换成功",
+		"data": gin.H{
+			"user":          user,
+			"token":         token,
+			"defaultRouter": authority.DefaultRouter,
+		},
+	})
+}
+
+// ImportUsers 从CSV/XLSX批量导入用户
+func ImportUsers(c *gin.Context) {
+	// 限制文件大小为10MB
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, MaxFileSize)
+
+	file, header, err := c.Request.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "请选择要导入的文件",
+		})
+		return
+	}
+	defer file.Close()
+
+	format, err := utils.TableFormatFromFilename(header.Filename)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  err.Error(),
+		})
+		return
+	}
+
+	rows, err := utils.ReadTable(format, file)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "解析文件失败: " + err.Error(),
+		})
+		return
+	}
+
+	// 未指定初始密码时使用默认密码123456
+	defaultPassword := c.PostForm("defaultPassword")
+	if defaultPassword == "" {
+		defaultPassword = "123456"
+	}
+	dryRun := c.PostForm("dryRun") == "true" || c.Query("dryRun") == "true"
+
+	report, err := userService.ImportUsers(c, rows, dryRun, defaultPassword)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "导入失败: " + err.Error(),
+		})
+		return
+	}
+
+	if report.Invalid > 0 {
+		c.JSON(http.StatusOK, gin.H{
+			"code": 400,
+			"msg":  fmt.Sprintf("校验未通过，共 %d 行存在错误", report.Invalid),
+			"data": report,
+		})
+		return
+	}
+
+	msg := fmt.Sprintf("导入成功，共导入 %d 个用户", report.Created)
+	if dryRun {
+		msg = fmt.Sprintf("校验通过，共 %d 个用户可导入", report.Valid)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"msg":  msg,
+		"data": report,
+	})
+}
+
+// DownloadUserImportTemplate 下载用户导入模板
+func DownloadUserImportTemplate(c *gin.Context) {
+	format := c.DefaultQuery("format", utils.TableFormatXLSX)
+	if format != utils.TableFormatCSV && format != utils.TableFormatXLSX {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "只支持 csv、xlsx 格式",
+		})
+		return
+	}
+
+	c.Header("Content-Type", utils.TableContentType(format))
+	c.Header("Content-Disposition", "attachment; filename=user_import_template."+format)
+	c.Status(http.StatusOK)
+
+	if err := userService.WriteUserImportTemplate(format, c.Writer); err != nil {
+		fmt.Printf("生成用户导入模板失败: %v\n", err)
+	}
+}
+
+// ExportUsers 导出用户列表，支持与列表相同的筛选条件
+func ExportUsers(c *gin.Context) {
+	format := c.DefaultQuery("format", utils.TableFormatXLSX)
+	if format != utils.TableFormatCSV && format != utils.TableFormatXLSX {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "只支持 csv、xlsx 格式",
+		})
+		return
+	}
+
+	var fields []string
+	if columns := c.Query("columns"); columns != "" {
+		fields = strings.Split(columns, ",")
+	}
+	columns, err := userService.SelectUserExportColumns(fields)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  err.Error(),
+		})
+		return
+	}
+
+	extendExportDeadline(c)
+	c.Header("Content-Type", utils.TableContentType(format))
+	c.Header("Content-Disposition", "attachment; filename=users."+format)
+	c.Status(http.StatusOK)
+
+	if err := userService.ExportUsers(userListFilter(c), columns, format, c.Writer); err != nil {
+		fmt.Printf("导出用户失败: %v\n", err)
+	}
+}
+
+// userListFilter 从查询参数中获取用户列表筛选条件
+func userListFilter(c *gin.Context) systemService.UserListFilter {
+	postId, _ := strconv.Atoi(c.Query("postId"))
+	return systemService.UserListFilter{
+		Username: c.Query("username"),
+		NickName: c.Query("nickName"),
+		PostId:   uint(postId),
+	}
+}
+
 /*
 // ResetPassword 重置用户密码为默认密码123456
 // 注释掉，现在使用UpdateUser中的特殊处理来实现密码重置
@@ -561,7 +918,7 @@ func ResetPassword(c *gin.Context) {
 	}
 
 	// 更新密码
-	if err := global.DB.Model(&user).Update("password", string(hashedPassword)).Error; err != nil {
+	if err := global.DB.WithContext(c).Model(&user).Update("password", string(hashedPassword)).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"code": 500,
 			"msg":  "密码重置失败: " + err.Error(),
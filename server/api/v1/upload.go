@@ -1,32 +1,37 @@
 package v1
 
 import (
-	"fmt"
-	"io"
-	"mime/multipart"
+	"errors"
 	"net/http"
-	"os"
-	"path/filepath"
 	"strings"
-	"time"
+
+	systemService "server/service/system"
+	"server/utils"
+	"server/utils/upload"
 
 	"github.com/gin-gonic/gin"
-	"github.com/google/uuid"
 )
 
-const (
-	MaxFileSize = 10 * 1024 * 1024 // 10MB
-	UploadPath  = "./uploads/avatar/"
-)
+const MaxFileSize = 10 * 1024 * 1024 // 10MB
+
+var avatarService = systemService.AvatarService{}
 
 // UploadAvatar 上传头像
+// @Tags      文件上传
+// @Summary   上传头像，按内容识别图片格式，裁剪为正方形并生成缩略图，不保留EXIF等元数据
+// @Accept    multipart/form-data
+// @Produce   application/json
+// @Param     file  formData  file  true  "JPG、PNG、GIF 图片"
+// @Success   200   {object}  response.Response{data=system.AvatarResult,msg=string}  "上传成功"
+// @Router    /upload/avatar [post]
 func UploadAvatar(c *gin.Context) {
-	// 限制文件大小为10MB
-	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, MaxFileSize)
+	// 限制文件大小为10MB，额外留出表单字段的空间
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, MaxFileSize+1<<20)
 
-	file, header, err := c.Request.FormFile("file")
+	file, _, err := c.Request.FormFile("file")
 	if err != nil {
-		if err.Error() == "http: request body too large" {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
 			c.JSON(http.StatusBadRequest, gin.H{
 				"code": 400,
 				"msg":  "文件大小不能超过10MB",
@@ -41,93 +46,68 @@ func UploadAvatar(c *gin.Context) {
 	}
 	defer file.Close()
 
-	// 验证文件类型
-	if !isValidImageType(header) {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"code": 400,
-			"msg":  "只支持上传 JPG、JPEG、PNG、GIF 格式的图片",
+	result, err := avatarService.UploadAvatar(c.Request.Context(), file)
+	if err != nil {
+		if errors.Is(err, utils.ErrInvalidImage) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code": 400,
+				"msg":  err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code": 500,
+			"msg":  "上传失败: " + err.Error(),
 		})
 		return
 	}
 
-	// 验证文件大小
-	if header.Size > MaxFileSize {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"code": 400,
-			"msg":  "文件大小不能超过10MB",
-		})
-		return
-	}
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"msg":  "上传成功",
+		"data": result,
+	})
+}
 
-	// 创建上传目录
-	if err := os.MkdirAll(UploadPath, 0755); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"code": 500,
-			"msg":  "创建上传目录失败",
+// RedirectObject 跳转到私有存储中对象的预签名地址，只允许访问公开前缀下的对象
+// @Tags      文件上传
+// @Summary   访问存储中的文件
+// @Param     key  path  string  true  "文件路径"
+// @Success   302
+// @Router    /upload/object/{key} [get]
+func RedirectObject(c *gin.Context) {
+	key, err := upload.CleanKey(c.Param("key"))
+	if err != nil || !isPublicObject(key) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"code": 404,
+			"msg":  "文件不存在",
 		})
 		return
 	}
 
-	// 生成新的文件名
-	ext := filepath.Ext(header.Filename)
-	newFileName := fmt.Sprintf("%s_%d%s", uuid.New().String(), time.Now().Unix(), ext)
-	filePath := filepath.Join(UploadPath, newFileName)
-
-	// 保存文件
-	dst, err := os.Create(filePath)
+	storage, err := upload.Storage()
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"code": 500,
-			"msg":  "保存文件失败",
+			"msg":  "文件存储不可用: " + err.Error(),
 		})
 		return
 	}
-	defer dst.Close()
-
-	if _, err := io.Copy(dst, file); err != nil {
+	fileURL, err := storage.URL(c.Request.Context(), key)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"code": 500,
-			"msg":  "保存文件失败",
+			"msg":  "生成访问地址失败: " + err.Error(),
 		})
 		return
 	}
 
-	// 生成访问URL
-	fileURL := fmt.Sprintf("/uploads/avatar/%s", newFileName)
-
-	c.JSON(http.StatusOK, gin.H{
-		"code": 0,
-		"msg":  "上传成功",
-		"data": gin.H{
-			"url":      fileURL,
-			"filename": newFileName,
-		},
-	})
+	// 预签名地址有时效，只允许短时间缓存跳转结果
+	c.Header("Cache-Control", "private, max-age=300")
+	c.Redirect(http.StatusFound, fileURL)
 }
 
-// isValidImageType 验证是否为有效的图片类型
-func isValidImageType(header *multipart.FileHeader) bool {
-	allowedTypes := map[string]bool{
-		"image/jpeg": true,
-		"image/jpg":  true,
-		"image/png":  true,
-		"image/gif":  true,
-	}
-
-	// 检查Content-Type
-	contentType := header.Header.Get("Content-Type")
-	if allowedTypes[contentType] {
-		return true
-	}
-
-	// 检查文件扩展名
-	ext := strings.ToLower(filepath.Ext(header.Filename))
-	allowedExts := map[string]bool{
-		".jpg":  true,
-		".jpeg": true,
-		".png":  true,
-		".gif":  true,
-	}
-
-	return allowedExts[ext]
+// isPublicObject 是否为无需登录即可访问的对象
+func isPublicObject(key string) bool {
+	return strings.HasPrefix(key, systemService.AvatarPrefix)
 }
@@ -1,20 +1,24 @@
 package main
 
 import (
+	"encoding/json"
 	"flag"
 	"fmt"
 	"server/core"
 	"server/global"
 	"server/initialize"
 	"server/model/system"
+	systemService "server/service/system"
+	"server/utils"
 	"os"
+	"strings"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
 func main() {
-	var action = flag.String("action", "", "操作类型: reset-admin-permissions, create-admin, show-admin-permissions")
+	var action = flag.String("action", "", "操作类型: reset-admin-permissions, create-admin, show-admin-permissions, check-log-redaction, verify-operation-logs")
 	flag.Parse()
 
 	if *action == "" {
@@ -22,6 +26,8 @@ func main() {
 		fmt.Println("  go run cmd/admin_tool.go -action=reset-admin-permissions    # 重置管理员权限")
 		fmt.Println("  go run cmd/admin_tool.go -action=create-admin               # 创建管理员用户")
 		fmt.Println("  go run cmd/admin_tool.go -action=show-admin-permissions     # 显示管理员权限")
+		fmt.Println("  go run cmd/admin_tool.go -action=check-log-redaction        # 检查操作日志脱敏")
+		fmt.Println("  go run cmd/admin_tool.go -action=verify-operation-logs      # 校验操作日志哈希链")
 		os.Exit(1)
 	}
 
@@ -42,6 +48,10 @@ func main() {
 		createAdminUser()
 	case "show-admin-permissions":
 		showAdminPermissions()
+	case "check-log-redaction":
+		checkLogRedaction()
+	case "verify-operation-logs":
+		verifyOperationLogs()
 	default:
 		fmt.Printf("未知操作: %s\n", *action)
 		os.Exit(1)
@@ -157,3 +167,99 @@ func showAdminPermissions() {
 		}
 	}
 }
+
+// checkLogRedaction 检查脱敏配置是否生效，并扫描操作日志表中残留的明文敏感字段
+func checkLogRedaction() {
+	redactor := utils.NewRedactor(global.CONFIG.OperationLog.Redact)
+	failed := false
+
+	// 使用包含明文密钥的典型请求验证脱敏结果
+	const secret = "Secret#123456"
+	samples := []struct {
+		method string
+		path   string
+		target string
+		body   interface{}
+	}{
+		{"POST", "/api/base/login", "request", map[string]interface{}{"username": "admin", "password": secret}},
+		{"POST", "/api/base/login", "response", map[string]interface{}{"code": 0, "data": map[string]interface{}{"token": secret}}},
+		{"PUT", "/api/system/user/password", "request", map[string]interface{}{"oldPassword": secret, "newPassword": secret}},
+		{"POST", "/api/system/user", "request", map[string]interface{}{"username": "test", "password": secret, "authorityIds": []interface{}{888}}},
+		{"POST", "/api/system/user/switch-authority", "response", map[string]interface{}{"data": map[string]interface{}{"token": secret}}},
+	}
+	for _, sample := range samples {
+		redacted := redactor.Redact(sample.method, sample.path, sample.target, sample.body)
+		data, _ := json.Marshal(redacted)
+		if strings.Contains(string(data), secret) {
+			failed = true
+			fmt.Printf("脱敏失败: %s %s (%s) => %s\n", sample.method, sample.path, sample.target, data)
+		}
+	}
+
+	// 扫描已有日志
+	var lastId uint
+	var scanned int
+	for {
+		var logs []system.SysOperationLog
+		db := global.DB.Select("id", "method", "path", "request_body", "response_body").Order("id ASC").Limit(1000)
+		if err := db.Where("id > ?", lastId).Find(&logs).Error; err != nil {
+			fmt.Printf("查询操作日志失败: %v\n", err)
+			os.Exit(1)
+		}
+
+		for _, log := range logs {
+			for target, body := range map[string]string{"request": log.RequestBody, "response": log.ResponseBody} {
+				var data interface{}
+				if body == "" || json.Unmarshal([]byte(body), &data) != nil {
+					continue
+				}
+				if paths := redactor.FindUnredacted(data); len(paths) > 0 {
+					failed = true
+					fmt.Printf("日志 %d %s %s 的%s中存在明文字段: %s\n", log.ID, log.Method, log.Path, target, strings.Join(paths, ", "))
+				}
+			}
+		}
+		scanned += len(logs)
+
+		if len(logs) < 1000 {
+			break
+		}
+		lastId = logs[len(logs)-1].ID
+	}
+
+	if failed {
+		fmt.Printf("检查完成，共扫描 %d 条日志，发现未脱敏的敏感字段\n", scanned)
+		os.Exit(1)
+	}
+	fmt.Printf("检查完成，共扫描 %d 条日志，未发现明文敏感字段\n", scanned)
+}
+
+// verifyOperationLogs 校验操作日志哈希链和归档检查点，发现断点时以非零状态退出
+func verifyOperationLogs() {
+	operationLogService := &systemService.OperationLogService{}
+	report, err := operationLogService.VerifyOperationLogChain()
+	if err != nil {
+		fmt.Printf("校验失败: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("共校验 %d 条日志（启用哈希链前的日志 %d 条），归档检查点 %d 个\n", report.Checked, report.Legacy, report.Checkpoints)
+	if report.StartAfterId > 0 {
+		fmt.Printf("ID %d 及之前的日志已归档，从其后开始校验\n", report.StartAfterId)
+	}
+	for _, checkpoint := range report.InvalidCheckpoints {
+		fmt.Printf("检查点 %d: %s\n", checkpoint.Id, checkpoint.Reason)
+	}
+	for _, item := range report.Breaks {
+		fmt.Printf("日志 %d: %s\n", item.Id, item.Reason)
+	}
+	if report.BrokenCount > len(report.Breaks) {
+		fmt.Printf("... 共 %d 处断点\n", report.BrokenCount)
+	}
+
+	if !report.Valid {
+		fmt.Println("校验未通过")
+		os.Exit(1)
+	}
+	fmt.Println("校验通过")
+}
@@ -0,0 +1,255 @@
+// openapi 根据已注册的gin路由和处理函数源码生成 OpenAPI 3 文档
+//
+//	go run ./cmd/openapi                # 生成 docs/openapi.json
+//	go run ./cmd/openapi -check         # 检查已注册的路由是否都在文档中，缺少时退出码为1
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"server/docs"
+	"server/global"
+	"server/initialize"
+	"server/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// securitySchemeName 未在 main.go 中声明时使用的认证方式名称
+var securitySchemeName = "Bearer"
+
+func main() {
+	root := flag.String("root", ".", "server 目录")
+	output := flag.String("o", "docs/openapi.json", "输出文件")
+	check := flag.Bool("check", false, "只检查已注册的路由是否都在文档中")
+	flag.Parse()
+
+	routes := registeredRoutes()
+	if *check {
+		os.Exit(checkRoutes(routes))
+	}
+
+	spec, err := generate(*root, routes)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "生成接口文档失败: %v\n", err)
+		os.Exit(1)
+	}
+	data, err := json.MarshalIndent(spec, "", "  ")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "生成接口文档失败: %v\n", err)
+		os.Exit(1)
+	}
+	if err := os.WriteFile(*output, append(data, '\n'), 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "写入接口文档失败: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("已生成 %s，共 %d 个接口\n", *output, len(routes))
+}
+
+// registeredRoutes 使用默认配置构建路由，开启指标接口使其出现在文档中
+func registeredRoutes() gin.RoutesInfo {
+	gin.SetMode(gin.ReleaseMode)
+	global.CONFIG.Metrics.Enabled = true
+	routes := initialize.Routers().Routes()
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].Path != routes[j].Path {
+			return routes[i].Path < routes[j].Path
+		}
+		return routes[i].Method < routes[j].Method
+	})
+	return routes
+}
+
+// checkRoutes 对比已注册的路由和当前的文档，返回退出码
+func checkRoutes(routes gin.RoutesInfo) int {
+	missing, err := docs.MissingRoutes(routes)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "解析接口文档失败: %v\n", err)
+		return 1
+	}
+	if len(missing) == 0 {
+		fmt.Printf("接口文档覆盖全部 %d 个路由\n", len(routes))
+		return 0
+	}
+	fmt.Fprintf(os.Stderr, "以下 %d 个路由不在接口文档中，请执行 go generate ./docs 重新生成:\n", len(missing))
+	for _, route := range missing {
+		fmt.Fprintf(os.Stderr, "  %-6s %s\n", route.Method, route.Path)
+	}
+	return 1
+}
+
+func generate(root string, routes gin.RoutesInfo) (*Spec, error) {
+	l, err := newLoader(root)
+	if err != nil {
+		return nil, err
+	}
+	spec := &Spec{
+		OpenAPI: "3.0.3",
+		Info:    Info{Title: "API", Version: "1.0"},
+		Servers: []Server{{URL: "/"}},
+		Paths:   map[string]map[string]*Operation{},
+		Components: Components{
+			SecuritySchemes: map[string]*SecurityScheme{},
+		},
+	}
+	if err := readGeneralInfo(filepath.Join(root, "main.go"), spec); err != nil {
+		return nil, err
+	}
+
+	schemas := newSchemaBuilder(l)
+	builder := &operationBuilder{loader: l, schemas: schemas}
+	modules := routeModules()
+	operationIDs := map[string]int{}
+
+	for _, route := range routes {
+		var op *Operation
+		name := handlerName(route.Handler)
+		if handler := l.lookupFunc(route.Handler); handler != nil {
+			op = builder.build(route.Method, route.Path, handler)
+		} else {
+			op = externalOperation(route)
+		}
+		if len(op.Tags) == 0 {
+			op.Tags = []string{modules.lookup(route.Method, route.Path)}
+		}
+
+		operationIDs[name]++
+		op.OperationID = name
+		if n := operationIDs[name]; n > 1 {
+			op.OperationID = fmt.Sprintf("%s_%d", name, n)
+		}
+
+		openAPIPath := docs.OpenAPIPath(route.Path)
+		if spec.Paths[openAPIPath] == nil {
+			spec.Paths[openAPIPath] = map[string]*Operation{}
+		}
+		spec.Paths[openAPIPath][strings.ToLower(route.Method)] = op
+	}
+
+	spec.Tags = sortedTags(spec.Paths)
+	spec.Components.Schemas = schemas.schemas
+	return spec, nil
+}
+
+// handlerName 处理函数名，闭包使用外层函数名
+func handlerName(handler string) string {
+	name := handler[strings.LastIndex(handler, "/")+1:]
+	parts := strings.Split(name, ".")
+	for i := len(parts) - 1; i > 0; i-- {
+		if !strings.HasPrefix(parts[i], "func") {
+			return strings.Trim(parts[i], "(*)")
+		}
+	}
+	return name
+}
+
+// externalOperation 模块外的处理函数，如gin提供的静态文件服务
+func externalOperation(route gin.RouteInfo) *Operation {
+	op := &Operation{
+		Summary: route.Handler,
+		Responses: map[string]*Response{
+			"200": {Description: "成功"},
+		},
+	}
+	if strings.Contains(route.Handler, "createStaticHandler") {
+		op.Summary = "静态文件访问"
+		op.Tags = []string{"静态文件"}
+		op.Responses["200"].Content = map[string]MediaType{"application/octet-stream": {Schema: &Schema{Type: "string", Format: "binary"}}}
+	}
+	for _, name := range ginParamNames(route.Path) {
+		op.Parameters = append(op.Parameters, &Parameter{Name: name, In: "path", Required: true, Schema: &Schema{Type: "string"}})
+	}
+	return op
+}
+
+// moduleIndex 操作日志中登记的模块，用作接口的分组标签
+type moduleIndex struct {
+	routes   map[string]string
+	prefixes map[string]string
+}
+
+func routeModules() moduleIndex {
+	index := moduleIndex{routes: map[string]string{}, prefixes: map[string]string{}}
+	for _, route := range middleware.GetOperationRoutes() {
+		if route.Module == "" {
+			continue
+		}
+		index.routes[route.Method+" "+route.Path] = route.Module
+		if _, ok := index.prefixes[routePrefix(route.Path)]; !ok {
+			index.prefixes[routePrefix(route.Path)] = route.Module
+		}
+	}
+	return index
+}
+
+// lookup 未登记操作日志的路由使用同一路由组中其他路由的模块，都没有时使用路由组名
+func (m moduleIndex) lookup(method, path string) string {
+	if module, ok := m.routes[method+" "+path]; ok {
+		return module
+	}
+	prefix := routePrefix(path)
+	if module, ok := m.prefixes[prefix]; ok {
+		return module
+	}
+	return prefix[strings.LastIndex(prefix, "/")+1:]
+}
+
+// routePrefix 路由组前缀，如 /api/system/user/:id 为 /api/system/user
+func routePrefix(path string) string {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	n := 2
+	if len(segments) > 1 && segments[0] == "api" && segments[1] == "system" {
+		n = 3
+	}
+	if len(segments) < n {
+		n = len(segments)
+	}
+	return "/" + strings.Join(segments[:n], "/")
+}
+
+// readGeneralInfo 读取 main.go 中的 @title、@version、@description 和认证方式注解
+func readGeneralInfo(mainFile string, spec *Spec) error {
+	file, err := parser.ParseFile(token.NewFileSet(), mainFile, nil, parser.ParseComments)
+	if err != nil {
+		return err
+	}
+	var scheme *SecurityScheme
+	for _, group := range file.Comments {
+		for _, line := range strings.Split(group.Text(), "\n") {
+			key, value, _ := strings.Cut(strings.TrimSpace(line), " ")
+			value = strings.TrimSpace(value)
+			switch key {
+			case "@title":
+				spec.Info.Title = value
+			case "@version":
+				spec.Info.Version = value
+			case "@description":
+				spec.Info.Description = value
+			case "@securityDefinitions.apikey":
+				securitySchemeName = value
+				scheme = &SecurityScheme{Type: "apiKey", In: "header", Name: "Authorization"}
+				spec.Components.SecuritySchemes[value] = scheme
+			case "@in":
+				if scheme != nil {
+					scheme.In = value
+				}
+			case "@name":
+				if scheme != nil {
+					scheme.Name = value
+				}
+			}
+		}
+	}
+	if len(spec.Components.SecuritySchemes) == 0 {
+		spec.Components.SecuritySchemes[securitySchemeName] = &SecurityScheme{Type: "apiKey", In: "header", Name: "Authorization"}
+	}
+	return nil
+}
@@ -0,0 +1,526 @@
+package main
+
+import (
+	"go/ast"
+	"go/token"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	// @Param  name  in  type  required  "说明"
+	paramPattern = regexp.MustCompile(`^(\S+)\s+(\S+)\s+(\S+)\s+(\S+)(?:\s+"([^"]*)")?`)
+	// @Success  200  {object}  type  "说明"
+	responsePattern = regexp.MustCompile(`^(\d+)\s+\{(\w+)\}\s+(\S+)(?:\s+"([^"]*)")?`)
+)
+
+// annotations 处理函数注释中的 swag 注解
+type annotations struct {
+	doc         string
+	tags        []string
+	summary     string
+	description string
+	security    bool
+	accept      []string
+	params      []string
+	responses   []string
+}
+
+func parseAnnotations(decl *ast.FuncDecl) annotations {
+	var a annotations
+	var doc []string
+	for _, line := range strings.Split(commentText(decl.Doc), "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "@") {
+			if line != "" {
+				doc = append(doc, line)
+			}
+			continue
+		}
+		key, value, _ := strings.Cut(line, " ")
+		value = strings.TrimSpace(value)
+		switch strings.ToLower(key) {
+		case "@tags":
+			for _, tag := range strings.Split(value, ",") {
+				if tag = strings.TrimSpace(tag); tag != "" {
+					a.tags = append(a.tags, tag)
+				}
+			}
+		case "@summary":
+			a.summary = value
+		case "@description":
+			a.description = value
+		case "@security":
+			a.security = true
+		case "@accept":
+			a.accept = strings.Fields(strings.ReplaceAll(value, ",", " "))
+		case "@param":
+			a.params = append(a.params, value)
+		case "@success", "@failure":
+			a.responses = append(a.responses, value)
+		}
+	}
+	if len(doc) > 0 {
+		a.doc = strings.TrimSpace(strings.TrimPrefix(doc[0], decl.Name.Name))
+	}
+	return a
+}
+
+// mimeTypes swag注解中的简写
+var mimeTypes = map[string]string{
+	"json":                  "application/json",
+	"mpfd":                  "multipart/form-data",
+	"x-www-form-urlencoded": "application/x-www-form-urlencoded",
+	"octet-stream":          "application/octet-stream",
+}
+
+func mimeType(value string) string {
+	if mime, ok := mimeTypes[value]; ok {
+		return mime
+	}
+	return value
+}
+
+// handlerUsage 从处理函数的代码中推断的请求信息
+type handlerUsage struct {
+	jsonBody   ast.Expr
+	queryType  ast.Expr
+	query      []string
+	formFiles  []string
+	formFields []string
+	rawBody    bool
+	auth       bool
+}
+
+// currentUserKeys JWTAuth写入上下文的键，读取这些键的处理函数需要登录
+var currentUserKeys = map[string]bool{"userID": true, "username": true, "authorityId": true, "sessionId": true}
+
+func analyzeHandler(decl *ast.FuncDecl, method string) handlerUsage {
+	var usage handlerUsage
+	if decl.Body == nil {
+		return usage
+	}
+	seen := map[string]bool{}
+	addName := func(list *[]string, name string) {
+		if name != "" && !seen[name] {
+			seen[name] = true
+			*list = append(*list, name)
+		}
+	}
+
+	ast.Inspect(decl.Body, func(node ast.Node) bool {
+		call, ok := node.(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+		var name string
+		switch fun := call.Fun.(type) {
+		case *ast.SelectorExpr:
+			name = fun.Sel.Name
+		case *ast.Ident:
+			name = fun.Name
+		}
+		switch name {
+		case "ShouldBindJSON", "BindJSON":
+			usage.jsonBody = boundType(decl.Body, call)
+		case "ShouldBindQuery", "BindQuery":
+			usage.queryType = boundType(decl.Body, call)
+		case "ShouldBind", "Bind":
+			if method == http.MethodGet || method == http.MethodDelete {
+				usage.queryType = boundType(decl.Body, call)
+			} else {
+				usage.jsonBody = boundType(decl.Body, call)
+			}
+		case "Query", "DefaultQuery", "GetQuery", "QueryArray":
+			addName(&usage.query, stringArg(call))
+		case "FormFile":
+			addName(&usage.formFiles, stringArg(call))
+		case "PostForm", "DefaultPostForm":
+			addName(&usage.formFields, stringArg(call))
+		case "GetRawData":
+			usage.rawBody = true
+		case "GetCurrentUser":
+			usage.auth = true
+		case "Get", "MustGet":
+			if currentUserKeys[stringArg(call)] {
+				usage.auth = true
+			}
+		}
+		return true
+	})
+	return usage
+}
+
+// stringArg 调用的第一个字符串字面量参数
+func stringArg(call *ast.CallExpr) string {
+	if len(call.Args) == 0 {
+		return ""
+	}
+	lit, ok := call.Args[0].(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return ""
+	}
+	value, _ := strconv.Unquote(lit.Value)
+	return value
+}
+
+// boundType 查找 ShouldBindJSON(&req) 中 req 声明的类型
+func boundType(body *ast.BlockStmt, call *ast.CallExpr) ast.Expr {
+	if len(call.Args) == 0 {
+		return nil
+	}
+	arg := call.Args[0]
+	if unary, ok := arg.(*ast.UnaryExpr); ok && unary.Op == token.AND {
+		arg = unary.X
+	}
+	ident, ok := arg.(*ast.Ident)
+	if !ok {
+		return nil
+	}
+
+	var found ast.Expr
+	ast.Inspect(body, func(node ast.Node) bool {
+		if found != nil {
+			return false
+		}
+		switch node := node.(type) {
+		case *ast.ValueSpec:
+			for _, name := range node.Names {
+				if name.Name == ident.Name && node.Type != nil {
+					found = node.Type
+				}
+			}
+		case *ast.AssignStmt:
+			if node.Tok != token.DEFINE {
+				return true
+			}
+			for i, lhs := range node.Lhs {
+				if name, ok := lhs.(*ast.Ident); ok && name.Name == ident.Name && i < len(node.Rhs) {
+					found = literalType(node.Rhs[i])
+				}
+			}
+		}
+		return true
+	})
+	return found
+}
+
+func literalType(expr ast.Expr) ast.Expr {
+	if unary, ok := expr.(*ast.UnaryExpr); ok && unary.Op == token.AND {
+		expr = unary.X
+	}
+	if lit, ok := expr.(*ast.CompositeLit); ok {
+		return lit.Type
+	}
+	return nil
+}
+
+// operationBuilder 根据处理函数的源码和注解生成接口文档
+type operationBuilder struct {
+	loader  *loader
+	schemas *schemaBuilder
+}
+
+func (b *operationBuilder) build(method, ginPath string, handler *funcDecl) *Operation {
+	op := &Operation{Responses: map[string]*Response{}}
+	a := parseAnnotations(handler.decl)
+	usage := analyzeHandler(handler.decl, method)
+	file, pkg := handler.file, handler.pkg
+
+	op.Tags = a.tags
+	op.Summary = a.summary
+	if op.Summary == "" {
+		op.Summary = a.doc
+	}
+	op.Description = a.description
+	if a.security || usage.auth {
+		op.Security = []map[string][]string{{securitySchemeName: {}}}
+	}
+
+	params := map[string]*Parameter{}
+	addParam := func(param *Parameter) {
+		key := param.In + ":" + param.Name
+		if params[key] == nil {
+			params[key] = param
+			op.Parameters = append(op.Parameters, param)
+		}
+	}
+
+	// 注解中的参数优先
+	var bodySchema *Schema
+	var bodyDescription string
+	var formSchema *Schema
+	for _, line := range a.params {
+		m := paramPattern.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		name, in, typ, required, description := m[1], m[2], m[3], m[4] == "true", m[5]
+		switch in {
+		case "body":
+			bodySchema = b.typeRef(typ, file)
+			bodyDescription = description
+		case "query":
+			if decl := b.resolveStruct(typ, file); decl != nil {
+				for _, param := range b.structParams(decl.spec.Type.(*ast.StructType), decl.file, decl.pkg) {
+					addParam(param)
+				}
+				continue
+			}
+			addParam(&Parameter{Name: name, In: in, Description: description, Required: required, Schema: b.typeRef(typ, file)})
+		case "formData":
+			if formSchema == nil {
+				formSchema = &Schema{Type: "object", Properties: map[string]*Schema{}}
+			}
+			formSchema.Properties[name] = withDescription(b.typeRef(typ, file), description)
+			if required {
+				formSchema.Required = append(formSchema.Required, name)
+			}
+		case "path", "header":
+			addParam(&Parameter{Name: name, In: in, Description: description, Required: in == "path" || required, Schema: b.typeRef(typ, file)})
+		}
+	}
+
+	// 路径参数
+	for _, m := range ginParamNames(ginPath) {
+		addParam(&Parameter{Name: m, In: "path", Required: true, Schema: pathParamSchema(m)})
+	}
+
+	// 从代码推断的参数
+	if usage.queryType != nil {
+		if decl, declFile, ok := b.schemas.embeddedStruct(usage.queryType, file, pkg); ok {
+			for _, param := range b.structParams(decl.spec.Type.(*ast.StructType), declFile, decl.pkg) {
+				addParam(param)
+			}
+		} else if st, ok := usage.queryType.(*ast.StructType); ok {
+			for _, param := range b.structParams(st, file, pkg) {
+				addParam(param)
+			}
+		}
+	}
+	for _, name := range usage.query {
+		addParam(&Parameter{Name: name, In: "query", Schema: &Schema{Type: "string"}})
+	}
+	if bodySchema == nil && usage.jsonBody != nil {
+		bodySchema = b.schemas.typeSchema(usage.jsonBody, file, pkg)
+	}
+	if formSchema == nil && len(usage.formFiles)+len(usage.formFields) > 0 {
+		formSchema = &Schema{Type: "object", Properties: map[string]*Schema{}}
+		for _, name := range usage.formFiles {
+			formSchema.Properties[name] = &Schema{Type: "string", Format: "binary"}
+			formSchema.Required = append(formSchema.Required, name)
+		}
+		for _, name := range usage.formFields {
+			formSchema.Properties[name] = &Schema{Type: "string"}
+		}
+	}
+
+	switch {
+	case formSchema != nil:
+		op.RequestBody = &RequestBody{Required: true, Content: map[string]MediaType{"multipart/form-data": {Schema: formSchema}}}
+	case bodySchema != nil:
+		contentType := "application/json"
+		if len(a.accept) > 0 {
+			contentType = mimeType(a.accept[0])
+		}
+		op.RequestBody = &RequestBody{Description: bodyDescription, Required: true, Content: map[string]MediaType{contentType: {Schema: bodySchema}}}
+	case usage.rawBody || (len(a.accept) > 0 && mimeType(a.accept[0]) == "application/octet-stream"):
+		op.RequestBody = &RequestBody{Required: true, Content: map[string]MediaType{"application/octet-stream": {Schema: &Schema{Type: "string", Format: "binary"}}}}
+	}
+
+	for _, line := range a.responses {
+		m := responsePattern.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		code, kind, typ, description := m[1], m[2], m[3], m[4]
+		if description == "" {
+			description = http.StatusText(atoi(code))
+		}
+		response := &Response{Description: description}
+		if kind == "file" {
+			response.Content = map[string]MediaType{"application/octet-stream": {Schema: &Schema{Type: "string", Format: "binary"}}}
+		} else {
+			response.Content = map[string]MediaType{"application/json": {Schema: b.typeRef(typ, file)}}
+		}
+		op.Responses[code] = response
+	}
+	if len(op.Responses) == 0 {
+		op.Responses["200"] = &Response{
+			Description: "成功",
+			Content:     map[string]MediaType{"application/json": {Schema: b.typeRef("response.Response", nil)}},
+		}
+	}
+	return op
+}
+
+// structParams 将查询结构体的字段展开为查询参数，参数名使用form标签
+func (b *operationBuilder) structParams(st *ast.StructType, file *ast.File, pkg *sourcePackage) []*Parameter {
+	var params []*Parameter
+	for _, field := range st.Fields.List {
+		tag := fieldTag(field)
+		formName, _, _ := strings.Cut(tag.Get("form"), ",")
+		if formName == "-" {
+			continue
+		}
+		if len(field.Names) == 0 && formName == "" {
+			if decl, declFile, ok := b.schemas.embeddedStruct(field.Type, file, pkg); ok {
+				params = append(params, b.structParams(decl.spec.Type.(*ast.StructType), declFile, decl.pkg)...)
+				continue
+			}
+		}
+		if formName == "" {
+			formName, _, _ = strings.Cut(tag.Get("json"), ",")
+		}
+		for _, name := range fieldNames(field) {
+			paramName := formName
+			if paramName == "" {
+				paramName = name
+			}
+			if !isExported(name) || paramName == "-" {
+				continue
+			}
+			params = append(params, &Parameter{
+				Name:        paramName,
+				In:          "query",
+				Description: fieldDescription(field, tag),
+				Required:    isRequired(tag),
+				Schema:      b.schemas.typeSchema(field.Type, file, pkg),
+			})
+		}
+	}
+	return params
+}
+
+// resolveStruct 解析注解中 pkg.Type 形式的结构体
+func (b *operationBuilder) resolveStruct(typ string, file *ast.File) *typeDecl {
+	qualifier, name, ok := strings.Cut(typ, ".")
+	if !ok {
+		return nil
+	}
+	decl := b.loader.lookupType(file, qualifier, name)
+	if decl == nil {
+		return nil
+	}
+	if _, ok := decl.spec.Type.(*ast.StructType); !ok {
+		return nil
+	}
+	return decl
+}
+
+// typeRef 解析swag注解中的类型，如 []system.SysUser、response.Response{data=system.SysFile,msg=string}
+func (b *operationBuilder) typeRef(typ string, file *ast.File) *Schema {
+	if open := strings.Index(typ, "{"); open > 0 && strings.HasSuffix(typ, "}") && !strings.HasSuffix(typ, "interface{}") {
+		base := b.typeRef(typ[:open], file)
+		override := &Schema{Type: "object", Properties: map[string]*Schema{}}
+		for _, field := range splitTopLevel(typ[open+1 : len(typ)-1]) {
+			name, value, ok := strings.Cut(field, "=")
+			if ok {
+				override.Properties[name] = b.typeRef(value, file)
+			}
+		}
+		return &Schema{AllOf: []*Schema{base, override}}
+	}
+	if elem, ok := strings.CutPrefix(typ, "[]"); ok {
+		return &Schema{Type: "array", Items: b.typeRef(elem, file)}
+	}
+	if strings.HasPrefix(typ, "map[") {
+		if end := strings.Index(typ, "]"); end > 0 {
+			return &Schema{Type: "object", AdditionalProperties: b.typeRef(typ[end+1:], file)}
+		}
+	}
+
+	switch typ {
+	case "string":
+		return &Schema{Type: "string"}
+	case "int", "integer", "uint":
+		return &Schema{Type: "integer"}
+	case "number", "float64":
+		return &Schema{Type: "number"}
+	case "bool", "boolean":
+		return &Schema{Type: "boolean"}
+	case "file":
+		return &Schema{Type: "string", Format: "binary"}
+	case "interface{}", "any":
+		return &Schema{}
+	case "object":
+		return &Schema{Type: "object"}
+	}
+
+	qualifier, name, ok := strings.Cut(typ, ".")
+	if ok {
+		if decl := b.loader.lookupType(file, qualifier, name); decl != nil {
+			return b.schemas.declSchema(decl)
+		}
+	}
+	return &Schema{Type: "object"}
+}
+
+// splitTopLevel 按不在括号内的逗号分割
+func splitTopLevel(s string) []string {
+	var parts []string
+	depth, start := 0, 0
+	for i, r := range s {
+		switch r {
+		case '{', '[':
+			depth++
+		case '}', ']':
+			depth--
+		case ',':
+			if depth == 0 {
+				parts = append(parts, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// ginParamNames gin路由中的参数名
+func ginParamNames(ginPath string) []string {
+	var names []string
+	for _, segment := range strings.Split(ginPath, "/") {
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+			names = append(names, segment[1:])
+		}
+	}
+	return names
+}
+
+// pathParamSchema 未注解的路径参数，id按整数处理
+func pathParamSchema(name string) *Schema {
+	if name == "id" {
+		return &Schema{Type: "integer"}
+	}
+	return &Schema{Type: "string"}
+}
+
+func atoi(s string) int {
+	n, _ := strconv.Atoi(s)
+	return n
+}
+
+// sortedTags 文档中出现的全部标签
+func sortedTags(paths map[string]map[string]*Operation) []Tag {
+	seen := map[string]bool{}
+	var names []string
+	for _, item := range paths {
+		for _, op := range item {
+			for _, tag := range op.Tags {
+				if !seen[tag] {
+					seen[tag] = true
+					names = append(names, tag)
+				}
+			}
+		}
+	}
+	sort.Strings(names)
+	tags := make([]Tag, 0, len(names))
+	for _, name := range names {
+		tags = append(tags, Tag{Name: name})
+	}
+	return tags
+}
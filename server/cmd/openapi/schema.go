@@ -0,0 +1,287 @@
+package main
+
+import (
+	"go/ast"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// schemaBuilder 将Go类型转换为 OpenAPI schema，结构体登记为 components 中的引用
+type schemaBuilder struct {
+	loader  *loader
+	schemas map[string]*Schema
+	names   map[*typeDecl]string
+}
+
+func newSchemaBuilder(l *loader) *schemaBuilder {
+	return &schemaBuilder{loader: l, schemas: map[string]*Schema{}, names: map[*typeDecl]string{}}
+}
+
+// externalTypes 模块外常用类型对应的schema
+var externalTypes = map[string]func() *Schema{
+	"time.Time":                   func() *Schema { return &Schema{Type: "string", Format: "date-time"} },
+	"time.Duration":               func() *Schema { return &Schema{Type: "integer", Format: "int64"} },
+	"gorm.io/gorm.DeletedAt":      func() *Schema { return &Schema{Type: "string", Format: "date-time", Nullable: true} },
+	"encoding/json.RawMessage":    func() *Schema { return &Schema{} },
+	"gorm.io/datatypes.JSON":      func() *Schema { return &Schema{} },
+	"github.com/google/uuid.UUID": func() *Schema { return &Schema{Type: "string", Format: "uuid"} },
+	"mime/multipart.FileHeader":   func() *Schema { return &Schema{Type: "string", Format: "binary"} },
+}
+
+// builtinSchema Go内置类型对应的schema
+func builtinSchema(name string) (*Schema, bool) {
+	switch name {
+	case "string", "error":
+		return &Schema{Type: "string"}, true
+	case "bool":
+		return &Schema{Type: "boolean"}, true
+	case "int", "int8", "int16", "int32", "uint", "uint8", "uint16", "uint32", "byte", "rune":
+		return &Schema{Type: "integer"}, true
+	case "int64", "uint64":
+		return &Schema{Type: "integer", Format: "int64"}, true
+	case "float32":
+		return &Schema{Type: "number", Format: "float"}, true
+	case "float64":
+		return &Schema{Type: "number", Format: "double"}, true
+	case "any":
+		return &Schema{}, true
+	}
+	return nil, false
+}
+
+// typeSchema 在文件上下文中转换类型表达式
+func (b *schemaBuilder) typeSchema(expr ast.Expr, file *ast.File, pkg *sourcePackage) *Schema {
+	switch expr := expr.(type) {
+	case *ast.Ident:
+		if schema, ok := builtinSchema(expr.Name); ok {
+			return schema
+		}
+		if pkg != nil && pkg.types[expr.Name] != nil {
+			return b.declSchema(pkg.types[expr.Name])
+		}
+	case *ast.SelectorExpr:
+		qualifier, ok := expr.X.(*ast.Ident)
+		if !ok {
+			break
+		}
+		p, ok := importPath(file, qualifier.Name)
+		if !ok {
+			break
+		}
+		if external, ok := externalTypes[p+"."+expr.Sel.Name]; ok {
+			return external()
+		}
+		if target := b.loader.load(p); target != nil && target.types[expr.Sel.Name] != nil {
+			return b.declSchema(target.types[expr.Sel.Name])
+		}
+	case *ast.StarExpr:
+		return b.typeSchema(expr.X, file, pkg)
+	case *ast.ArrayType:
+		if ident, ok := expr.Elt.(*ast.Ident); ok && ident.Name == "byte" {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: b.typeSchema(expr.Elt, file, pkg)}
+	case *ast.MapType:
+		return &Schema{Type: "object", AdditionalProperties: b.typeSchema(expr.Value, file, pkg)}
+	case *ast.StructType:
+		return b.structSchema(expr, file, pkg)
+	case *ast.InterfaceType:
+		return &Schema{}
+	}
+	return &Schema{Type: "object"}
+}
+
+// declSchema 结构体返回引用，其他命名类型展开为底层类型
+func (b *schemaBuilder) declSchema(decl *typeDecl) *Schema {
+	if _, ok := decl.spec.Type.(*ast.StructType); !ok {
+		return b.typeSchema(decl.spec.Type, decl.file, decl.pkg)
+	}
+	if name, ok := b.names[decl]; ok {
+		return &Schema{Ref: "#/components/schemas/" + name}
+	}
+
+	name := b.componentName(decl)
+	b.names[decl] = name
+	// 先占位，结构体引用自身时不会无限递归
+	b.schemas[name] = &Schema{}
+	schema := b.structSchema(decl.spec.Type.(*ast.StructType), decl.file, decl.pkg)
+	schema.Description = firstSentence(commentText(decl.spec.Doc), decl.spec.Name.Name)
+	b.schemas[name] = schema
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
+
+// componentName 使用 包名.类型名，同名包中存在同名类型时使用完整路径区分
+func (b *schemaBuilder) componentName(decl *typeDecl) string {
+	name := decl.pkg.name + "." + decl.spec.Name.Name
+	for _, p := range b.loader.byName[decl.pkg.name] {
+		if p == decl.pkg.path {
+			continue
+		}
+		if other := b.loader.load(p); other != nil && other.types[decl.spec.Name.Name] != nil {
+			rel := strings.TrimPrefix(strings.TrimPrefix(decl.pkg.path, b.loader.module), "/")
+			return strings.ReplaceAll(rel, "/", ".") + "." + decl.spec.Name.Name
+		}
+	}
+	return name
+}
+
+func (b *schemaBuilder) structSchema(st *ast.StructType, file *ast.File, pkg *sourcePackage) *Schema {
+	schema := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	b.addFields(schema, st, file, pkg)
+	return schema
+}
+
+// addFields 添加结构体字段，未指定json名称的嵌入字段展开到当前结构体
+func (b *schemaBuilder) addFields(schema *Schema, st *ast.StructType, file *ast.File, pkg *sourcePackage) {
+	for _, field := range st.Fields.List {
+		tag := fieldTag(field)
+		jsonName, _, _ := strings.Cut(tag.Get("json"), ",")
+		if jsonName == "-" {
+			continue
+		}
+
+		if len(field.Names) == 0 && jsonName == "" {
+			if decl, file, ok := b.embeddedStruct(field.Type, file, pkg); ok {
+				b.addFields(schema, decl.spec.Type.(*ast.StructType), file, decl.pkg)
+				continue
+			}
+			if isGormModel(field.Type, file) {
+				schema.Properties["ID"] = &Schema{Type: "integer"}
+				schema.Properties["CreatedAt"] = &Schema{Type: "string", Format: "date-time"}
+				schema.Properties["UpdatedAt"] = &Schema{Type: "string", Format: "date-time"}
+				schema.Properties["DeletedAt"] = &Schema{Type: "string", Format: "date-time", Nullable: true}
+				continue
+			}
+		}
+
+		names := fieldNames(field)
+		for _, name := range names {
+			if !isExported(name) {
+				continue
+			}
+			property := jsonName
+			if property == "" {
+				property = name
+			}
+			fieldSchema := b.typeSchema(field.Type, file, pkg)
+			if _, ok := field.Type.(*ast.StarExpr); ok && fieldSchema.Ref == "" {
+				fieldSchema.Nullable = true
+			}
+			schema.Properties[property] = withDescription(fieldSchema, fieldDescription(field, tag))
+			if isRequired(tag) {
+				schema.Required = append(schema.Required, property)
+			}
+		}
+	}
+}
+
+// embeddedStruct 解析嵌入字段对应的模块内结构体
+func (b *schemaBuilder) embeddedStruct(expr ast.Expr, file *ast.File, pkg *sourcePackage) (*typeDecl, *ast.File, bool) {
+	if star, ok := expr.(*ast.StarExpr); ok {
+		expr = star.X
+	}
+	var decl *typeDecl
+	switch expr := expr.(type) {
+	case *ast.Ident:
+		if pkg != nil {
+			decl = pkg.types[expr.Name]
+		}
+	case *ast.SelectorExpr:
+		if qualifier, ok := expr.X.(*ast.Ident); ok {
+			if p, ok := importPath(file, qualifier.Name); ok {
+				if target := b.loader.load(p); target != nil {
+					decl = target.types[expr.Sel.Name]
+				}
+			}
+		}
+	}
+	if decl == nil {
+		return nil, nil, false
+	}
+	if _, ok := decl.spec.Type.(*ast.StructType); !ok {
+		return nil, nil, false
+	}
+	return decl, decl.file, true
+}
+
+func isGormModel(expr ast.Expr, file *ast.File) bool {
+	selector, ok := expr.(*ast.SelectorExpr)
+	if !ok || selector.Sel.Name != "Model" {
+		return false
+	}
+	qualifier, ok := selector.X.(*ast.Ident)
+	if !ok {
+		return false
+	}
+	p, _ := importPath(file, qualifier.Name)
+	return p == "gorm.io/gorm"
+}
+
+func fieldTag(field *ast.Field) reflect.StructTag {
+	if field.Tag == nil {
+		return ""
+	}
+	tag, _ := strconv.Unquote(field.Tag.Value)
+	return reflect.StructTag(tag)
+}
+
+func fieldNames(field *ast.Field) []string {
+	if len(field.Names) == 0 {
+		// 指定了json名称的嵌入字段
+		switch expr := field.Type.(type) {
+		case *ast.Ident:
+			return []string{expr.Name}
+		case *ast.SelectorExpr:
+			return []string{expr.Sel.Name}
+		case *ast.StarExpr:
+			return fieldNames(&ast.Field{Type: expr.X})
+		}
+		return nil
+	}
+	names := make([]string, 0, len(field.Names))
+	for _, name := range field.Names {
+		names = append(names, name.Name)
+	}
+	return names
+}
+
+// fieldDescription 字段说明，优先使用行尾注释，其次使用gorm标签中的comment
+func fieldDescription(field *ast.Field, tag reflect.StructTag) string {
+	if text := commentText(field.Comment); text != "" {
+		return text
+	}
+	if text := commentText(field.Doc); text != "" {
+		return text
+	}
+	for _, part := range strings.Split(tag.Get("gorm"), ";") {
+		if comment, ok := strings.CutPrefix(part, "comment:"); ok {
+			return comment
+		}
+	}
+	return ""
+}
+
+func isRequired(tag reflect.StructTag) bool {
+	for _, rule := range strings.Split(tag.Get("binding"), ",") {
+		if rule == "required" {
+			return true
+		}
+	}
+	return false
+}
+
+func isExported(name string) bool {
+	for _, r := range name {
+		return unicode.IsUpper(r)
+	}
+	return false
+}
+
+// firstSentence 去掉注释开头的标识符名称，只保留第一行
+func firstSentence(text, name string) string {
+	line, _, _ := strings.Cut(text, "\n")
+	line = strings.TrimSpace(strings.TrimPrefix(line, name))
+	return line
+}
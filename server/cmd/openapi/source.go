@@ -0,0 +1,216 @@
+package main
+
+import (
+	"bufio"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// sourcePackage 模块内的一个包
+type sourcePackage struct {
+	path  string // 导入路径
+	name  string // 包名
+	types map[string]*typeDecl
+	funcs map[string]*funcDecl
+}
+
+type typeDecl struct {
+	spec *ast.TypeSpec
+	file *ast.File
+	pkg  *sourcePackage
+}
+
+type funcDecl struct {
+	decl *ast.FuncDecl
+	file *ast.File
+	pkg  *sourcePackage
+}
+
+// loader 按需解析模块内包的源码
+type loader struct {
+	root   string
+	module string
+	fset   *token.FileSet
+	pkgs   map[string]*sourcePackage
+	byName map[string][]string // 包名 -> 导入路径
+}
+
+func newLoader(root string) (*loader, error) {
+	module, err := readModulePath(filepath.Join(root, "go.mod"))
+	if err != nil {
+		return nil, err
+	}
+	l := &loader{
+		root:   root,
+		module: module,
+		fset:   token.NewFileSet(),
+		pkgs:   map[string]*sourcePackage{},
+		byName: map[string][]string{},
+	}
+
+	// 建立包名索引，用于解析注释中的 system.SysUser 这类引用
+	err = filepath.WalkDir(root, func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if name != root && (strings.HasPrefix(d.Name(), ".") || d.Name() == "uploads") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") {
+			return nil
+		}
+		file, err := parser.ParseFile(l.fset, name, nil, parser.PackageClauseOnly)
+		if err != nil {
+			return nil
+		}
+		rel, _ := filepath.Rel(root, filepath.Dir(name))
+		importPath := path.Join(module, filepath.ToSlash(rel))
+		pkgName := file.Name.Name
+		for _, existing := range l.byName[pkgName] {
+			if existing == importPath {
+				return nil
+			}
+		}
+		l.byName[pkgName] = append(l.byName[pkgName], importPath)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, paths := range l.byName {
+		sort.Strings(paths)
+	}
+	return l, nil
+}
+
+func readModulePath(goMod string) (string, error) {
+	file, err := os.Open(goMod)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if module, ok := strings.CutPrefix(strings.TrimSpace(scanner.Text()), "module "); ok {
+			return strings.Trim(strings.TrimSpace(module), `"`), nil
+		}
+	}
+	return "", os.ErrNotExist
+}
+
+// load 解析导入路径对应的包，不属于本模块时返回nil
+func (l *loader) load(importPath string) *sourcePackage {
+	if pkg, ok := l.pkgs[importPath]; ok {
+		return pkg
+	}
+	rel, ok := strings.CutPrefix(importPath, l.module)
+	if !ok || (rel != "" && !strings.HasPrefix(rel, "/")) {
+		return nil
+	}
+	dir := filepath.Join(l.root, filepath.FromSlash(strings.TrimPrefix(rel, "/")))
+
+	l.pkgs[importPath] = nil
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	pkg := &sourcePackage{path: importPath, types: map[string]*typeDecl{}, funcs: map[string]*funcDecl{}}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		file, err := parser.ParseFile(l.fset, filepath.Join(dir, name), nil, parser.ParseComments)
+		if err != nil {
+			continue
+		}
+		pkg.name = file.Name.Name
+		for _, decl := range file.Decls {
+			switch decl := decl.(type) {
+			case *ast.FuncDecl:
+				if decl.Recv == nil {
+					pkg.funcs[decl.Name.Name] = &funcDecl{decl: decl, file: file, pkg: pkg}
+				}
+			case *ast.GenDecl:
+				for _, spec := range decl.Specs {
+					if spec, ok := spec.(*ast.TypeSpec); ok {
+						if spec.Doc == nil && len(decl.Specs) == 1 {
+							spec.Doc = decl.Doc
+						}
+						pkg.types[spec.Name.Name] = &typeDecl{spec: spec, file: file, pkg: pkg}
+					}
+				}
+			}
+		}
+	}
+	l.pkgs[importPath] = pkg
+	return pkg
+}
+
+// lookupFunc 根据gin记录的处理函数名查找函数，如 server/api/v1.Login，闭包返回外层函数
+func (l *loader) lookupFunc(handlerName string) *funcDecl {
+	slash := strings.LastIndex(handlerName, "/")
+	dot := strings.Index(handlerName[slash+1:], ".")
+	if dot < 0 {
+		return nil
+	}
+	importPath := handlerName[:slash+1+dot]
+	name, _, _ := strings.Cut(handlerName[slash+1+dot+1:], ".")
+	pkg := l.load(importPath)
+	if pkg == nil {
+		return nil
+	}
+	return pkg.funcs[name]
+}
+
+// importPath 解析文件中导入包的别名
+func importPath(file *ast.File, alias string) (string, bool) {
+	for _, spec := range file.Imports {
+		p, _ := strconv.Unquote(spec.Path.Value)
+		name := path.Base(p)
+		if spec.Name != nil {
+			name = spec.Name.Name
+		}
+		if name == alias {
+			return p, true
+		}
+	}
+	return "", false
+}
+
+// lookupType 在文件上下文中查找 pkg.Type 形式的类型，先按文件的导入别名，找不到时再按包名
+func (l *loader) lookupType(file *ast.File, qualifier, name string) *typeDecl {
+	if file != nil {
+		if p, ok := importPath(file, qualifier); ok {
+			if pkg := l.load(p); pkg != nil && pkg.types[name] != nil {
+				return pkg.types[name]
+			}
+		}
+	}
+	// 注解中的包名不一定与文件的导入别名一致，如 system.OperationLogRequest 实际在 model/system 中
+	for _, p := range l.byName[qualifier] {
+		if pkg := l.load(p); pkg != nil && pkg.types[name] != nil {
+			return pkg.types[name]
+		}
+	}
+	return nil
+}
+
+// commentText 注释的纯文本，去掉 // 前缀
+func commentText(group *ast.CommentGroup) string {
+	if group == nil {
+		return ""
+	}
+	return strings.TrimSpace(group.Text())
+}
@@ -8,6 +8,12 @@ type Server struct {
 	System System `mapstructure:"system" json:"system" yaml:"system"`
 	Mysql  Mysql  `mapstructure:"mysql" json:"mysql" yaml:"mysql"`
 	CORS   CORS   `mapstructure:"cors" json:"cors" yaml:"cors"`
+
+	OperationLog OperationLog `mapstructure:"operation-log" json:"operation-log" yaml:"operation-log"`
+	Session      Session      `mapstructure:"session" json:"session" yaml:"session"`
+	Metrics      Metrics      `mapstructure:"metrics" json:"metrics" yaml:"metrics"`
+	Oss          Oss          `mapstructure:"oss" json:"oss" yaml:"oss"`
+	Avatar       Avatar       `mapstructure:"avatar" json:"avatar" yaml:"avatar"`
 }
 
 type System struct {
@@ -25,6 +31,12 @@ type JWT struct {
 	Issuer      string `mapstructure:"issuer" json:"issuer" yaml:"issuer"`
 }
 
+// Session 在线会话配置
+type Session struct {
+	IdleTimeout   int `mapstructure:"idle-timeout" json:"idle-timeout" yaml:"idle-timeout"`       // 超过该时间无请求视为离线，单位分钟
+	TouchInterval int `mapstructure:"touch-interval" json:"touch-interval" yaml:"touch-interval"` // 最后活动时间的写入间隔，单位秒
+}
+
 type Casbin struct {
 	ModelPath string `mapstructure:"model-path" json:"model-path" yaml:"model-path"`
 }
@@ -59,6 +71,100 @@ type Zap struct {
 	LogInConsole  bool   `mapstructure:"log-in-console" json:"log-in-console" yaml:"log-in-console"`
 }
 
+// Metrics Prometheus指标配置
+type Metrics struct {
+	Enabled bool   `mapstructure:"enabled" json:"enabled" yaml:"enabled"`
+	Path    string `mapstructure:"path" json:"path" yaml:"path"`    // 指标路径，默认 /metrics
+	Token   string `mapstructure:"token" json:"token" yaml:"token"` // 不为空时抓取需携带 Authorization: Bearer <token>
+}
+
+// Oss 文件存储配置
+type Oss struct {
+	Type    string   `mapstructure:"type" json:"type" yaml:"type"`             // local-本地磁盘, s3-S3兼容存储, minio-MinIO
+	MaxSize int64    `mapstructure:"max-size" json:"max-size" yaml:"max-size"` // 文件管理单个文件的大小上限，单位MB
+	Chunk   Chunk    `mapstructure:"chunk" json:"chunk" yaml:"chunk"`
+	Local   LocalOss `mapstructure:"local" json:"local" yaml:"local"`
+	S3      S3Oss    `mapstructure:"s3" json:"s3" yaml:"s3"`
+}
+
+// Chunk 分片上传配置，分片保存在文件存储中，多副本部署时可以在任意实例上续传
+type Chunk struct {
+	Size    int64 `mapstructure:"size" json:"size" yaml:"size"`             // 分片大小，单位MB
+	MaxSize int64 `mapstructure:"max-size" json:"max-size" yaml:"max-size"` // 分片上传的文件大小上限，单位MB
+	Expire  int   `mapstructure:"expire" json:"expire" yaml:"expire"`       // 未完成的上传超过N小时无新分片时由定时任务清理
+}
+
+// LocalOss 本地磁盘存储，多副本部署时需挂载共享目录
+type LocalOss struct {
+	Path    string `mapstructure:"path" json:"path" yaml:"path"`             // 存储目录
+	BaseURL string `mapstructure:"base-url" json:"base-url" yaml:"base-url"` // 访问地址前缀，以 / 开头时由服务直接提供静态访问
+}
+
+// S3Oss S3兼容存储（AWS S3、MinIO、阿里云OSS、腾讯云COS等）
+type S3Oss struct {
+	Endpoint       string `mapstructure:"endpoint" json:"endpoint" yaml:"endpoint"`                      // 如 s3.amazonaws.com、127.0.0.1:9000
+	Region         string `mapstructure:"region" json:"region" yaml:"region"`                            // 区域，MinIO可为空
+	Bucket         string `mapstructure:"bucket" json:"bucket" yaml:"bucket"`                            // 存储桶
+	AccessKey      string `mapstructure:"access-key" json:"access-key" yaml:"access-key"`                // 访问密钥
+	SecretKey      string `mapstructure:"secret-key" json:"secret-key" yaml:"secret-key"`                // 访问密钥
+	UseSSL         bool   `mapstructure:"use-ssl" json:"use-ssl" yaml:"use-ssl"`                         // 是否使用HTTPS
+	PathStyle      bool   `mapstructure:"path-style" json:"path-style" yaml:"path-style"`                // 使用路径风格访问，MinIO和自建服务通常需要开启
+	Prefix         string `mapstructure:"prefix" json:"prefix" yaml:"prefix"`                            // 对象键前缀，多个环境共用存储桶时区分
+	BaseURL        string `mapstructure:"base-url" json:"base-url" yaml:"base-url"`                      // 公开访问地址（如CDN域名），为空时使用endpoint拼接
+	Presign        bool   `mapstructure:"presign" json:"presign" yaml:"presign"`                         // 存储桶不公开时使用预签名地址访问
+	PresignExpires int    `mapstructure:"presign-expires" json:"presign-expires" yaml:"presign-expires"` // 预签名地址有效期，单位秒
+}
+
+// Avatar 头像处理配置，上传的头像会居中裁剪为正方形并重新编码，不保留EXIF等元数据
+type Avatar struct {
+	Size       int   `mapstructure:"size" json:"size" yaml:"size"`                   // 头像边长，单位像素
+	Thumbnails []int `mapstructure:"thumbnails" json:"thumbnails" yaml:"thumbnails"` // 缩略图边长，单位像素
+	Quality    int   `mapstructure:"quality" json:"quality" yaml:"quality"`          // JPEG质量，1-100
+	MaxPixels  int   `mapstructure:"max-pixels" json:"max-pixels" yaml:"max-pixels"` // 原图的最大像素数，防止解压炸弹
+}
+
 type CORS struct {
 	Mode string `mapstructure:"mode" json:"mode" yaml:"mode"`
 }
+
+type OperationLog struct {
+	Redact        Redact            `mapstructure:"redact" json:"redact" yaml:"redact"`
+	Queue         OperationLogQueue `mapstructure:"queue" json:"queue" yaml:"queue"`
+	CheckpointKey string            `mapstructure:"checkpoint-key" json:"checkpoint-key" yaml:"checkpoint-key"` // 哈希链和归档检查点的签名密钥，为空时使用JWT签名密钥
+	Retention     LogRetention      `mapstructure:"retention" json:"retention" yaml:"retention"`
+}
+
+// LogRetention 日志保留策略，超出范围的日志先归档再清理
+type LogRetention struct {
+	Enabled  bool   `mapstructure:"enabled" json:"enabled" yaml:"enabled"`       // 首次启动时创建的定时任务是否启用
+	KeepDays int    `mapstructure:"keep-days" json:"keep-days" yaml:"keep-days"` // 保留最近N天的日志，0表示不限制
+	MaxRows  int    `mapstructure:"max-rows" json:"max-rows" yaml:"max-rows"`    // 最多保留的日志条数，0表示不限制
+	Cron     string `mapstructure:"cron" json:"cron" yaml:"cron"`                // 首次启动时创建的定时任务的cron表达式
+}
+
+// OperationLogQueue 操作日志写入队列配置
+type OperationLogQueue struct {
+	Size           int    `mapstructure:"size" json:"size" yaml:"size"`                                  // 队列容量
+	BatchSize      int    `mapstructure:"batch-size" json:"batch-size" yaml:"batch-size"`                // 每批写入条数
+	FlushInterval  int    `mapstructure:"flush-interval" json:"flush-interval" yaml:"flush-interval"`    // 最长写入间隔，单位毫秒
+	ReplayInterval int    `mapstructure:"replay-interval" json:"replay-interval" yaml:"replay-interval"` // 重放暂存文件的间隔，单位秒
+	Overflow       string `mapstructure:"overflow" json:"overflow" yaml:"overflow"`                      // 队列满时的处理方式: spool-写入暂存文件, drop-丢弃
+	SpoolPath      string `mapstructure:"spool-path" json:"spool-path" yaml:"spool-path"`                // 数据库不可用时的暂存文件
+}
+
+// Redact 操作日志脱敏配置
+type Redact struct {
+	Strategy string       `mapstructure:"strategy" json:"strategy" yaml:"strategy"`    // 默认脱敏方式: full-完全遮盖, partial-保留首尾, hash-哈希
+	HashSalt string       `mapstructure:"hash-salt" json:"hash-salt" yaml:"hash-salt"` // hash方式使用的密钥
+	Fields   []string     `mapstructure:"fields" json:"fields" yaml:"fields"`          // 任意层级都需要脱敏的字段名（不区分大小写）
+	Rules    []RedactRule `mapstructure:"rules" json:"rules" yaml:"rules"`             // 按路由配置的JSON路径规则
+}
+
+// RedactRule 按路由配置的脱敏规则
+type RedactRule struct {
+	Method   string   `mapstructure:"method" json:"method" yaml:"method"`       // 请求方法，为空时匹配所有方法
+	Path     string   `mapstructure:"path" json:"path" yaml:"path"`             // 请求路径，支持 * 和 :param 匹配单段路径
+	Target   string   `mapstructure:"target" json:"target" yaml:"target"`       // request、response，为空时两者都处理
+	Paths    []string `mapstructure:"paths" json:"paths" yaml:"paths"`          // JSON路径，如 data.token、list[*].phone
+	Strategy string   `mapstructure:"strategy" json:"strategy" yaml:"strategy"` // 为空时使用默认脱敏方式
+}
@@ -1,12 +1,18 @@
 package core
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"server/global"
 	"server/initialize"
+	"server/service/system"
 
 	"github.com/gin-gonic/gin"
 )
@@ -27,6 +33,12 @@ func RunWindowsServer() {
 		initialize.Redis()
 	}
 
+	// 启动操作日志批量写入器
+	system.StartOperationLogWriter(global.CONFIG.OperationLog.Queue)
+	if global.CONFIG.Metrics.Enabled {
+		system.RegisterMetricsCollectors()
+	}
+
 	Router := initialize.Routers()
 	Router.Static("/form-generator", "./resource/page")
 
@@ -44,5 +56,33 @@ func RunWindowsServer() {
 	fmt.Printf(`
 	项目运行中...
 	`)
-	fmt.Println(s.ListenAndServe().Error())
+	go func() {
+		if err := s.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			fmt.Println(err.Error())
+			os.Exit(1)
+		}
+	}()
+
+	// 等待退出信号，优雅关闭服务
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+	fmt.Println("正在关闭服务...")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := s.Shutdown(ctx); err != nil {
+		fmt.Printf("服务关闭失败: %v\n", err)
+	}
+
+	// 停止调度并等待正在执行的定时任务
+	if err := system.StopJobScheduler(ctx); err != nil {
+		fmt.Printf("定时任务停止失败: %v\n", err)
+	}
+
+	// 请求处理完毕后写入剩余的操作日志
+	if err := system.StopOperationLogWriter(ctx); err != nil {
+		fmt.Printf("操作日志写入失败: %v\n", err)
+	}
+	fmt.Println("服务已关闭")
 }
@@ -6,6 +6,7 @@ import (
 	"os"
 
 	"server/global"
+	"server/service/system"
 
 	"github.com/fsnotify/fsnotify"
 	"github.com/spf13/viper"
@@ -48,10 +49,12 @@ func Viper(path ...string) *viper.Viper {
 		if err = v.Unmarshal(&global.CONFIG); err != nil {
 			fmt.Println(err)
 		}
+		system.ReloadOperationLogRedactor()
 	})
 	if err = v.Unmarshal(&global.CONFIG); err != nil {
 		fmt.Println(err)
 	}
+	system.ReloadOperationLogRedactor()
 
 	return v
 }
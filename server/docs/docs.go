@@ -0,0 +1,95 @@
+// Package docs 提供由接口源码生成的 OpenAPI 3 文档，修改接口后执行 go generate ./docs 重新生成
+package docs
+
+import (
+	_ "embed"
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+//go:generate go run ../cmd/openapi -root .. -o openapi.json
+
+// RoutePrefix 文档页面的访问前缀，不参与接口文档的覆盖检查
+const RoutePrefix = "/swagger"
+
+//go:embed openapi.json
+var spec []byte
+
+// ginParamPattern gin路由中的 :id 和 *path 参数
+var ginParamPattern = regexp.MustCompile(`[:*](\w+)`)
+
+// Spec 生成的 OpenAPI 文档
+func Spec() []byte {
+	return spec
+}
+
+// OpenAPIPath 将gin路由路径转换为 OpenAPI 路径，如 /user/:id 转换为 /user/{id}
+func OpenAPIPath(ginPath string) string {
+	return ginParamPattern.ReplaceAllString(ginPath, "{$1}")
+}
+
+// Register 注册文档页面 /swagger/index.html 和文档内容 /swagger/doc.json
+func Register(router gin.IRoutes) {
+	router.GET(RoutePrefix, func(c *gin.Context) {
+		c.Redirect(http.StatusMovedPermanently, RoutePrefix+"/index.html")
+	})
+	router.GET(RoutePrefix+"/index.html", func(c *gin.Context) {
+		c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(indexHTML))
+	})
+	router.GET(RoutePrefix+"/doc.json", func(c *gin.Context) {
+		c.Data(http.StatusOK, "application/json; charset=utf-8", spec)
+	})
+}
+
+// MissingRoutes 返回已注册但文档中没有的路由，文档页面自身的路由除外
+func MissingRoutes(routes gin.RoutesInfo) ([]gin.RouteInfo, error) {
+	var doc struct {
+		Paths map[string]map[string]json.RawMessage `json:"paths"`
+	}
+	if err := json.Unmarshal(spec, &doc); err != nil {
+		return nil, err
+	}
+
+	var missing []gin.RouteInfo
+	for _, route := range routes {
+		if route.Path == RoutePrefix || strings.HasPrefix(route.Path, RoutePrefix+"/") {
+			continue
+		}
+		if _, ok := doc.Paths[OpenAPIPath(route.Path)][strings.ToLower(route.Method)]; !ok {
+			missing = append(missing, route)
+		}
+	}
+	sort.Slice(missing, func(i, j int) bool {
+		if missing[i].Path != missing[j].Path {
+			return missing[i].Path < missing[j].Path
+		}
+		return missing[i].Method < missing[j].Method
+	})
+	return missing, nil
+}
+
+const indexHTML = `<!DOCTYPE html>
+<html lang="zh-CN">
+<head>
+  <meta charset="utf-8">
+  <title>API 文档</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js"></script>
+  <script>
+    window.ui = SwaggerUIBundle({
+      url: "doc.json",
+      dom_id: "#swagger-ui",
+      persistAuthorization: true
+    });
+  </script>
+</body>
+</html>
+`
@@ -0,0 +1,15 @@
+package global
+
+import "time"
+
+// 构建信息，编译时通过 ldflags 注入：
+//
+//	go build -ldflags "-X server/global.Version=v1.0.0 -X server/global.GitCommit=$(git rev-parse --short HEAD) -X server/global.BuildTime=$(date -u +%Y-%m-%dT%H:%M:%SZ)" -o app main.go
+var (
+	Version   = "v1.0.0"
+	GitCommit = ""
+	BuildTime = ""
+)
+
+// StartTime 进程启动时间
+var StartTime = time.Now()
@@ -8,6 +8,7 @@ import (
 
 	"server/global"
 	"server/model/system"
+	systemService "server/service/system"
 	"server/utils"
 
 	"github.com/google/uuid"
@@ -20,12 +21,49 @@ const (
 
 // Gorm 初始化数据库并产生数据库全局变量
 func Gorm() *gorm.DB {
+	var db *gorm.DB
 	switch global.CONFIG.System.DbType {
 	case Mysql:
-		return GormMysql()
+		db = GormMysql()
 	default:
-		return GormMysql()
+		db = GormMysql()
 	}
+	if db == nil {
+		return nil
+	}
+	// 记录用户、角色、菜单的数据变更，与数据库类型无关
+	if err := systemService.RegisterEntityChangeCallbacks(db); err != nil {
+		fmt.Printf("注册实体变更回调失败: %v\n", err)
+	}
+	return db
+}
+
+// migrateTables 需要自动迁移的表，注意顺序：先创建被引用的表
+var migrateTables = []interface{}{
+	system.SysAuthority{},
+	system.SysBaseMenu{},
+	system.SysUser{},
+	system.SysUserAuthority{},
+	system.SysPost{},
+	system.SysUserPost{},
+	system.SysBaseMenuParameter{},
+	system.SysBaseMenuBtn{},
+	system.SysAuthorityMenu{},
+	system.SysPermissionTemplate{},
+	system.SysPermissionTemplateMenu{},
+	system.SysOperationLog{},
+	system.SysOperationLogCheckpoint{},
+	system.SysOperationLogChainHead{},
+	system.SysEntityChange{},
+	system.SysJob{},
+	system.SysJobLog{},
+	system.SysJobLock{},
+	system.SysUserSession{},
+	system.SysLoginLog{},
+	system.SysFile{},
+	system.SysFileLock{},
+	system.SysFileUpload{},
+	system.SysFileChunk{},
 }
 
 // RegisterTables 注册数据库表专用
@@ -36,16 +74,7 @@ func RegisterTables() {
 	needsInit := checkIfNeedsInitialization(db)
 
 	// 自动迁移表结构（不删除现有数据）
-	err := db.AutoMigrate(
-		// 系统模块表 - 注意顺序：先创建被引用的表
-		system.SysAuthority{},
-		system.SysBaseMenu{},
-		system.SysUser{},
-		system.SysBaseMenuParameter{},
-		system.SysBaseMenuBtn{},
-		system.SysAuthorityMenu{},
-		system.SysOperationLog{},
-	)
+	err := db.AutoMigrate(migrateTables...)
 
 	if err != nil {
 		fmt.Printf("数据库表迁移失败: %v\n", err)
@@ -61,6 +90,30 @@ func RegisterTables() {
 	} else {
 		fmt.Println("数据库已存在数据，跳过初始化")
 	}
+
+	// 同步用户当前角色到用户角色关联表（兼容单角色时期的数据）
+	syncUserAuthorities(db)
+
+	// 迁移和基础数据完成后才允许就绪检查通过
+	systemService.MarkTablesMigrated(migrateTables)
+}
+
+// syncUserAuthorities 将sys_users.authority_id补录到sys_user_authorities中
+func syncUserAuthorities(db *gorm.DB) {
+	result := db.Exec(`INSERT INTO sys_user_authorities (sys_user_id, sys_authority_authority_id)
+		SELECT u.id, u.authority_id FROM sys_users u
+		WHERE u.deleted_at IS NULL AND u.authority_id <> 0
+		AND NOT EXISTS (
+			SELECT 1 FROM sys_user_authorities ua
+			WHERE ua.sys_user_id = u.id AND ua.sys_authority_authority_id = u.authority_id
+		)`)
+	if result.Error != nil {
+		fmt.Printf("同步用户角色关联失败: %v\n", result.Error)
+		return
+	}
+	if result.RowsAffected > 0 {
+		fmt.Printf("已同步 %d 条用户角色关联\n", result.RowsAffected)
+	}
 }
 
 // checkIfNeedsInitialization 检查是否需要初始化数据库
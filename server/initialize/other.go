@@ -1,17 +1,71 @@
 package initialize
 
+import (
+	"context"
+	"fmt"
+	"server/global"
+	"server/model/system"
+	systemService "server/service/system"
+
+	"github.com/go-redis/redis/v8"
+)
+
 func OtherInit() {
 	// 其他初始化操作
 }
 
+// Timer 创建内置定时任务并启动调度器，需在数据库表初始化后调用
 func Timer() {
-	// 定时器初始化
+	if global.DB == nil {
+		return
+	}
+
+	retention := global.CONFIG.OperationLog.Retention
+	if retention.Cron == "" {
+		retention.Cron = "0 3 * * *"
+	}
+	jobService := systemService.JobService{}
+	if err := jobService.EnsureJob(system.SysJob{
+		Name:    "操作日志保留策略",
+		JobType: systemService.JobTypeOperationLogRetention,
+		Cron:    retention.Cron,
+		Enabled: retention.Enabled,
+		Remark:  "参数为空时使用配置文件中的保留天数和最大条数",
+	}); err != nil {
+		fmt.Printf("创建内置定时任务失败: %v\n", err)
+	}
+	if err := jobService.EnsureJob(system.SysJob{
+		Name:    "分片上传清理",
+		JobType: systemService.JobTypeFileChunkCleanup,
+		Cron:    "30 * * * *",
+		Enabled: true,
+		Remark:  "参数为空时使用配置文件中的过期时间",
+	}); err != nil {
+		fmt.Printf("创建内置定时任务失败: %v\n", err)
+	}
+
+	if err := systemService.StartJobScheduler(); err != nil {
+		fmt.Printf("启动定时任务调度器失败: %v\n", err)
+	}
 }
 
 func DBList() {
 	// 数据库列表初始化
 }
 
+// Redis 初始化Redis连接，连接失败时不启用Redis
 func Redis() {
-	// Redis 初始化
+	redisCfg := global.CONFIG.Redis
+	client := redis.NewClient(&redis.Options{
+		Addr:     redisCfg.Addr,
+		Password: redisCfg.Password,
+		DB:       redisCfg.DB,
+	})
+	if err := client.Ping(context.Background()).Err(); err != nil {
+		fmt.Printf("Redis连接失败: %v\n", err)
+		client.Close()
+		return
+	}
+	fmt.Println("Redis连接成功")
+	global.REDIS = client
 }
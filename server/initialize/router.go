@@ -3,9 +3,15 @@ package initialize
 import (
 	"fmt"
 	"net/http"
+	"path/filepath"
+	"strings"
 
 	v1 "server/api/v1"
+	"server/docs"
+	"server/global"
 	"server/middleware"
+	systemService "server/service/system"
+	"server/utils/upload"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
@@ -20,46 +26,58 @@ func Routers() *gin.Engine {
 		AllowOrigins:     []string{"*"},
 		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
 		AllowHeaders:     []string{"*"},
-		ExposeHeaders:    []string{"Content-Length", "Authorization"},
+		ExposeHeaders:    []string{"Content-Length", "Authorization", "X-Request-Id"},
 		AllowCredentials: true,
 	}))
 
+	// Prometheus指标
+	metricsCfg := global.CONFIG.Metrics
+	if metricsCfg.Enabled {
+		Router.Use(middleware.Metrics())
+		if metricsCfg.Path == "" {
+			metricsCfg.Path = "/metrics"
+		}
+		Router.GET(metricsCfg.Path, middleware.MetricsHandler(metricsCfg.Token))
+	}
+
 	// 操作日志中间件
 	Router.Use(middleware.OperationLogMiddlewareWithBody())
 
-	// 健康监测
-	Router.GET("/health", func(c *gin.Context) {
-		c.JSON(http.StatusOK, gin.H{
-			"message": "ok",
-			"status":  "running",
-		})
-	})
+	// 健康监测：/health 与 /health/live 为存活检查，/health/ready 为就绪检查
+	Router.GET("/health", v1.HealthLive)
+	Router.GET("/health/live", v1.HealthLive)
+	Router.GET("/health/ready", v1.HealthReady)
 
-	// 静态文件服务 - 头像文件访问
-	Router.Static("/uploads", "./uploads")
+	// 静态文件服务 - 本地存储中只公开头像目录，文件管理和分片上传的文件需登录后通过接口下载
+	// 使用对象存储时由存储服务提供访问
+	if storage, err := upload.Storage(); err != nil {
+		fmt.Printf("初始化文件存储失败: %v\n", err)
+	} else if local, ok := storage.(*upload.Local); ok && strings.HasPrefix(local.BaseURL(), "/") {
+		avatarDir := strings.TrimSuffix(systemService.AvatarPrefix, "/")
+		Router.Static(local.BaseURL()+"/"+avatarDir, filepath.Join(local.Root(), avatarDir))
+	}
 
 	// 公开路由组
 	PublicGroup := Router.Group("/api")
 	{
-		PublicGroup.GET("/health", func(c *gin.Context) {
-			c.JSON(http.StatusOK, gin.H{
-				"message": "ok",
-				"status":  "running",
-			})
-		})
+		PublicGroup.GET("/health", v1.HealthLive)
+		PublicGroup.GET("/health/live", v1.HealthLive)
+		PublicGroup.GET("/health/ready", v1.HealthReady)
 
 		// 基础路由（无需认证）
 		BaseGroup := PublicGroup.Group("/base")
 		{
-			BaseGroup.POST("/login", v1.Login)
-			BaseGroup.POST("/logout", v1.Logout)
+			middleware.LogRoute(BaseGroup, http.MethodPost, "/login", middleware.OperationMeta{Module: "认证", Action: "LOGIN", Description: "用户登录 {body.username}"}, v1.Login)
+			middleware.LogRoute(BaseGroup, http.MethodPost, "/logout", middleware.OperationMeta{Module: "认证", Action: "LOGOUT", Description: "用户登出"}, v1.Logout)
+			middleware.LogRoute(BaseGroup, http.MethodPost, "/refresh", middleware.OperationMeta{Module: "认证", Action: "UPDATE", Description: "刷新token", SkipBody: true}, v1.RefreshToken)
 			BaseGroup.GET("/captcha", v1.Captcha)
 		}
 
 		// 文件上传路由
 		UploadGroup := PublicGroup.Group("/upload")
 		{
-			UploadGroup.POST("/avatar", v1.UploadAvatar)
+			middleware.LogRoute(UploadGroup, http.MethodPost, "/avatar", middleware.OperationMeta{Module: "文件上传", Action: "UPLOAD", Description: "上传头像", SkipBody: true}, v1.UploadAvatar)
+			UploadGroup.GET("/object/*key", v1.RedirectObject)
 		}
 
 		// 仪表盘路由
@@ -79,13 +97,18 @@ func Routers() *gin.Engine {
 				UserGroup.GET("/list", v1.GetUserList)
 				UserGroup.GET("/info", middleware.JWTAuth(), v1.GetUserInfo)
 				UserGroup.GET("/menus", middleware.JWTAuth(), v1.GetUserMenus)
-				UserGroup.PUT("/info", middleware.JWTAuth(), v1.UpdateUserInfo)
-				UserGroup.PUT("/password", middleware.JWTAuth(), v1.ChangePassword)
+				middleware.LogRoute(UserGroup, http.MethodPut, "/info", middleware.OperationMeta{Module: "用户管理", Description: "更新个人信息"}, middleware.JWTAuth(), v1.UpdateUserInfo)
+				middleware.LogRoute(UserGroup, http.MethodPut, "/password", middleware.OperationMeta{Module: "用户管理", Description: "修改密码"}, middleware.JWTAuth(), v1.ChangePassword)
+				middleware.LogRoute(UserGroup, http.MethodPost, "/switch-authority", middleware.OperationMeta{Module: "用户管理", Action: "UPDATE", Description: "切换当前角色为 {body.authorityId}"}, middleware.JWTAuth(), v1.SwitchAuthority)
+
+				middleware.LogRoute(UserGroup, http.MethodGet, "/export", middleware.OperationMeta{Module: "用户管理", Action: "EXPORT", Description: "导出用户", SkipBody: true}, middleware.JWTAuth(), v1.ExportUsers)
+				UserGroup.GET("/import/template", middleware.JWTAuth(), v1.DownloadUserImportTemplate)
+				middleware.LogRoute(UserGroup, http.MethodPost, "/import", middleware.OperationMeta{Module: "用户管理", Action: "IMPORT", Description: "批量导入用户", SkipBody: true}, middleware.JWTAuth(), v1.ImportUsers)
 
-				UserGroup.POST("", v1.CreateUser)
+				middleware.LogRoute(UserGroup, http.MethodPost, "", middleware.OperationMeta{Module: "用户管理", Description: "创建用户 {body.username}"}, v1.CreateUser)
 				UserGroup.GET("/:id", v1.GetUserById)
-				UserGroup.PUT("/:id", v1.UpdateUser)
-				UserGroup.DELETE("/:id", v1.DeleteUser)
+				middleware.LogRoute(UserGroup, http.MethodPut, "/:id", middleware.OperationMeta{Module: "用户管理", Description: "更新用户 {param.id}"}, v1.UpdateUser)
+				middleware.LogRoute(UserGroup, http.MethodDelete, "/:id", middleware.OperationMeta{Module: "用户管理", Description: "删除用户 {param.id}"}, v1.DeleteUser)
 			}
 
 			// 角色管理
@@ -93,12 +116,37 @@ func Routers() *gin.Engine {
 			{
 				RoleGroup.GET("/list", v1.GetAuthorityList)
 				RoleGroup.GET("/all", v1.GetAllAuthorities)
-				RoleGroup.POST("", v1.CreateAuthority)
+				RoleGroup.GET("/tree", v1.GetAuthorityTree)
+				middleware.LogRoute(RoleGroup, http.MethodPost, "", middleware.OperationMeta{Module: "角色管理", Description: "创建角色 {body.authorityName}"}, v1.CreateAuthority)
 				RoleGroup.GET("/:id/menus", v1.GetAuthorityMenus)
-				RoleGroup.POST("/:id/menus", v1.AssignMenus)
+				middleware.LogRoute(RoleGroup, http.MethodPost, "/:id/menus", middleware.OperationMeta{Module: "角色管理", Action: "UPDATE", Description: "分配角色 {param.id} 的菜单权限"}, v1.AssignMenus)
+				RoleGroup.GET("/:id/effective", v1.GetEffectivePermissions)
+				middleware.LogRoute(RoleGroup, http.MethodPost, "/:id/copy", middleware.OperationMeta{Module: "角色管理", Description: "复制角色 {param.id} 为 {body.authorityName}"}, v1.CopyAuthority)
 				RoleGroup.GET("/:id", v1.GetAuthorityById)
-				RoleGroup.PUT("/:id", v1.UpdateAuthority)
-				RoleGroup.DELETE("/:id", v1.DeleteAuthority)
+				middleware.LogRoute(RoleGroup, http.MethodPut, "/:id", middleware.OperationMeta{Module: "角色管理", Description: "更新角色 {param.id}"}, v1.UpdateAuthority)
+				middleware.LogRoute(RoleGroup, http.MethodDelete, "/:id", middleware.OperationMeta{Module: "角色管理", Description: "删除角色 {param.id}"}, v1.DeleteAuthority)
+			}
+
+			// 权限模板管理
+			PermissionTemplateGroup := SystemGroup.Group("/permission-template")
+			{
+				PermissionTemplateGroup.GET("/list", v1.GetPermissionTemplateList)
+				middleware.LogRoute(PermissionTemplateGroup, http.MethodPost, "", middleware.OperationMeta{Module: "权限模板", Description: "创建权限模板 {body.name}"}, v1.CreatePermissionTemplate)
+				middleware.LogRoute(PermissionTemplateGroup, http.MethodPost, "/:id/apply", middleware.OperationMeta{Module: "权限模板", Action: "UPDATE", Description: "应用权限模板 {param.id}"}, v1.ApplyPermissionTemplate)
+				PermissionTemplateGroup.GET("/:id", v1.GetPermissionTemplateById)
+				middleware.LogRoute(PermissionTemplateGroup, http.MethodPut, "/:id", middleware.OperationMeta{Module: "权限模板", Description: "更新权限模板 {param.id}"}, v1.UpdatePermissionTemplate)
+				middleware.LogRoute(PermissionTemplateGroup, http.MethodDelete, "/:id", middleware.OperationMeta{Module: "权限模板", Description: "删除权限模板 {param.id}"}, v1.DeletePermissionTemplate)
+			}
+
+			// 岗位管理
+			PostGroup := SystemGroup.Group("/post")
+			{
+				PostGroup.GET("/list", v1.GetPostList)
+				PostGroup.GET("/all", v1.GetAllPosts)
+				middleware.LogRoute(PostGroup, http.MethodPost, "", middleware.OperationMeta{Module: "岗位管理", Description: "创建岗位 {body.postName}"}, v1.CreatePost)
+				PostGroup.GET("/:id", v1.GetPostById)
+				middleware.LogRoute(PostGroup, http.MethodPut, "/:id", middleware.OperationMeta{Module: "岗位管理", Description: "更新岗位 {param.id}"}, v1.UpdatePost)
+				middleware.LogRoute(PostGroup, http.MethodDelete, "/:id", middleware.OperationMeta{Module: "岗位管理", Description: "删除岗位 {param.id}"}, v1.DeletePost)
 			}
 
 			// 菜单管理
@@ -106,24 +154,91 @@ func Routers() *gin.Engine {
 			{
 				MenuGroup.GET("/list", v1.GetMenuList)
 				MenuGroup.GET("/tree", v1.GetMenuTree)
-				MenuGroup.POST("", v1.CreateMenu)
+				middleware.LogRoute(MenuGroup, http.MethodPost, "", middleware.OperationMeta{Module: "菜单管理", Description: "创建菜单 {body.title}"}, v1.CreateMenu)
 				MenuGroup.GET("/:id", v1.GetMenuById)
-				MenuGroup.PUT("/:id", v1.UpdateMenu)
-				MenuGroup.DELETE("/:id", v1.DeleteMenu)
+				middleware.LogRoute(MenuGroup, http.MethodPut, "/:id", middleware.OperationMeta{Module: "菜单管理", Description: "更新菜单 {param.id}"}, v1.UpdateMenu)
+				middleware.LogRoute(MenuGroup, http.MethodDelete, "/:id", middleware.OperationMeta{Module: "菜单管理", Description: "删除菜单 {param.id}"}, v1.DeleteMenu)
+			}
+
+			// 实体变更记录
+			EntityChangeGroup := SystemGroup.Group("/entity-change")
+			{
+				EntityChangeGroup.GET("/list", middleware.JWTAuth(), middleware.AdminAuth(), v1.GetEntityChanges)
+			}
+
+			// 登录日志
+			LoginLogGroup := SystemGroup.Group("/login-log")
+			{
+				LoginLogGroup.GET("/list", middleware.JWTAuth(), v1.GetLoginLogList)
+				LoginLogGroup.GET("/stats", middleware.JWTAuth(), v1.GetLoginStats)
+				LoginLogGroup.GET("/mine", middleware.JWTAuth(), v1.GetMyLoginLogs)
+				middleware.LogRoute(LoginLogGroup, http.MethodGet, "/export", middleware.OperationMeta{Module: "登录日志", Action: "EXPORT", Description: "导出登录日志", SkipBody: true}, middleware.JWTAuth(), v1.ExportLoginLogs)
+			}
+
+			// 服务监控
+			MonitorGroup := SystemGroup.Group("/monitor")
+			{
+				MonitorGroup.GET("/server", middleware.JWTAuth(), middleware.AdminAuth(), v1.GetServerMonitor)
+			}
+
+			// 在线用户
+			OnlineGroup := SystemGroup.Group("/online")
+			{
+				OnlineGroup.GET("/list", middleware.JWTAuth(), v1.GetOnlineUsers)
+				middleware.LogRoute(OnlineGroup, http.MethodDelete, "/user/:userId", middleware.OperationMeta{Module: "在线用户", Action: "KICK", Description: "踢出用户 {param.userId} 的全部会话"}, middleware.JWTAuth(), middleware.AdminAuth(), v1.KickUser)
+				middleware.LogRoute(OnlineGroup, http.MethodDelete, "/:sessionId", middleware.OperationMeta{Module: "在线用户", Action: "KICK", Description: "踢出会话 {param.sessionId}"}, middleware.JWTAuth(), middleware.AdminAuth(), v1.KickSession)
+			}
+
+			// 定时任务管理
+			JobGroup := SystemGroup.Group("/job")
+			{
+				JobGroup.GET("/list", middleware.JWTAuth(), middleware.AdminAuth(), v1.GetJobList)
+				JobGroup.GET("/types", middleware.JWTAuth(), middleware.AdminAuth(), v1.GetJobTypes)
+				JobGroup.GET("/logs", middleware.JWTAuth(), middleware.AdminAuth(), v1.GetJobLogs)
+				middleware.LogRoute(JobGroup, http.MethodPost, "", middleware.OperationMeta{Module: "定时任务", Description: "创建定时任务 {body.name}"}, middleware.JWTAuth(), middleware.AdminAuth(), v1.CreateJob)
+				JobGroup.GET("/:id", middleware.JWTAuth(), middleware.AdminAuth(), v1.GetJobById)
+				middleware.LogRoute(JobGroup, http.MethodPut, "/:id", middleware.OperationMeta{Module: "定时任务", Description: "更新定时任务 {param.id}"}, middleware.JWTAuth(), middleware.AdminAuth(), v1.UpdateJob)
+				middleware.LogRoute(JobGroup, http.MethodDelete, "/:id", middleware.OperationMeta{Module: "定时任务", Description: "删除定时任务 {param.id}"}, middleware.JWTAuth(), middleware.AdminAuth(), v1.DeleteJob)
+				middleware.LogRoute(JobGroup, http.MethodPost, "/:id/pause", middleware.OperationMeta{Module: "定时任务", Action: "UPDATE", Description: "暂停定时任务 {param.id}"}, middleware.JWTAuth(), middleware.AdminAuth(), v1.PauseJob)
+				middleware.LogRoute(JobGroup, http.MethodPost, "/:id/resume", middleware.OperationMeta{Module: "定时任务", Action: "UPDATE", Description: "恢复定时任务 {param.id}"}, middleware.JWTAuth(), middleware.AdminAuth(), v1.ResumeJob)
+				middleware.LogRoute(JobGroup, http.MethodPost, "/:id/run", middleware.OperationMeta{Module: "定时任务", Action: "EXECUTE", Description: "立即执行定时任务 {param.id}"}, middleware.JWTAuth(), middleware.AdminAuth(), v1.RunJob)
+			}
+
+			// 文件管理
+			FileGroup := SystemGroup.Group("/file")
+			{
+				FileGroup.GET("/list", middleware.JWTAuth(), v1.GetFileList)
+				FileGroup.GET("/quota", middleware.JWTAuth(), v1.GetMyFileQuota)
+				middleware.LogRoute(FileGroup, http.MethodPost, "/upload", middleware.OperationMeta{Module: "文件管理", Action: "UPLOAD", Description: "上传文件", SkipBody: true}, middleware.JWTAuth(), v1.UploadFile)
+				middleware.LogRoute(FileGroup, http.MethodGet, "/:id/download", middleware.OperationMeta{Module: "文件管理", Action: "EXPORT", Description: "下载文件 {param.id}", SkipBody: true}, middleware.JWTAuth(), v1.DownloadFile)
+				middleware.LogRoute(FileGroup, http.MethodDelete, "/:id", middleware.OperationMeta{Module: "文件管理", Description: "删除文件 {param.id}"}, middleware.JWTAuth(), v1.DeleteFile)
+
+				// 分片上传，单个分片不记录操作日志
+				middleware.LogRoute(FileGroup, http.MethodPost, "/chunk/init", middleware.OperationMeta{Module: "文件管理", Action: "UPLOAD", Description: "开始分片上传 {body.name}"}, middleware.JWTAuth(), v1.InitChunkUpload)
+				FileGroup.GET("/chunk/:uploadId", middleware.JWTAuth(), v1.GetChunkUpload)
+				FileGroup.PUT("/chunk/:uploadId/:index", middleware.JWTAuth(), v1.UploadChunk)
+				middleware.LogRoute(FileGroup, http.MethodPost, "/chunk/:uploadId/complete", middleware.OperationMeta{Module: "文件管理", Action: "UPLOAD", Description: "完成分片上传 {param.uploadId}"}, middleware.JWTAuth(), v1.CompleteChunkUpload)
+				middleware.LogRoute(FileGroup, http.MethodDelete, "/chunk/:uploadId", middleware.OperationMeta{Module: "文件管理", Description: "取消分片上传 {param.uploadId}"}, middleware.JWTAuth(), v1.AbortChunkUpload)
 			}
 
-			// 操作日志管理 - 暂时注释掉，需要重新实现
 			// 操作日志管理路由
 			OperationLogGroup := SystemGroup.Group("/operation-log")
 			{
 				OperationLogGroup.GET("/list", v1.GetOperationLogList)
 				OperationLogGroup.GET("/stats", v1.GetOperationStats)
-				OperationLogGroup.GET("/export", v1.ExportOperationLogs)
+				OperationLogGroup.GET("/routes", v1.GetOperationRoutes)
+				OperationLogGroup.GET("/verify", middleware.JWTAuth(), middleware.AdminAuth(), v1.VerifyOperationLogs)
+				OperationLogGroup.GET("/checkpoints", middleware.JWTAuth(), middleware.AdminAuth(), v1.GetOperationLogCheckpoints)
+				OperationLogGroup.GET("/retention", middleware.JWTAuth(), middleware.AdminAuth(), v1.GetOperationLogRetention)
+				middleware.LogRoute(OperationLogGroup, http.MethodPost, "/retention/run", middleware.OperationMeta{Module: "操作日志", Description: "执行操作日志保留策略"}, middleware.JWTAuth(), middleware.AdminAuth(), v1.RunOperationLogRetention)
+				OperationLogGroup.GET("/archives", middleware.JWTAuth(), middleware.AdminAuth(), v1.GetOperationLogArchives)
+				middleware.LogRoute(OperationLogGroup, http.MethodGet, "/archives/:name/download", middleware.OperationMeta{Module: "操作日志", Action: "EXPORT", Description: "下载操作日志归档 {param.name}", SkipBody: true}, middleware.JWTAuth(), middleware.AdminAuth(), v1.DownloadOperationLogArchive)
+				middleware.LogRoute(OperationLogGroup, http.MethodGet, "/export", middleware.OperationMeta{Module: "操作日志", Action: "EXPORT", Description: "导出操作日志", SkipBody: true}, middleware.JWTAuth(), middleware.AdminAuth(), v1.ExportOperationLogs)
 				OperationLogGroup.GET("/:id", v1.GetOperationLogById)
-				OperationLogGroup.DELETE("/:id", v1.DeleteOperationLog)
-				OperationLogGroup.DELETE("/batch", v1.DeleteOperationLogsByIds)
-				OperationLogGroup.DELETE("/clear", v1.ClearOperationLogs)
-				OperationLogGroup.DELETE("/clear-by-days", v1.ClearOperationLogsByDays)
+				middleware.LogRoute(OperationLogGroup, http.MethodDelete, "/:id", middleware.OperationMeta{Module: "操作日志", Description: "删除操作日志 {param.id}"}, v1.DeleteOperationLog)
+				middleware.LogRoute(OperationLogGroup, http.MethodDelete, "/batch", middleware.OperationMeta{Module: "操作日志", Description: "批量删除操作日志"}, v1.DeleteOperationLogsByIds)
+				middleware.LogRoute(OperationLogGroup, http.MethodDelete, "/clear", middleware.OperationMeta{Module: "操作日志", Description: "归档并清空操作日志"}, middleware.JWTAuth(), middleware.AdminAuth(), v1.ClearOperationLogs)
+				middleware.LogRoute(OperationLogGroup, http.MethodDelete, "/clear-by-days", middleware.OperationMeta{Module: "操作日志", Description: "归档并清理 {body.days} 天前的操作日志"}, middleware.JWTAuth(), middleware.AdminAuth(), v1.ClearOperationLogsByDays)
 			}
 		}
 		fmt.Println("System routes initialized")
@@ -132,6 +247,16 @@ func Routers() *gin.Engine {
 		// router.InitBusinessRouter(PublicGroup)
 	}
 
+	// 接口文档，只在开发环境提供，文档由 go generate ./docs 生成
+	if global.CONFIG.System.Env == "develop" {
+		docs.Register(Router)
+		if missing, err := docs.MissingRoutes(Router.Routes()); err != nil {
+			fmt.Printf("解析接口文档失败: %v\n", err)
+		} else if len(missing) > 0 {
+			fmt.Printf("有 %d 个路由不在接口文档中，请执行 go generate ./docs 重新生成\n", len(missing))
+		}
+	}
+
 	fmt.Println("        欢迎使用 go-gin-element-admin")
 	fmt.Println("        当前版本:v1.0.0")
 	fmt.Println("        默认自动化文档地址:http://127.0.0.1:8888/swagger/index.html")
@@ -0,0 +1,27 @@
+package initialize
+
+import (
+	"testing"
+
+	"server/docs"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestRoutesDocumented 新增或修改路由后需执行 go generate ./docs 重新生成接口文档
+func TestRoutesDocumented(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	routes := Routers().Routes()
+	if len(routes) == 0 {
+		t.Fatal("no routes registered")
+	}
+
+	missing, err := docs.MissingRoutes(routes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, route := range missing {
+		t.Errorf("route %s %s is not documented, run go generate ./docs", route.Method, route.Path)
+	}
+}
@@ -18,10 +18,10 @@ func main() {
 	global.VP = core.Viper() // 初始化Viper
 	initialize.OtherInit()
 	global.DB = initialize.Gorm() // gorm连接数据库
-	initialize.Timer()
 	initialize.DBList()
 	if global.DB != nil {
 		initialize.RegisterTables() // 初始化表
+		initialize.Timer()          // 启动定时任务
 		// 程序结束前关闭数据库链接
 		db, _ := global.DB.DB()
 		defer db.Close()
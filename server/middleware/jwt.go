@@ -1,13 +1,20 @@
 package middleware
 
 import (
+	"errors"
 	"net/http"
+	"server/service/system"
 	"server/utils"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 )
 
+var sessionService = system.SessionService{}
+
+// AdminAuthorityId 超级管理员角色ID
+const AdminAuthorityId uint = 888
+
 // JWTAuth JWT认证中间件
 func JWTAuth() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -38,7 +45,22 @@ func JWTAuth() gin.HandlerFunc {
 			return
 		}
 
+		// 校验会话是否已登出或被踢出，并更新最后活动时间
+		if err := sessionService.TouchSession(claims.ID); err != nil {
+			status := http.StatusUnauthorized
+			if errors.Is(err, system.ErrSessionUnavailable) {
+				status = http.StatusServiceUnavailable
+			}
+			c.JSON(status, gin.H{
+				"code": status,
+				"msg":  err.Error(),
+			})
+			c.Abort()
+			return
+		}
+
 		// 将用户信息存储到context中
+		c.Set("sessionId", claims.ID)
 		c.Set("userID", claims.UserID)
 		c.Set("username", claims.Username)
 		c.Set("authorityId", claims.AuthorityId)
@@ -47,6 +69,27 @@ func JWTAuth() gin.HandlerFunc {
 	}
 }
 
+// AdminAuth 管理员权限中间件，需放在JWTAuth之后，只允许当前角色为超级管理员的用户访问
+func AdminAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !IsAdmin(c) {
+			c.JSON(http.StatusForbidden, gin.H{
+				"code": 403,
+				"msg":  "需要管理员权限",
+			})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// IsAdmin 当前用户的角色是否为超级管理员
+func IsAdmin(c *gin.Context) bool {
+	_, _, authorityId, ok := GetCurrentUser(c)
+	return ok && authorityId == AdminAuthorityId
+}
+
 // GetCurrentUser 从context中获取当前用户信息
 func GetCurrentUser(c *gin.Context) (userID uint, username string, authorityId uint, exists bool) {
 	userIDInterface, exists1 := c.Get("userID")
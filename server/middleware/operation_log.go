@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 
 // OperationLogMiddleware 操作日志中间件
@@ -16,7 +17,7 @@ func OperationLogMiddleware() gin.HandlerFunc {
 	return gin.LoggerWithFormatter(func(param gin.LogFormatterParams) string {
 		// 只记录需要记录的操作
 		if shouldLogOperation(param.Method, param.Path) {
-			go recordOperationLog(param)
+			recordOperationLog(param)
 		}
 		return ""
 	})
@@ -34,29 +35,38 @@ func (r ResponseBodyWriter) Write(b []byte) (int, error) {
 }
 
 // OperationLogMiddlewareWithBody 带请求体和响应体记录的操作日志中间件
+// 优先按路由登记的元数据记录，未登记的路由只记录增删改操作
 func OperationLogMiddlewareWithBody() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// 只对需要记录的操作进行处理
-		if !shouldLogOperation(c.Request.Method, c.Request.URL.Path) {
+		// 生成请求ID，用于关联操作日志和实体变更记录
+		requestId := uuid.New().String()
+		c.Set(system.ContextRequestIdKey, requestId)
+		c.Header("X-Request-Id", requestId)
+
+		meta, registered := lookupOperation(c.Request.Method, c.FullPath())
+		if !registered && !shouldLogOperation(c.Request.Method, c.Request.URL.Path) {
 			c.Next()
 			return
 		}
 
 		start := time.Now()
 
-		// 读取请求体
 		var requestBody []byte
-		if c.Request.Body != nil {
-			requestBody, _ = io.ReadAll(c.Request.Body)
-			c.Request.Body = io.NopCloser(bytes.NewBuffer(requestBody))
-		}
+		var responseBodyWriter *ResponseBodyWriter
+		if !meta.SkipBody {
+			// 读取请求体
+			if c.Request.Body != nil {
+				requestBody, _ = io.ReadAll(c.Request.Body)
+				c.Request.Body = io.NopCloser(bytes.NewBuffer(requestBody))
+			}
 
-		// 创建响应体写入器
-		responseBodyWriter := &ResponseBodyWriter{
-			ResponseWriter: c.Writer,
-			body:           bytes.NewBufferString(""),
+			// 创建响应体写入器
+			responseBodyWriter = &ResponseBodyWriter{
+				ResponseWriter: c.Writer,
+				body:           bytes.NewBufferString(""),
+			}
+			c.Writer = responseBodyWriter
 		}
-		c.Writer = responseBodyWriter
 
 		// 处理请求
 		c.Next()
@@ -64,10 +74,13 @@ func OperationLogMiddlewareWithBody() gin.HandlerFunc {
 		// 计算耗时
 		latency := time.Since(start).Milliseconds()
 
-		// 异步记录操作日志
-		go func() {
-			recordDetailedOperationLog(c, requestBody, responseBodyWriter.body.Bytes(), latency)
-		}()
+		var responseBody []byte
+		if responseBodyWriter != nil {
+			responseBody = responseBodyWriter.body.Bytes()
+		}
+
+		// 记录操作日志（只解析并入队，由后台批量写入）
+		recordDetailedOperationLog(c, meta, requestBody, responseBody, latency)
 	}
 }
 
@@ -82,6 +95,7 @@ func shouldLogOperation(method, path string) bool {
 	excludePaths := []string{
 		"/api/health",
 		"/api/system/operation-log", // 避免记录日志查询操作本身
+		"/api/system/file/chunk/",   // 分片内容不记录，初始化、完成、取消在注册路由时登记
 	}
 
 	for _, excludePath := range excludePaths {
@@ -101,8 +115,8 @@ func recordOperationLog(param gin.LogFormatterParams) {
 	// 确定操作类型
 	operationType := getOperationType(param.Method)
 
-	// 生成操作描述
-	description := generateDescription(param.Method, param.Path)
+	// 无法获取路由信息，使用请求方法和路径作为描述
+	description := operationType + " " + param.Path
 
 	// 获取用户信息（这里需要从上下文中获取，暂时使用默认值）
 	userID := uint(1)   // 默认管理员ID
@@ -113,6 +127,7 @@ func recordOperationLog(param gin.LogFormatterParams) {
 		username,
 		param.Method,
 		param.Path,
+		"", // 所属模块
 		operationType,
 		description,
 		nil, // 请求体
@@ -122,19 +137,15 @@ func recordOperationLog(param gin.LogFormatterParams) {
 		param.StatusCode,
 		param.Latency.Milliseconds(),
 		param.ErrorMessage,
+		nil, // 变更快照
+		"",  // 请求ID
 	)
 }
 
 // recordDetailedOperationLog 记录详细操作日志
-func recordDetailedOperationLog(c *gin.Context, requestBody, responseBody []byte, latency int64) {
+func recordDetailedOperationLog(c *gin.Context, meta OperationMeta, requestBody, responseBody []byte, latency int64) {
 	operationLogService := &system.OperationLogService{}
 
-	// 确定操作类型
-	operationType := getOperationType(c.Request.Method)
-
-	// 生成操作描述
-	description := generateDescription(c.Request.Method, c.Request.URL.Path)
-
 	// 获取用户信息（从JWT token或session中获取）
 	userID, username := getUserInfo(c)
 
@@ -145,6 +156,27 @@ func recordDetailedOperationLog(c *gin.Context, requestBody, responseBody []byte
 		json.Unmarshal(requestBody, &reqBodyInterface)
 	}
 
+	// 确定操作类型
+	operationType := meta.Action
+	if operationType == "" {
+		operationType = getOperationType(c.Request.Method)
+	}
+
+	// 生成操作描述，未登记描述的路由使用请求方法和路由路径
+	routePath := c.FullPath()
+	if routePath == "" {
+		routePath = c.Request.URL.Path
+	}
+	// 描述中引用的请求体字段按脱敏后的值填充，避免密码等明文经描述写入日志
+	var descriptionBody interface{}
+	if len(requestBody) > 0 && json.Unmarshal(requestBody, &descriptionBody) == nil {
+		descriptionBody = system.OperationLogRedactor().Redact(c.Request.Method, c.Request.URL.Path, "request", descriptionBody)
+	}
+	description := renderDescription(c, meta.Description, descriptionBody)
+	if description == "" {
+		description = operationType + " " + routePath
+	}
+
 	if len(responseBody) > 0 {
 		json.Unmarshal(responseBody, &respBodyInterface)
 	}
@@ -154,8 +186,8 @@ func recordDetailedOperationLog(c *gin.Context, requestBody, responseBody []byte
 	if c.Writer.Status() >= 400 {
 		if respBodyInterface != nil {
 			if respMap, ok := respBodyInterface.(map[string]interface{}); ok {
-				if msg, exists := respMap["msg"]; exists {
-					errorMessage = msg.(string)
+				if msg, ok := respMap["msg"].(string); ok {
+					errorMessage = msg
 				}
 			}
 		}
@@ -166,6 +198,7 @@ func recordDetailedOperationLog(c *gin.Context, requestBody, responseBody []byte
 		username,
 		c.Request.Method,
 		c.Request.URL.Path,
+		meta.Module,
 		operationType,
 		description,
 		reqBodyInterface,
@@ -175,6 +208,8 @@ func recordDetailedOperationLog(c *gin.Context, requestBody, responseBody []byte
 		c.Writer.Status(),
 		latency,
 		errorMessage,
+		getOperationSnapshot(c),
+		c.GetString(system.ContextRequestIdKey),
 	)
 }
 
@@ -187,93 +222,30 @@ func getOperationType(method string) string {
 		return "UPDATE"
 	case "DELETE":
 		return "DELETE"
+	case "GET":
+		return "QUERY"
 	default:
 		return "OTHER"
 	}
 }
 
-// generateDescription 生成操作描述
-func generateDescription(method, path string) string {
-	operationType := getOperationType(method)
-
-	// 根据路径生成描述
-	if strings.Contains(path, "/user") {
-		switch operationType {
-		case "CREATE":
-			return "创建用户"
-		case "UPDATE":
-			return "更新用户信息"
-		case "DELETE":
-			return "删除用户"
-		}
-	} else if strings.Contains(path, "/role") {
-		switch operationType {
-		case "CREATE":
-			return "创建角色"
-		case "UPDATE":
-			return "更新角色信息"
-		case "DELETE":
-			return "删除角色"
-		}
-	} else if strings.Contains(path, "/menu") {
-		switch operationType {
-		case "CREATE":
-			return "创建菜单"
-		case "UPDATE":
-			return "更新菜单信息"
-		case "DELETE":
-			return "删除菜单"
-		}
-	} else if strings.Contains(path, "/authority") {
-		switch operationType {
-		case "CREATE":
-			return "创建权限"
-		case "UPDATE":
-			return "更新权限信息"
-		case "DELETE":
-			return "删除权限"
-		}
-	} else if strings.Contains(path, "/upload") {
-		return "文件上传"
-	} else if strings.Contains(path, "/login") {
-		return "用户登录"
-	} else if strings.Contains(path, "/logout") {
-		return "用户登出"
-	}
+// operationSnapshotKey 上下文中保存变更快照的键
+const operationSnapshotKey = "operationSnapshot"
 
-	// 根据具体路径生成更详细的描述
-	switch {
-	case strings.Contains(path, "/password"):
-		return "修改密码"
-	case strings.Contains(path, "/info"):
-		return "更新个人信息"
-	case strings.Contains(path, "/batch"):
-		return "批量" + operationType
-	case strings.Contains(path, "/assign"):
-		return "分配权限"
-	default:
-		return operationType + " " + extractResourceName(path)
-	}
+// SetOperationSnapshot 记录本次操作的变更前后快照，随操作日志一起保存
+func SetOperationSnapshot(c *gin.Context, before, after interface{}) {
+	c.Set(operationSnapshotKey, gin.H{
+		"before": before,
+		"after":  after,
+	})
 }
 
-// extractResourceName 从路径中提取资源名称
-func extractResourceName(path string) string {
-	parts := strings.Split(path, "/")
-	if len(parts) >= 3 {
-		resource := parts[len(parts)-2]
-		// 转换为中文描述
-		switch resource {
-		case "system":
-			return "系统管理"
-		case "dashboard":
-			return "仪表板"
-		case "operation-log":
-			return "操作日志"
-		default:
-			return resource
-		}
+// getOperationSnapshot 获取处理器记录的变更快照
+func getOperationSnapshot(c *gin.Context) interface{} {
+	if snapshot, exists := c.Get(operationSnapshotKey); exists {
+		return snapshot
 	}
-	return path
+	return nil
 }
 
 // getUserInfo 从上下文中获取用户信息
@@ -0,0 +1,116 @@
+package middleware
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// OperationMeta 路由的操作日志元数据，在注册路由时登记
+type OperationMeta struct {
+	Module      string // 所属模块，如 用户管理
+	Action      string // 操作类型，为空时根据请求方法推断（POST:CREATE PUT:UPDATE DELETE:DELETE GET:QUERY）
+	Description string // 描述模板，支持 {param.id}、{query.format}、{body.username} 占位符
+	SkipBody    bool   // 不记录请求体和响应体，用于文件上传、导出等接口
+}
+
+// OperationRoute 已登记操作日志的路由
+type OperationRoute struct {
+	Method string `json:"method"`
+	Path   string `json:"path"`
+	OperationMeta
+}
+
+var (
+	operationRoutes   = make(map[string]OperationMeta)
+	operationRoutesMu sync.RWMutex
+)
+
+// placeholderPattern 描述模板中的占位符
+var placeholderPattern = regexp.MustCompile(`\{(param|query|body)\.([\w.]+)\}`)
+
+// RegisterOperation 登记路由的操作日志元数据，fullPath与gin的FullPath一致
+func RegisterOperation(method, fullPath string, meta OperationMeta) {
+	operationRoutesMu.Lock()
+	defer operationRoutesMu.Unlock()
+	operationRoutes[method+" "+fullPath] = meta
+}
+
+// LogRoute 在路由组上注册路由，同时登记操作日志元数据
+func LogRoute(group *gin.RouterGroup, method, relativePath string, meta OperationMeta, handlers ...gin.HandlerFunc) gin.IRoutes {
+	fullPath := path.Join(group.BasePath(), relativePath)
+	if strings.HasSuffix(relativePath, "/") && !strings.HasSuffix(fullPath, "/") {
+		fullPath += "/"
+	}
+	RegisterOperation(method, fullPath, meta)
+	return group.Handle(method, relativePath, handlers...)
+}
+
+// GetOperationRoutes 获取全部已登记的路由，按路径排序
+func GetOperationRoutes() []OperationRoute {
+	operationRoutesMu.RLock()
+	defer operationRoutesMu.RUnlock()
+
+	routes := make([]OperationRoute, 0, len(operationRoutes))
+	for key, meta := range operationRoutes {
+		method, fullPath, _ := strings.Cut(key, " ")
+		routes = append(routes, OperationRoute{Method: method, Path: fullPath, OperationMeta: meta})
+	}
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].Path != routes[j].Path {
+			return routes[i].Path < routes[j].Path
+		}
+		return routes[i].Method < routes[j].Method
+	})
+	return routes
+}
+
+// lookupOperation 根据请求方法和gin的FullPath查找操作日志元数据
+func lookupOperation(method, fullPath string) (OperationMeta, bool) {
+	operationRoutesMu.RLock()
+	defer operationRoutesMu.RUnlock()
+	meta, ok := operationRoutes[method+" "+fullPath]
+	return meta, ok
+}
+
+// renderDescription 用路径参数、查询参数和请求体替换描述模板中的占位符
+func renderDescription(c *gin.Context, template string, body interface{}) string {
+	description := placeholderPattern.ReplaceAllStringFunc(template, func(placeholder string) string {
+		match := placeholderPattern.FindStringSubmatch(placeholder)
+		switch match[1] {
+		case "param":
+			return c.Param(match[2])
+		case "query":
+			return c.Query(match[2])
+		default:
+			return bodyValue(body, match[2])
+		}
+	})
+	return strings.TrimSpace(description)
+}
+
+// bodyValue 按点分隔的字段路径读取请求体中的值
+func bodyValue(body interface{}, field string) string {
+	value := body
+	for _, key := range strings.Split(field, ".") {
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			return ""
+		}
+		value = m[key]
+	}
+
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	default:
+		return fmt.Sprint(v)
+	}
+}
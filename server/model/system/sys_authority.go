@@ -15,6 +15,8 @@ type SysAuthority struct {
 	AuthorityCode string         `json:"authorityCode" gorm:"column:authority_code;comment:角色编码;unique"`
 	ParentId      *uint          `json:"parentId" gorm:"comment:父角色ID"`
 	DefaultRouter string         `json:"defaultRouter" gorm:"comment:默认菜单;default:dashboard"`
+	FileQuota     *int64         `json:"fileQuota" gorm:"default:0;comment:文件存储配额，单位字节，0表示不限制"`
+	Children      []SysAuthority `json:"children,omitempty" gorm:"-"`
 }
 
 func (SysAuthority) TableName() string {
@@ -0,0 +1,46 @@
+package system
+
+import (
+	"gorm.io/gorm"
+)
+
+// SysEntityChange 实体变更记录表，记录数据修改前后的快照和字段差异
+type SysEntityChange struct {
+	gorm.Model
+	EntityType string `json:"entityType" gorm:"index:idx_entity_change_entity;size:64;comment:实体类型"`
+	EntityId   string `json:"entityId" gorm:"index:idx_entity_change_entity;size:64;comment:实体ID"`
+	Action     string `json:"action" gorm:"size:16;comment:变更类型(CREATE/UPDATE/DELETE)"`
+	Before     string `json:"before" gorm:"type:text;comment:变更前数据"`
+	After      string `json:"after" gorm:"type:text;comment:变更后数据"`
+	Diff       string `json:"diff" gorm:"type:text;comment:字段差异"`
+	RequestId  string `json:"requestId" gorm:"index;size:64;comment:请求ID"`
+	UserID     uint   `json:"userId" gorm:"comment:操作用户ID"`
+	Username   string `json:"username" gorm:"comment:操作用户名"`
+}
+
+func (SysEntityChange) TableName() string {
+	return "sys_entity_changes"
+}
+
+// EntityChangeRequest 实体变更记录查询请求
+type EntityChangeRequest struct {
+	PageInfo
+	EntityType string `json:"entityType" form:"entityType" binding:"required"`
+	EntityId   string `json:"entityId" form:"entityId" binding:"required"`
+	Action     string `json:"action" form:"action"`
+}
+
+// EntityChangeItem 实体变更记录及关联的操作日志
+type EntityChangeItem struct {
+	SysEntityChange
+	OperationLogId uint   `json:"operationLogId"`
+	Description    string `json:"description"`
+}
+
+// EntityChangeResponse 实体变更记录响应
+type EntityChangeResponse struct {
+	List     []EntityChangeItem `json:"list"`
+	Total    int64              `json:"total"`
+	Page     int                `json:"page"`
+	PageSize int                `json:"pageSize"`
+}
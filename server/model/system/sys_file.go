@@ -0,0 +1,50 @@
+package system
+
+import (
+	"gorm.io/gorm"
+)
+
+// SysFile 上传文件记录，内容相同的文件共用同一个存储对象
+type SysFile struct {
+	gorm.Model
+	Name         string `json:"name" gorm:"size:255;comment:原始文件名"`
+	Key          string `json:"key" gorm:"index;size:255;comment:存储中的对象键"`
+	Size         int64  `json:"size" gorm:"comment:文件大小，单位字节"`
+	MimeType     string `json:"mimeType" gorm:"size:128;comment:文件类型"`
+	Ext          string `json:"ext" gorm:"size:32;comment:扩展名"`
+	Hash         string `json:"hash" gorm:"index;size:64;comment:SHA-256"`
+	Storage      string `json:"storage" gorm:"size:16;comment:存储类型(local/s3/minio)"`
+	UploaderId   uint   `json:"uploaderId" gorm:"index;comment:上传人ID"`
+	UploaderName string `json:"uploaderName" gorm:"size:64;comment:上传人"`
+}
+
+func (SysFile) TableName() string {
+	return "sys_files"
+}
+
+// FileRequest 文件查询请求
+type FileRequest struct {
+	PageInfo
+	Name         string `json:"name" form:"name"`
+	MimeType     string `json:"mimeType" form:"mimeType"` // 支持 image/ 这样的前缀匹配
+	UploaderName string `json:"uploaderName" form:"uploaderName"`
+	StartTime    string `json:"startTime" form:"startTime"`
+	EndTime      string `json:"endTime" form:"endTime"`
+	UploaderId   uint   `json:"-" form:"-"` // 只查询该用户上传的文件，由接口按当前用户设置
+}
+
+// SysFileLock 文件内容锁，每种内容一行；新增和删除文件记录时锁定，保证存储中的对象与引用它的记录一致
+type SysFileLock struct {
+	Storage string `gorm:"primaryKey;size:16;comment:存储类型"`
+	Hash    string `gorm:"primaryKey;size:64;comment:SHA-256"`
+}
+
+func (SysFileLock) TableName() string {
+	return "sys_file_locks"
+}
+
+// FileQuota 用户的文件存储配额，Limit为0表示不限制
+type FileQuota struct {
+	Used  int64 `json:"used"`
+	Limit int64 `json:"limit"`
+}
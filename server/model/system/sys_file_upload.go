@@ -0,0 +1,54 @@
+package system
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// SysFileUpload 分片上传任务，完成后合并为 SysFile
+type SysFileUpload struct {
+	gorm.Model
+	UploadId     string `json:"uploadId" gorm:"uniqueIndex;size:64;comment:上传ID"`
+	Name         string `json:"name" gorm:"size:255;comment:原始文件名"`
+	Size         int64  `json:"size" gorm:"comment:文件大小，单位字节"`
+	Hash         string `json:"hash" gorm:"size:64;comment:客户端声明的SHA-256，合并后校验"`
+	ChunkSize    int64  `json:"chunkSize" gorm:"comment:分片大小，单位字节"`
+	TotalChunks  int    `json:"totalChunks" gorm:"comment:分片数"`
+	Storage      string `json:"storage" gorm:"size:16;comment:存储类型"`
+	Status       string `json:"status" gorm:"index;size:16;comment:状态(uploading/merging/completed)"`
+	FileId       uint   `json:"fileId" gorm:"comment:合并后的文件ID"`
+	UploaderId   uint   `json:"uploaderId" gorm:"index;comment:上传人ID"`
+	UploaderName string `json:"uploaderName" gorm:"size:64;comment:上传人"`
+}
+
+func (SysFileUpload) TableName() string {
+	return "sys_file_uploads"
+}
+
+// SysFileChunk 已接收的分片
+type SysFileChunk struct {
+	ID         uint      `json:"-" gorm:"primarykey"`
+	UploadId   string    `json:"-" gorm:"uniqueIndex:idx_upload_chunk;size:64;comment:上传ID"`
+	ChunkIndex int       `json:"index" gorm:"uniqueIndex:idx_upload_chunk;comment:分片序号，从0开始"`
+	Size       int64     `json:"size" gorm:"comment:分片大小"`
+	Checksum   string    `json:"checksum" gorm:"size:64;comment:分片的SHA-256"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+func (SysFileChunk) TableName() string {
+	return "sys_file_chunks"
+}
+
+// ChunkInitRequest 初始化分片上传请求
+type ChunkInitRequest struct {
+	Name string `json:"name" binding:"required"`
+	Size int64  `json:"size" binding:"required,gt=0"`
+	Hash string `json:"hash"` // 整个文件的SHA-256，可选，传入时合并后校验，并用于续传时匹配未完成的上传
+}
+
+// ChunkUploadStatus 分片上传进度
+type ChunkUploadStatus struct {
+	SysFileUpload
+	Uploaded []int `json:"uploaded"` // 已接收的分片序号
+}
@@ -0,0 +1,72 @@
+package system
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// SysJob 定时任务表
+type SysJob struct {
+	gorm.Model
+	Name       string     `json:"name" gorm:"uniqueIndex;size:128;comment:任务名称"`
+	JobType    string     `json:"jobType" gorm:"size:64;comment:任务类型"`
+	Cron       string     `json:"cron" gorm:"size:64;comment:cron表达式"`
+	Params     string     `json:"params" gorm:"type:text;comment:任务参数(JSON)"`
+	Enabled    bool       `json:"enabled" gorm:"comment:是否启用"`
+	Remark     string     `json:"remark" gorm:"comment:备注"`
+	LastRunAt  *time.Time `json:"lastRunAt" gorm:"comment:最后执行时间"`
+	LastStatus string     `json:"lastStatus" gorm:"size:16;comment:最后执行结果"`
+	NextRunAt  *time.Time `json:"nextRunAt" gorm:"-"`
+}
+
+func (SysJob) TableName() string {
+	return "sys_jobs"
+}
+
+// SysJobLog 定时任务执行记录表
+type SysJobLog struct {
+	gorm.Model
+	JobId      uint      `json:"jobId" gorm:"index;comment:任务ID"`
+	JobName    string    `json:"jobName" gorm:"size:128;comment:任务名称"`
+	JobType    string    `json:"jobType" gorm:"size:64;comment:任务类型"`
+	Trigger    string    `json:"trigger" gorm:"size:16;comment:触发方式(schedule/manual)"`
+	Operator   string    `json:"operator" gorm:"comment:操作人"`
+	Instance   string    `json:"instance" gorm:"size:128;comment:执行实例"`
+	Status     string    `json:"status" gorm:"index;size:16;comment:执行结果(running/success/failed)"`
+	StartedAt  time.Time `json:"startedAt" gorm:"comment:开始时间"`
+	FinishedAt time.Time `json:"finishedAt" gorm:"comment:结束时间"`
+	Duration   int64     `json:"duration" gorm:"comment:耗时(毫秒)"`
+	Result     string    `json:"result" gorm:"type:text;comment:执行结果"`
+	Error      string    `json:"error" gorm:"type:text;comment:错误信息"`
+}
+
+func (SysJobLog) TableName() string {
+	return "sys_job_logs"
+}
+
+// SysJobLock 定时任务执行锁，多实例部署时保证同一任务同一时刻只执行一次
+type SysJobLock struct {
+	Name      string    `json:"name" gorm:"primaryKey;size:128;comment:锁名称"`
+	Owner     string    `json:"owner" gorm:"size:128;comment:持有者"`
+	ExpiresAt time.Time `json:"expiresAt" gorm:"comment:过期时间"`
+}
+
+func (SysJobLock) TableName() string {
+	return "sys_job_locks"
+}
+
+// JobRequest 定时任务查询请求
+type JobRequest struct {
+	PageInfo
+	Name    string `json:"name" form:"name"`
+	JobType string `json:"jobType" form:"jobType"`
+	Enabled *bool  `json:"enabled" form:"enabled"`
+}
+
+// JobLogRequest 定时任务执行记录查询请求
+type JobLogRequest struct {
+	PageInfo
+	JobId  uint   `json:"jobId" form:"jobId"`
+	Status string `json:"status" form:"status"`
+}
@@ -0,0 +1,44 @@
+package system
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// SysLoginLog 登录日志表，记录登录、登出、刷新token的每次尝试
+type SysLoginLog struct {
+	gorm.Model
+	UserID    uint      `json:"userId" gorm:"index;comment:用户ID，用户不存在时为0"`
+	Username  string    `json:"username" gorm:"index;size:64;comment:用户名（登录失败时为尝试的用户名）"`
+	Action    string    `json:"action" gorm:"size:16;comment:动作(login/logout/refresh)"`
+	Success   bool      `json:"success" gorm:"index;comment:是否成功"`
+	Reason    string    `json:"reason" gorm:"comment:失败原因"`
+	SessionId string    `json:"sessionId" gorm:"size:64;comment:会话ID"`
+	IP        string    `json:"ip" gorm:"index;size:64;comment:IP地址"`
+	UserAgent string    `json:"userAgent" gorm:"comment:用户代理"`
+	Browser   string    `json:"browser" gorm:"size:64;comment:浏览器"`
+	OS        string    `json:"os" gorm:"size:64;comment:操作系统"`
+	LoginTime time.Time `json:"loginTime" gorm:"index;comment:时间"`
+}
+
+func (SysLoginLog) TableName() string {
+	return "sys_login_logs"
+}
+
+// LoginLogRequest 登录日志查询请求
+type LoginLogRequest struct {
+	PageInfo
+	Username  string `json:"username" form:"username"`
+	Action    string `json:"action" form:"action"`
+	Success   *bool  `json:"success" form:"success"`
+	IP        string `json:"ip" form:"ip"`
+	StartTime string `json:"startTime" form:"startTime"`
+	EndTime   string `json:"endTime" form:"endTime"`
+}
+
+// LoginLogExportRequest 登录日志导出请求
+type LoginLogExportRequest struct {
+	LoginLogRequest
+	Format string `json:"format" form:"format"` // 导出格式：csv、xlsx
+}
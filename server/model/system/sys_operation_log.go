@@ -13,22 +13,58 @@ type SysOperationLog struct {
 	Username     string    `json:"username" gorm:"comment:用户名"`
 	Method       string    `json:"method" gorm:"comment:请求方法"`
 	Path         string    `json:"path" gorm:"comment:请求路径"`
-	OperationType string   `json:"operationType" gorm:"comment:操作类型(CREATE/UPDATE/DELETE)"`
+	Module       string    `json:"module" gorm:"index;size:64;comment:所属模块"`
+	OperationType string   `json:"operationType" gorm:"comment:操作类型(CREATE/UPDATE/DELETE/QUERY等)"`
 	Description  string    `json:"description" gorm:"comment:操作描述"`
 	RequestBody  string    `json:"requestBody" gorm:"type:text;comment:请求参数"`
 	ResponseBody string    `json:"responseBody" gorm:"type:text;comment:响应结果"`
+	Snapshot     string    `json:"snapshot" gorm:"type:text;comment:变更前后快照"`
+	RequestId    string    `json:"requestId" gorm:"index;size:64;comment:请求ID"`
 	IP           string    `json:"ip" gorm:"comment:请求IP"`
 	UserAgent    string    `json:"userAgent" gorm:"comment:用户代理"`
 	Status       int       `json:"status" gorm:"comment:响应状态码"`
 	ErrorMessage string    `json:"errorMessage" gorm:"comment:错误信息"`
 	Latency      int64     `json:"latency" gorm:"comment:请求耗时(毫秒)"`
 	OperationTime time.Time `json:"operationTime" gorm:"comment:操作时间"`
+	PrevHash     string    `json:"prevHash" gorm:"size:64;comment:上一条日志的哈希"`
+	Hash         string    `json:"hash" gorm:"size:64;comment:本条日志的哈希"`
 }
 
 func (SysOperationLog) TableName() string {
 	return "sys_operation_logs"
 }
 
+// SysOperationLogCheckpoint 操作日志归档检查点，记录被清理日志的范围和链尾哈希
+type SysOperationLogCheckpoint struct {
+	gorm.Model
+	FirstId       uint      `json:"firstId" gorm:"comment:归档的第一条日志ID"`
+	LastId        uint      `json:"lastId" gorm:"index;comment:归档的最后一条日志ID"`
+	Count         int64     `json:"count" gorm:"comment:归档条数"`
+	LastHash      string    `json:"lastHash" gorm:"size:64;comment:最后一条日志的哈希"`
+	BrokenCount   int       `json:"brokenCount" gorm:"comment:归档时发现的哈希链断点数"`
+	ArchiveFile   string    `json:"archiveFile" gorm:"comment:归档文件"`
+	ArchiveSha256 string    `json:"archiveSha256" gorm:"size:64;comment:归档文件的SHA-256"`
+	ArchiveSize   int64     `json:"archiveSize" gorm:"comment:归档文件大小"`
+	ArchivedAt    time.Time `json:"archivedAt" gorm:"comment:归档时间"`
+	Operator      string    `json:"operator" gorm:"comment:操作人"`
+	Reason        string    `json:"reason" gorm:"comment:归档原因"`
+	Signature     string    `json:"signature" gorm:"size:64;comment:检查点签名"`
+}
+
+func (SysOperationLogCheckpoint) TableName() string {
+	return "sys_operation_log_checkpoints"
+}
+
+// SysOperationLogChainHead 哈希链写入锁，只有一行；写入日志前锁定该行，日志表为空时多个实例也能按顺序追加
+type SysOperationLogChainHead struct {
+	ID        uint `gorm:"primarykey"`
+	UpdatedAt time.Time
+}
+
+func (SysOperationLogChainHead) TableName() string {
+	return "sys_operation_log_chain_heads"
+}
+
 // OperationLogRequest 操作日志查询请求
 type OperationLogRequest struct {
 	PageInfo
@@ -36,12 +72,20 @@ type OperationLogRequest struct {
 	Username      string `json:"username" form:"username"`
 	Method        string `json:"method" form:"method"`
 	Path          string `json:"path" form:"path"`
+	Module        string `json:"module" form:"module"`
 	OperationType string `json:"operationType" form:"operationType"`
 	Status        int    `json:"status" form:"status"`
 	StartTime     string `json:"startTime" form:"startTime"`
 	EndTime       string `json:"endTime" form:"endTime"`
 }
 
+// OperationLogExportRequest 操作日志导出请求
+type OperationLogExportRequest struct {
+	OperationLogRequest
+	Format      string `json:"format" form:"format"`           // 导出格式：csv、xlsx、ndjson
+	IncludeBody bool   `json:"includeBody" form:"includeBody"` // 是否包含请求和响应内容
+}
+
 // OperationLogResponse 操作日志响应
 type OperationLogResponse struct {
 	List     []SysOperationLog `json:"list"`
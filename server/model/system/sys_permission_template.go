@@ -0,0 +1,28 @@
+package system
+
+import (
+	"gorm.io/gorm"
+)
+
+// SysPermissionTemplate 权限模板表
+type SysPermissionTemplate struct {
+	gorm.Model
+	Name        string `json:"name" gorm:"uniqueIndex;size:128;comment:模板名称"`
+	Description string `json:"description" gorm:"comment:模板描述"`
+	MenuIds     []uint `json:"menuIds" gorm:"-"`
+}
+
+func (SysPermissionTemplate) TableName() string {
+	return "sys_permission_templates"
+}
+
+// SysPermissionTemplateMenu 权限模板菜单关联表
+type SysPermissionTemplateMenu struct {
+	gorm.Model
+	TemplateId uint `json:"templateId" gorm:"index;comment:模板ID"`
+	BaseMenuId uint `json:"baseMenuId" gorm:"comment:菜单ID"`
+}
+
+func (SysPermissionTemplateMenu) TableName() string {
+	return "sys_permission_template_menus"
+}
@@ -0,0 +1,29 @@
+package system
+
+import (
+	"gorm.io/gorm"
+)
+
+// SysPost 岗位表
+type SysPost struct {
+	gorm.Model
+	PostCode string `json:"postCode" gorm:"uniqueIndex;size:64;comment:岗位编码"`
+	PostName string `json:"postName" gorm:"comment:岗位名称"`
+	Sort     int    `json:"sort" gorm:"comment:排序标记"`
+	Status   int    `json:"status" gorm:"default:1;comment:岗位状态 1正常 2停用"`
+	Remark   string `json:"remark" gorm:"comment:备注"`
+}
+
+func (SysPost) TableName() string {
+	return "sys_posts"
+}
+
+// SysUserPost 用户岗位关联表
+type SysUserPost struct {
+	SysUserId uint `json:"sysUserId" gorm:"column:sys_user_id;primaryKey;comment:用户ID"`
+	SysPostId uint `json:"sysPostId" gorm:"column:sys_post_id;primaryKey;comment:岗位ID"`
+}
+
+func (SysUserPost) TableName() string {
+	return "sys_user_posts"
+}
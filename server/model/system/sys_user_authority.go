@@ -0,0 +1,11 @@
+package system
+
+// SysUserAuthority 用户角色关联表
+type SysUserAuthority struct {
+	SysUserId               uint `json:"sysUserId" gorm:"column:sys_user_id;primaryKey;comment:用户ID"`
+	SysAuthorityAuthorityId uint `json:"authorityId" gorm:"column:sys_authority_authority_id;primaryKey;comment:角色ID"`
+}
+
+func (SysUserAuthority) TableName() string {
+	return "sys_user_authorities"
+}
@@ -0,0 +1,36 @@
+package system
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// SysUserSession 用户登录会话表，每次登录对应一条，会话ID写入token的jti
+type SysUserSession struct {
+	gorm.Model
+	SessionId    string     `json:"sessionId" gorm:"uniqueIndex;size:64;comment:会话ID"`
+	UserID       uint       `json:"userId" gorm:"index;comment:用户ID"`
+	Username     string     `json:"username" gorm:"comment:用户名"`
+	AuthorityId  uint       `json:"authorityId" gorm:"comment:当前角色ID"`
+	IP           string     `json:"ip" gorm:"size:64;comment:登录IP"`
+	UserAgent    string     `json:"userAgent" gorm:"comment:用户代理"`
+	LoginAt      time.Time  `json:"loginAt" gorm:"comment:登录时间"`
+	LastSeenAt   time.Time  `json:"lastSeenAt" gorm:"index;comment:最后活动时间"`
+	ExpiresAt    time.Time  `json:"expiresAt" gorm:"comment:过期时间"`
+	RevokedAt    *time.Time `json:"revokedAt" gorm:"index;comment:失效时间"`
+	RevokeReason string     `json:"revokeReason" gorm:"size:16;comment:失效原因(logout/kicked)"`
+	RevokedBy    string     `json:"revokedBy" gorm:"comment:操作人"`
+}
+
+func (SysUserSession) TableName() string {
+	return "sys_user_sessions"
+}
+
+// OnlineUserRequest 在线用户查询请求
+type OnlineUserRequest struct {
+	PageInfo
+	Username string `json:"username" form:"username"`
+	IP       string `json:"ip" form:"ip"`
+	UserId   uint   `json:"-" form:"-"` // 只查询该用户的会话，由接口按当前用户设置
+}
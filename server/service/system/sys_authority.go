@@ -0,0 +1,574 @@
+package system
+
+import (
+	"context"
+	"errors"
+	"server/global"
+	"server/model/system"
+
+	"gorm.io/gorm"
+)
+
+type AuthorityService struct{}
+
+// AuthorityPrune 角色需要被收回的菜单权限
+type AuthorityPrune struct {
+	AuthorityId    uint   `json:"authorityId"`
+	AuthorityName  string `json:"authorityName"`
+	RemovedMenuIds []uint `json:"removedMenuIds"`
+}
+
+// PermissionSource 权限来源说明
+type PermissionSource struct {
+	AuthorityId   uint   `json:"authorityId"`
+	AuthorityName string `json:"authorityName"`
+	Relation      string `json:"relation"` // self:本角色 ancestor:祖先角色 default:系统默认
+}
+
+// EffectivePermission 角色的单个有效权限
+type EffectivePermission struct {
+	MenuId         uint               `json:"menuId"`
+	Title          string             `json:"title"`
+	MenuType       string             `json:"menuType"`
+	PermissionCode string             `json:"permissionCode"`
+	Effective      bool               `json:"effective"`
+	Sources        []PermissionSource `json:"sources"`
+	BlockedBy      *PermissionSource  `json:"blockedBy,omitempty"`
+}
+
+// LoadAuthorityMenuIds 获取角色直接拥有的菜单ID
+func (s *AuthorityService) LoadAuthorityMenuIds(db *gorm.DB, authorityId uint) ([]uint, error) {
+	var menuIds []uint
+	err := db.Model(&system.SysAuthorityMenu{}).Where("authority_id = ?", authorityId).Pluck("base_menu_id", &menuIds).Error
+	return menuIds, err
+}
+
+// ResolveAuthorityMenuIds 获取角色实际拥有的菜单ID，管理员角色未配置权限时默认拥有全部菜单
+func (s *AuthorityService) ResolveAuthorityMenuIds(db *gorm.DB, authorityId uint) ([]uint, error) {
+	menuIds, err := s.LoadAuthorityMenuIds(db, authorityId)
+	if err != nil {
+		return nil, err
+	}
+	return resolveAdminDefault(db, authorityId, menuIds)
+}
+
+// resolveAdminDefault 管理员角色未配置权限时返回全部菜单ID，与用户菜单接口保持一致
+func resolveAdminDefault(db *gorm.DB, authorityId uint, menuIds []uint) ([]uint, error) {
+	if len(menuIds) > 0 || authorityId != 888 {
+		return menuIds, nil
+	}
+	var allMenuIds []uint
+	err := db.Model(&system.SysBaseMenu{}).Pluck("id", &allMenuIds).Error
+	return allMenuIds, err
+}
+
+// GetDescendants 按层级顺序（由近及远）获取角色的所有子孙角色
+func (s *AuthorityService) GetDescendants(db *gorm.DB, authorityId uint) ([]system.SysAuthority, error) {
+	var authorities []system.SysAuthority
+	if err := db.Find(&authorities).Error; err != nil {
+		return nil, err
+	}
+
+	childrenMap := make(map[uint][]system.SysAuthority)
+	for _, authority := range authorities {
+		if authority.ParentId != nil {
+			childrenMap[*authority.ParentId] = append(childrenMap[*authority.ParentId], authority)
+		}
+	}
+
+	var descendants []system.SysAuthority
+	visited := map[uint]bool{authorityId: true}
+	queue := []uint{authorityId}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, child := range childrenMap[current] {
+			if visited[child.AuthorityId] {
+				continue
+			}
+			visited[child.AuthorityId] = true
+			descendants = append(descendants, child)
+			queue = append(queue, child.AuthorityId)
+		}
+	}
+
+	return descendants, nil
+}
+
+// PlanDescendantPrune 计算角色权限变为rootMenuIds后，子孙角色需要收回的权限
+func (s *AuthorityService) PlanDescendantPrune(db *gorm.DB, authorityId uint, rootMenuIds []uint) ([]AuthorityPrune, error) {
+	descendants, err := s.GetDescendants(db, authorityId)
+	if err != nil {
+		return nil, err
+	}
+	if rootMenuIds, err = resolveAdminDefault(db, authorityId, rootMenuIds); err != nil {
+		return nil, err
+	}
+
+	// 记录每个角色收回权限后剩余的菜单
+	remaining := map[uint]map[uint]bool{authorityId: toIdSet(rootMenuIds)}
+
+	var plan []AuthorityPrune
+	for _, child := range descendants {
+		menuIds, err := s.LoadAuthorityMenuIds(db, child.AuthorityId)
+		if err != nil {
+			return nil, err
+		}
+
+		parentMenus := remaining[*child.ParentId]
+		kept := make(map[uint]bool)
+		var removed []uint
+		for _, menuId := range menuIds {
+			if parentMenus[menuId] {
+				kept[menuId] = true
+			} else {
+				removed = append(removed, menuId)
+			}
+		}
+		remaining[child.AuthorityId] = kept
+
+		if len(removed) > 0 {
+			plan = append(plan, AuthorityPrune{
+				AuthorityId:    child.AuthorityId,
+				AuthorityName:  child.AuthorityName,
+				RemovedMenuIds: removed,
+			})
+		}
+	}
+
+	return plan, nil
+}
+
+// ApplyPrune 收回计划中的权限（需在事务中调用）
+func (s *AuthorityService) ApplyPrune(tx *gorm.DB, plan []AuthorityPrune) error {
+	for _, item := range plan {
+		if len(item.RemovedMenuIds) == 0 {
+			continue
+		}
+		if err := tx.Where("authority_id = ? AND base_menu_id IN ?", item.AuthorityId, item.RemovedMenuIds).
+			Delete(&system.SysAuthorityMenu{}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PruneDescendantMenus 收回子孙角色中超出本角色范围的权限（需在事务中调用）
+func (s *AuthorityService) PruneDescendantMenus(tx *gorm.DB, authorityId uint) ([]AuthorityPrune, error) {
+	menuIds, err := s.LoadAuthorityMenuIds(tx, authorityId)
+	if err != nil {
+		return nil, err
+	}
+
+	plan, err := s.PlanDescendantPrune(tx, authorityId, menuIds)
+	if err != nil {
+		return nil, err
+	}
+	return plan, s.ApplyPrune(tx, plan)
+}
+
+// PruneToParent 角色更换父角色后，收回本角色及子孙角色超出新父角色范围的权限（需在事务中调用）
+func (s *AuthorityService) PruneToParent(tx *gorm.DB, authority system.SysAuthority, parentId uint) ([]AuthorityPrune, error) {
+	parentMenuIds, err := s.ResolveAuthorityMenuIds(tx, parentId)
+	if err != nil {
+		return nil, err
+	}
+	menuIds, err := s.LoadAuthorityMenuIds(tx, authority.AuthorityId)
+	if err != nil {
+		return nil, err
+	}
+
+	parentSet := toIdSet(parentMenuIds)
+	var kept, removed []uint
+	for _, menuId := range menuIds {
+		if parentSet[menuId] {
+			kept = append(kept, menuId)
+		} else {
+			removed = append(removed, menuId)
+		}
+	}
+
+	var plan []AuthorityPrune
+	if len(removed) > 0 {
+		plan = append(plan, AuthorityPrune{
+			AuthorityId:    authority.AuthorityId,
+			AuthorityName:  authority.AuthorityName,
+			RemovedMenuIds: removed,
+		})
+	}
+
+	descendantPlan, err := s.PlanDescendantPrune(tx, authority.AuthorityId, kept)
+	if err != nil {
+		return nil, err
+	}
+	plan = append(plan, descendantPlan...)
+
+	return plan, s.ApplyPrune(tx, plan)
+}
+
+// MenuBrief 菜单简要信息
+type MenuBrief struct {
+	MenuId         uint   `json:"menuId"`
+	Title          string `json:"title"`
+	MenuType       string `json:"menuType"`
+	PermissionCode string `json:"permissionCode"`
+}
+
+// AuthorityImpact 子孙角色受权限变更影响的情况
+type AuthorityImpact struct {
+	AuthorityId       uint        `json:"authorityId"`
+	AuthorityName     string      `json:"authorityName"`
+	RemovedMenus      []MenuBrief `json:"removedMenus"`
+	AffectedUserCount int64       `json:"affectedUserCount"`
+}
+
+// AssignMenusPreview 分配菜单权限的变更预览
+type AssignMenusPreview struct {
+	AuthorityId         uint              `json:"authorityId"`
+	AuthorityName       string            `json:"authorityName"`
+	AddedMenus          []MenuBrief       `json:"addedMenus"`
+	RemovedMenus        []MenuBrief       `json:"removedMenus"`
+	AddedButtons        []MenuBrief       `json:"addedButtons"`
+	RemovedButtons      []MenuBrief       `json:"removedButtons"`
+	AffectedUserCount   int64             `json:"affectedUserCount"`
+	ImpactedAuthorities []AuthorityImpact `json:"impactedAuthorities"`
+}
+
+// PreviewAssignMenus 预览角色权限由beforeMenuIds变为afterMenuIds产生的影响，不做任何修改
+func (s *AuthorityService) PreviewAssignMenus(authority system.SysAuthority, beforeMenuIds, afterMenuIds []uint) (*AssignMenusPreview, error) {
+	beforeSet := toIdSet(beforeMenuIds)
+	afterSet := toIdSet(afterMenuIds)
+
+	var addedIds, removedIds []uint
+	for _, id := range uniqueIds(afterMenuIds) {
+		if !beforeSet[id] {
+			addedIds = append(addedIds, id)
+		}
+	}
+	for _, id := range uniqueIds(beforeMenuIds) {
+		if !afterSet[id] {
+			removedIds = append(removedIds, id)
+		}
+	}
+
+	plan, err := s.PlanDescendantPrune(global.DB, authority.AuthorityId, afterMenuIds)
+	if err != nil {
+		return nil, err
+	}
+
+	// 一次性查询所有涉及的菜单
+	involvedIds := append(append([]uint{}, addedIds...), removedIds...)
+	for _, item := range plan {
+		involvedIds = append(involvedIds, item.RemovedMenuIds...)
+	}
+	menuMap, err := s.loadMenuBriefs(involvedIds)
+	if err != nil {
+		return nil, err
+	}
+
+	userService := UserService{}
+	preview := &AssignMenusPreview{
+		AuthorityId:         authority.AuthorityId,
+		AuthorityName:       authority.AuthorityName,
+		AddedMenus:          []MenuBrief{},
+		RemovedMenus:        []MenuBrief{},
+		AddedButtons:        []MenuBrief{},
+		RemovedButtons:      []MenuBrief{},
+		ImpactedAuthorities: []AuthorityImpact{},
+	}
+	for _, id := range addedIds {
+		if menu, ok := menuMap[id]; ok {
+			if menu.MenuType == "button" {
+				preview.AddedButtons = append(preview.AddedButtons, menu)
+			} else {
+				preview.AddedMenus = append(preview.AddedMenus, menu)
+			}
+		}
+	}
+	for _, id := range removedIds {
+		if menu, ok := menuMap[id]; ok {
+			if menu.MenuType == "button" {
+				preview.RemovedButtons = append(preview.RemovedButtons, menu)
+			} else {
+				preview.RemovedMenus = append(preview.RemovedMenus, menu)
+			}
+		}
+	}
+
+	if preview.AffectedUserCount, err = userService.CountAuthorityUsers(authority.AuthorityId); err != nil {
+		return nil, err
+	}
+
+	for _, item := range plan {
+		impact := AuthorityImpact{
+			AuthorityId:   item.AuthorityId,
+			AuthorityName: item.AuthorityName,
+			RemovedMenus:  []MenuBrief{},
+		}
+		for _, id := range item.RemovedMenuIds {
+			if menu, ok := menuMap[id]; ok {
+				impact.RemovedMenus = append(impact.RemovedMenus, menu)
+			}
+		}
+		if impact.AffectedUserCount, err = userService.CountAuthorityUsers(item.AuthorityId); err != nil {
+			return nil, err
+		}
+		preview.ImpactedAuthorities = append(preview.ImpactedAuthorities, impact)
+	}
+
+	return preview, nil
+}
+
+// loadMenuBriefs 批量查询菜单简要信息
+func (s *AuthorityService) loadMenuBriefs(menuIds []uint) (map[uint]MenuBrief, error) {
+	result := make(map[uint]MenuBrief)
+	menuIds = uniqueIds(menuIds)
+	if len(menuIds) == 0 {
+		return result, nil
+	}
+
+	var menus []system.SysBaseMenu
+	if err := global.DB.Where("id IN ?", menuIds).Order("sort ASC").Find(&menus).Error; err != nil {
+		return nil, err
+	}
+	for _, menu := range menus {
+		result[menu.ID] = MenuBrief{
+			MenuId:         menu.ID,
+			Title:          menu.Title,
+			MenuType:       menu.MenuType,
+			PermissionCode: menu.PermissionCode,
+		}
+	}
+	return result, nil
+}
+
+// GetAncestors 获取角色的祖先角色，按由近及远排列
+func (s *AuthorityService) GetAncestors(authority system.SysAuthority) ([]system.SysAuthority, error) {
+	var ancestors []system.SysAuthority
+	visited := map[uint]bool{authority.AuthorityId: true}
+
+	current := authority
+	for current.ParentId != nil && !visited[*current.ParentId] {
+		var parent system.SysAuthority
+		if err := global.DB.Where("authority_id = ?", *current.ParentId).First(&parent).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				break
+			}
+			return nil, err
+		}
+		visited[parent.AuthorityId] = true
+		ancestors = append(ancestors, parent)
+		current = parent
+	}
+
+	return ancestors, nil
+}
+
+// GetEffectivePermissions 获取角色的有效权限，并说明每项权限的来源
+func (s *AuthorityService) GetEffectivePermissions(authority system.SysAuthority) ([]EffectivePermission, error) {
+	menuIds, err := s.LoadAuthorityMenuIds(global.DB, authority.AuthorityId)
+	if err != nil {
+		return nil, err
+	}
+
+	// 管理员角色未配置权限时默认拥有全部菜单，与用户菜单接口保持一致
+	adminDefault := len(menuIds) == 0 && authority.AuthorityId == 888
+
+	var menus []system.SysBaseMenu
+	db := global.DB.Order("sort ASC")
+	if !adminDefault {
+		if len(menuIds) == 0 {
+			return []EffectivePermission{}, nil
+		}
+		db = db.Where("id IN ?", menuIds)
+	}
+	if err := db.Find(&menus).Error; err != nil {
+		return nil, err
+	}
+
+	ancestors, err := s.GetAncestors(authority)
+	if err != nil {
+		return nil, err
+	}
+	ancestorMenus := make([]map[uint]bool, len(ancestors))
+	for i, ancestor := range ancestors {
+		ids, err := s.ResolveAuthorityMenuIds(global.DB, ancestor.AuthorityId)
+		if err != nil {
+			return nil, err
+		}
+		ancestorMenus[i] = toIdSet(ids)
+	}
+
+	permissions := make([]EffectivePermission, 0, len(menus))
+	for _, menu := range menus {
+		relation := "self"
+		if adminDefault {
+			relation = "default"
+		}
+		permission := EffectivePermission{
+			MenuId:         menu.ID,
+			Title:          menu.Title,
+			MenuType:       menu.MenuType,
+			PermissionCode: menu.PermissionCode,
+			Effective:      true,
+			Sources: []PermissionSource{{
+				AuthorityId:   authority.AuthorityId,
+				AuthorityName: authority.AuthorityName,
+				Relation:      relation,
+			}},
+		}
+
+		// 逐级检查祖先角色，任何一级未授予都会使该权限失效
+		for i, ancestor := range ancestors {
+			source := PermissionSource{
+				AuthorityId:   ancestor.AuthorityId,
+				AuthorityName: ancestor.AuthorityName,
+				Relation:      "ancestor",
+			}
+			if !ancestorMenus[i][menu.ID] {
+				permission.Effective = false
+				permission.BlockedBy = &source
+				break
+			}
+			permission.Sources = append(permission.Sources, source)
+		}
+
+		permissions = append(permissions, permission)
+	}
+
+	return permissions, nil
+}
+
+// BuildAuthorityTree 构建角色树，父角色不存在的角色作为根节点
+func (s *AuthorityService) BuildAuthorityTree(authorities []system.SysAuthority) []system.SysAuthority {
+	exists := make(map[uint]bool)
+	for _, authority := range authorities {
+		exists[authority.AuthorityId] = true
+	}
+
+	childrenMap := make(map[uint][]system.SysAuthority)
+	var roots []system.SysAuthority
+	for _, authority := range authorities {
+		if authority.ParentId == nil || !exists[*authority.ParentId] || *authority.ParentId == authority.AuthorityId {
+			roots = append(roots, authority)
+		} else {
+			childrenMap[*authority.ParentId] = append(childrenMap[*authority.ParentId], authority)
+		}
+	}
+
+	visited := make(map[uint]bool)
+	var attach func(nodes []system.SysAuthority) []system.SysAuthority
+	attach = func(nodes []system.SysAuthority) []system.SysAuthority {
+		result := make([]system.SysAuthority, 0, len(nodes))
+		for _, node := range nodes {
+			if visited[node.AuthorityId] {
+				continue
+			}
+			visited[node.AuthorityId] = true
+			node.Children = attach(childrenMap[node.AuthorityId])
+			result = append(result, node)
+		}
+		return result
+	}
+
+	return attach(roots)
+}
+
+// SetAuthorityMenus 重新设置角色的菜单权限（需在事务中调用）
+func (s *AuthorityService) SetAuthorityMenus(tx *gorm.DB, authorityId uint, menuIds []uint) error {
+	if err := tx.Where("authority_id = ?", authorityId).Delete(&system.SysAuthorityMenu{}).Error; err != nil {
+		return err
+	}
+
+	var authorityMenus []system.SysAuthorityMenu
+	for _, menuId := range uniqueIds(menuIds) {
+		authorityMenus = append(authorityMenus, system.SysAuthorityMenu{
+			AuthorityId: authorityId,
+			BaseMenuId:  menuId,
+		})
+	}
+	if len(authorityMenus) == 0 {
+		return nil
+	}
+	return tx.Create(&authorityMenus).Error
+}
+
+// ConstrainToParent 将菜单限制在父角色权限范围内，返回保留和超出范围的菜单ID
+func (s *AuthorityService) ConstrainToParent(db *gorm.DB, parentId *uint, menuIds []uint) ([]uint, []uint, error) {
+	menuIds = uniqueIds(menuIds)
+	if parentId == nil {
+		return menuIds, nil, nil
+	}
+
+	parentMenuIds, err := s.ResolveAuthorityMenuIds(db, *parentId)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	parentSet := toIdSet(parentMenuIds)
+	var kept, skipped []uint
+	for _, menuId := range menuIds {
+		if parentSet[menuId] {
+			kept = append(kept, menuId)
+		} else {
+			skipped = append(skipped, menuId)
+		}
+	}
+	return kept, skipped, nil
+}
+
+// CopyAuthority 复制角色及其菜单权限，返回因父角色限制未复制的菜单ID
+func (s *AuthorityService) CopyAuthority(ctx context.Context, source system.SysAuthority, target *system.SysAuthority) ([]uint, error) {
+	var count int64
+	if err := global.DB.Model(&system.SysAuthority{}).Where("authority_code = ?", target.AuthorityCode).Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		return nil, errors.New("角色编码已存在")
+	}
+
+	if target.ParentId != nil {
+		var parent system.SysAuthority
+		if err := global.DB.Where("authority_id = ?", *target.ParentId).First(&parent).Error; err != nil {
+			return nil, errors.New("父角色不存在")
+		}
+	}
+
+	if target.DefaultRouter == "" {
+		target.DefaultRouter = source.DefaultRouter
+	}
+	target.AuthorityId = 0
+
+	var skipped []uint
+	err := global.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(target).Error; err != nil {
+			return err
+		}
+
+		menuIds, err := s.ResolveAuthorityMenuIds(tx, source.AuthorityId)
+		if err != nil {
+			return err
+		}
+
+		// 复制到其他父角色下时，权限不能超过新父角色
+		var kept []uint
+		kept, skipped, err = s.ConstrainToParent(tx, target.ParentId, menuIds)
+		if err != nil {
+			return err
+		}
+		return s.SetAuthorityMenus(tx, target.AuthorityId, kept)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return skipped, nil
+}
+
+// toIdSet 将ID切片转换为集合
+func toIdSet(ids []uint) map[uint]bool {
+	set := make(map[uint]bool, len(ids))
+	for _, id := range ids {
+		set[id] = true
+	}
+	return set
+}
@@ -0,0 +1,116 @@
+package system
+
+import (
+	"fmt"
+	"server/model/system"
+	"sort"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+// openAuthorityTestDB 创建内存数据库并建好角色与菜单相关的表
+func openAuthorityTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&system.SysAuthority{}, &system.SysAuthorityMenu{}, &system.SysBaseMenu{}); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return db
+}
+
+// seedAuthority 创建角色并授予指定菜单
+func seedAuthority(t *testing.T, db *gorm.DB, authorityId uint, parentId *uint, menuIds ...uint) system.SysAuthority {
+	t.Helper()
+	authority := system.SysAuthority{
+		AuthorityId:   authorityId,
+		AuthorityName: "role",
+		AuthorityCode: fmt.Sprintf("role-%d", authorityId),
+		ParentId:      parentId,
+	}
+	if err := db.Create(&authority).Error; err != nil {
+		t.Fatal(err)
+	}
+	for _, menuId := range menuIds {
+		if err := db.Create(&system.SysAuthorityMenu{AuthorityId: authorityId, BaseMenuId: menuId}).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	return authority
+}
+
+func authorityMenuIds(t *testing.T, db *gorm.DB, authorityId uint) []uint {
+	t.Helper()
+	var service AuthorityService
+	menuIds, err := service.LoadAuthorityMenuIds(db, authorityId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Slice(menuIds, func(i, j int) bool { return menuIds[i] < menuIds[j] })
+	return menuIds
+}
+
+func TestPruneUnderDefaultAdminKeepsChildMenus(t *testing.T) {
+	db := openAuthorityTestDB(t)
+	for i := 0; i < 3; i++ {
+		if err := db.Create(&system.SysBaseMenu{Title: "menu"}).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 管理员角色没有显式授权，默认拥有全部菜单
+	admin := uint(888)
+	seedAuthority(t, db, admin, nil)
+	child := seedAuthority(t, db, 100, nil, 1, 2)
+	grandchild := seedAuthority(t, db, 101, &child.AuthorityId, 2)
+
+	var service AuthorityService
+
+	plan, err := service.PruneToParent(db, child, admin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan) != 0 {
+		t.Errorf("re-parenting under the admin role pruned %+v", plan)
+	}
+
+	if err := db.Model(&child).Update("parent_id", admin).Error; err != nil {
+		t.Fatal(err)
+	}
+	if plan, err = service.PruneDescendantMenus(db, admin); err != nil {
+		t.Fatal(err)
+	}
+	if len(plan) != 0 {
+		t.Errorf("pruning under the admin role pruned %+v", plan)
+	}
+
+	if got := authorityMenuIds(t, db, child.AuthorityId); len(got) != 2 || got[0] != 1 || got[1] != 2 {
+		t.Errorf("child menus = %v, want [1 2]", got)
+	}
+	if got := authorityMenuIds(t, db, grandchild.AuthorityId); len(got) != 1 || got[0] != 2 {
+		t.Errorf("grandchild menus = %v, want [2]", got)
+	}
+
+	// 管理员角色显式授权后，按授权范围收回子角色的权限
+	if err := db.Create(&system.SysAuthorityMenu{AuthorityId: admin, BaseMenuId: 1}).Error; err != nil {
+		t.Fatal(err)
+	}
+	if _, err = service.PruneDescendantMenus(db, admin); err != nil {
+		t.Fatal(err)
+	}
+	if got := authorityMenuIds(t, db, child.AuthorityId); len(got) != 1 || got[0] != 1 {
+		t.Errorf("child menus after admin grant = %v, want [1]", got)
+	}
+	if got := authorityMenuIds(t, db, grandchild.AuthorityId); len(got) != 0 {
+		t.Errorf("grandchild menus after admin grant = %v, want []", got)
+	}
+}
@@ -0,0 +1,164 @@
+package system
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"io"
+	"path"
+	"server/global"
+	"server/model/system"
+	"server/utils"
+	"server/utils/upload"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// AvatarPrefix 头像在存储中的目录
+	AvatarPrefix = "avatar/"
+	// AvatarMaxSize 上传头像原图的大小上限，单位字节
+	AvatarMaxSize = 10 << 20
+
+	defaultAvatarSize    = 256
+	defaultAvatarQuality = 85
+)
+
+// AvatarResult 头像上传结果
+type AvatarResult struct {
+	URL        string         `json:"url"`
+	Key        string         `json:"key"`
+	Filename   string         `json:"filename"`
+	Thumbnails map[int]string `json:"thumbnails"` // 缩略图边长 -> 访问地址
+}
+
+type AvatarService struct{}
+
+// avatarSizes 头像边长和缩略图边长，忽略不合法和与头像相同的缩略图尺寸
+func avatarSizes() (int, []int) {
+	cfg := global.CONFIG.Avatar
+	size := cfg.Size
+	if size <= 0 {
+		size = defaultAvatarSize
+	}
+	var thumbnails []int
+	seen := map[int]bool{size: true}
+	for _, thumb := range cfg.Thumbnails {
+		if thumb <= 0 || seen[thumb] {
+			continue
+		}
+		seen[thumb] = true
+		thumbnails = append(thumbnails, thumb)
+	}
+	return size, thumbnails
+}
+
+// thumbnailKey 缩略图的对象键，与头像放在同一目录
+func thumbnailKey(key string, size int) string {
+	ext := path.Ext(key)
+	return fmt.Sprintf("%s_%d%s", strings.TrimSuffix(key, ext), size, ext)
+}
+
+// UploadAvatar 校验图片内容后裁剪、缩放并重新编码保存，原图中的EXIF等元数据不会保留
+func (s *AvatarService) UploadAvatar(ctx context.Context, reader io.Reader) (*AvatarResult, error) {
+	data, err := io.ReadAll(io.LimitReader(reader, AvatarMaxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > AvatarMaxSize {
+		return nil, fmt.Errorf("%w: 文件大小不能超过10MB", utils.ErrInvalidImage)
+	}
+
+	img, format, err := utils.DecodeImage(data, global.CONFIG.Avatar.MaxPixels)
+	if err != nil {
+		return nil, err
+	}
+
+	storage, err := upload.Storage()
+	if err != nil {
+		return nil, fmt.Errorf("文件存储不可用: %w", err)
+	}
+
+	ext, contentType := utils.EncodedImageExt(format)
+	filename := uuid.New().String() + ext
+	key := AvatarPrefix + filename
+	size, thumbnails := avatarSizes()
+
+	if err := s.putImage(ctx, storage, key, img, format, size, contentType); err != nil {
+		return nil, err
+	}
+	result := &AvatarResult{Key: key, Filename: filename, Thumbnails: map[int]string{}}
+	if result.URL, err = upload.LinkURL(ctx, storage, key); err != nil {
+		return nil, err
+	}
+
+	for _, thumb := range thumbnails {
+		thumbKey := thumbnailKey(key, thumb)
+		if err := s.putImage(ctx, storage, thumbKey, img, format, thumb, contentType); err != nil {
+			return nil, err
+		}
+		if result.Thumbnails[thumb], err = upload.LinkURL(ctx, storage, thumbKey); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// putImage 缩放到指定边长后编码保存
+func (s *AvatarService) putImage(ctx context.Context, storage upload.OSS, key string, img image.Image, format string, size int, contentType string) error {
+	quality := global.CONFIG.Avatar.Quality
+	if quality <= 0 {
+		quality = defaultAvatarQuality
+	}
+
+	var buf bytes.Buffer
+	if err := utils.EncodeImage(&buf, utils.ResizeSquare(img, size), format, quality); err != nil {
+		return err
+	}
+	if err := storage.Put(ctx, key, &buf, int64(buf.Len()), contentType); err != nil {
+		return fmt.Errorf("保存头像失败: %w", err)
+	}
+	return nil
+}
+
+// DeleteAvatar 删除用户不再使用的头像及其缩略图；外部地址、默认头像和仍被其他用户引用的头像不删除
+func (s *AvatarService) DeleteAvatar(ctx context.Context, headerImg string, userID uint) error {
+	if headerImg == "" {
+		return nil
+	}
+	storage, err := upload.Storage()
+	if err != nil {
+		return err
+	}
+	key, ok := upload.KeyFromURL(storage, headerImg)
+	if !ok || !strings.HasPrefix(key, AvatarPrefix) {
+		return nil
+	}
+
+	var count int64
+	if err := global.DB.WithContext(ctx).Model(&system.SysUser{}).
+		Where("header_img LIKE ? AND id <> ?", "%"+key, userID).
+		Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+
+	if err := storage.Delete(ctx, key); err != nil {
+		return err
+	}
+	// 旧版本上传的头像没有缩略图，文件名不是UUID
+	if _, err := uuid.Parse(strings.TrimSuffix(path.Base(key), path.Ext(key))); err != nil {
+		return nil
+	}
+	_, thumbnails := avatarSizes()
+	for _, thumb := range thumbnails {
+		if err := storage.Delete(ctx, thumbnailKey(key, thumb)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
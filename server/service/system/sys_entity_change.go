@@ -0,0 +1,474 @@
+package system
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"server/global"
+	"server/model/system"
+	"server/utils"
+	"sort"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 请求上下文中的键，由中间件写入，处理器通过 global.DB.WithContext(c) 传给GORM
+const (
+	ContextRequestIdKey = "requestId"
+	ContextUserIdKey    = "userID"
+	ContextUsernameKey  = "username"
+)
+
+const entityChangeBeforeKey = "entity_change:before"
+
+// auditedEntity 需要记录变更的实体
+type auditedEntity struct {
+	EntityType string // 实体类型
+	IdColumn   string // 实体ID所在列
+	ItemColumn string // 一个实体对应多行时（关联表），用于区分行的列
+}
+
+// auditedEntities 按表名登记需要记录变更的实体
+var auditedEntities = map[string]auditedEntity{
+	"sys_users":           {EntityType: "user", IdColumn: "id"},
+	"sys_authorities":     {EntityType: "authority", IdColumn: "authority_id"},
+	"sys_base_menus":      {EntityType: "menu", IdColumn: "id"},
+	"sys_authority_menus": {EntityType: "authority_menu", IdColumn: "authority_id", ItemColumn: "base_menu_id"},
+}
+
+// diffIgnoredColumns 不参与差异比较的列
+var diffIgnoredColumns = map[string]bool{
+	"updated_at": true,
+}
+
+type EntityChangeService struct{}
+
+// FieldChange 单个字段的变更
+type FieldChange struct {
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// RegisterEntityChangeCallbacks 注册GORM回调，在更新和删除登记的实体前后记录快照
+// 关联表的行通过先删后建维护，因此同时记录新增，便于还原完整的变更过程
+// 变更记录的请求ID和操作人从语句的Context中读取，只有通过 global.DB.WithContext(c) 传入gin.Context 的语句才能关联到请求，
+// 其余语句（如后台任务）也会记录变更，但请求ID和操作人为空
+func RegisterEntityChangeCallbacks(db *gorm.DB) error {
+	if err := db.Callback().Create().After("gorm:create").Before("gorm:commit_or_rollback_transaction").
+		Register("entity_change:after_create", recordEntityCreate); err != nil {
+		return err
+	}
+	if err := db.Callback().Update().After("gorm:before_update").Before("gorm:update").
+		Register("entity_change:before_update", captureEntityBefore); err != nil {
+		return err
+	}
+	if err := db.Callback().Update().After("gorm:update").Before("gorm:commit_or_rollback_transaction").
+		Register("entity_change:after_update", recordEntityUpdate); err != nil {
+		return err
+	}
+	if err := db.Callback().Delete().After("gorm:before_delete").Before("gorm:delete").
+		Register("entity_change:before_delete", captureEntityBefore); err != nil {
+		return err
+	}
+	return db.Callback().Delete().After("gorm:delete").Before("gorm:commit_or_rollback_transaction").
+		Register("entity_change:after_delete", recordEntityDelete)
+}
+
+// captureEntityBefore 按本次语句的条件查询变更前的数据
+func captureEntityBefore(db *gorm.DB) {
+	if db.Error != nil || db.Statement.Schema == nil {
+		return
+	}
+	if _, ok := auditedEntities[db.Statement.Table]; !ok {
+		return
+	}
+
+	conditions := statementConditions(db.Statement)
+	if len(conditions) == 0 {
+		// 没有条件的全表更新会被GORM拒绝，不做记录
+		return
+	}
+
+	query := db.Session(&gorm.Session{NewDB: true, SkipHooks: true}).Table(db.Statement.Table).
+		Clauses(clause.Where{Exprs: conditions})
+	if field := db.Statement.Schema.LookUpField("DeletedAt"); field != nil && !db.Statement.Unscoped {
+		query = query.Where(clause.Eq{Column: clause.Column{Name: field.DBName}, Value: nil})
+	}
+
+	var rows []map[string]interface{}
+	if err := query.Find(&rows).Error; err != nil {
+		fmt.Printf("查询变更前数据失败: %v\n", err)
+		return
+	}
+	if len(rows) > 0 {
+		db.InstanceSet(entityChangeBeforeKey, rows)
+	}
+}
+
+// recordEntityUpdate 查询更新后的数据并保存变更记录
+func recordEntityUpdate(db *gorm.DB) {
+	before, ok := entityBefore(db)
+	if !ok {
+		return
+	}
+
+	primaryKey := primaryColumn(db.Statement)
+	var keys []interface{}
+	for _, row := range before {
+		keys = append(keys, row[primaryKey])
+	}
+
+	var after []map[string]interface{}
+	if err := db.Session(&gorm.Session{NewDB: true, SkipHooks: true}).Table(db.Statement.Table).
+		Where(clause.IN{Column: clause.Column{Name: primaryKey}, Values: keys}).
+		Find(&after).Error; err != nil {
+		fmt.Printf("查询变更后数据失败: %v\n", err)
+		return
+	}
+
+	saveEntityChanges(db, "UPDATE", before, after)
+}
+
+// recordEntityCreate 保存新增记录
+func recordEntityCreate(db *gorm.DB) {
+	if db.Error != nil || db.RowsAffected == 0 || db.Statement.Schema == nil {
+		return
+	}
+	if _, ok := auditedEntities[db.Statement.Table]; !ok {
+		return
+	}
+
+	var after []map[string]interface{}
+	switch db.Statement.ReflectValue.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < db.Statement.ReflectValue.Len(); i++ {
+			after = append(after, structRow(db.Statement, reflect.Indirect(db.Statement.ReflectValue.Index(i))))
+		}
+	case reflect.Struct:
+		after = append(after, structRow(db.Statement, db.Statement.ReflectValue))
+	}
+	saveEntityChanges(db, "CREATE", nil, after)
+}
+
+// recordEntityDelete 保存删除记录
+func recordEntityDelete(db *gorm.DB) {
+	before, ok := entityBefore(db)
+	if !ok {
+		return
+	}
+	saveEntityChanges(db, "DELETE", before, nil)
+}
+
+// entityBefore 获取变更前的数据，语句执行失败或没有影响行时返回false
+func entityBefore(db *gorm.DB) ([]map[string]interface{}, bool) {
+	if db.Error != nil || db.RowsAffected == 0 {
+		return nil, false
+	}
+	value, ok := db.InstanceGet(entityChangeBeforeKey)
+	if !ok {
+		return nil, false
+	}
+	rows, ok := value.([]map[string]interface{})
+	return rows, ok
+}
+
+// saveEntityChanges 按实体分组生成变更记录，在同一事务中写入
+func saveEntityChanges(db *gorm.DB, action string, before, after []map[string]interface{}) {
+	entity := auditedEntities[db.Statement.Table]
+	primaryKey := primaryColumn(db.Statement)
+	ctx := db.Statement.Context
+
+	requestId, _ := ctx.Value(ContextRequestIdKey).(string)
+	userID, _ := ctx.Value(ContextUserIdKey).(uint)
+	username, _ := ctx.Value(ContextUsernameKey).(string)
+
+	beforeGroups, order := groupRows(before, entity.IdColumn)
+	afterGroups, afterOrder := groupRows(after, entity.IdColumn)
+	if action == "CREATE" {
+		order = afterOrder
+	}
+
+	var changes []system.SysEntityChange
+	for _, entityId := range order {
+		beforeRows, afterRows := beforeGroups[entityId], afterGroups[entityId]
+
+		var diff interface{}
+		var beforeValue, afterValue interface{}
+		if entity.ItemColumn != "" {
+			diff = diffItems(beforeRows, afterRows, entity.ItemColumn, action)
+			beforeValue, afterValue = maskRows(beforeRows), maskRows(afterRows)
+		} else {
+			var beforeRow, afterRow map[string]interface{}
+			if len(beforeRows) > 0 {
+				beforeRow = beforeRows[0]
+				beforeValue = maskRow(beforeRow)
+			}
+			if len(afterRows) > 0 {
+				afterRow = afterRows[0]
+				afterValue = maskRow(afterRow)
+			}
+			diff = diffRow(beforeRow, afterRow, primaryKey)
+		}
+		if action == "UPDATE" && isEmptyDiff(diff) {
+			continue
+		}
+
+		changes = append(changes, system.SysEntityChange{
+			EntityType: entity.EntityType,
+			EntityId:   entityId,
+			Action:     action,
+			Before:     marshalJSON(beforeValue),
+			After:      marshalJSON(afterValue),
+			Diff:       marshalJSON(diff),
+			RequestId:  requestId,
+			UserID:     userID,
+			Username:   username,
+		})
+	}
+	if len(changes) == 0 {
+		return
+	}
+
+	// 使用当前语句的连接，变更记录与业务数据在同一事务中提交或回滚
+	if err := db.Session(&gorm.Session{NewDB: true, SkipHooks: true}).Create(&changes).Error; err != nil {
+		fmt.Printf("保存实体变更记录失败: %v\n", err)
+	}
+}
+
+// GetEntityChanges 分页获取实体的变更记录，并关联对应请求的操作日志
+func (s *EntityChangeService) GetEntityChanges(req system.EntityChangeRequest) (system.EntityChangeResponse, error) {
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.PageSize <= 0 {
+		req.PageSize = 10
+	}
+
+	db := global.DB.Model(&system.SysEntityChange{}).
+		Where("entity_type = ? AND entity_id = ?", req.EntityType, req.EntityId)
+	if req.Action != "" {
+		db = db.Where("action = ?", req.Action)
+	}
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return system.EntityChangeResponse{}, err
+	}
+
+	var changes []system.SysEntityChange
+	if err := db.Order("id DESC").Offset((req.Page - 1) * req.PageSize).Limit(req.PageSize).Find(&changes).Error; err != nil {
+		return system.EntityChangeResponse{}, err
+	}
+
+	var requestIds []string
+	for _, change := range changes {
+		if change.RequestId != "" {
+			requestIds = append(requestIds, change.RequestId)
+		}
+	}
+	logs := make(map[string]system.SysOperationLog)
+	if len(requestIds) > 0 {
+		var operationLogs []system.SysOperationLog
+		if err := global.DB.Select("id", "request_id", "description").
+			Where("request_id IN ?", requestIds).Find(&operationLogs).Error; err != nil {
+			return system.EntityChangeResponse{}, err
+		}
+		for _, log := range operationLogs {
+			logs[log.RequestId] = log
+		}
+	}
+
+	list := make([]system.EntityChangeItem, 0, len(changes))
+	for _, change := range changes {
+		item := system.EntityChangeItem{SysEntityChange: change}
+		if log, ok := logs[change.RequestId]; ok {
+			item.OperationLogId = log.ID
+			item.Description = log.Description
+		}
+		list = append(list, item)
+	}
+
+	return system.EntityChangeResponse{
+		List:     list,
+		Total:    total,
+		Page:     req.Page,
+		PageSize: req.PageSize,
+	}, nil
+}
+
+// statementConditions 获取语句的WHERE条件，模型带主键时追加主键条件
+func statementConditions(stmt *gorm.Statement) []clause.Expression {
+	var conditions []clause.Expression
+	if c, ok := stmt.Clauses["WHERE"]; ok {
+		if where, ok := c.Expression.(clause.Where); ok {
+			conditions = append(conditions, where.Exprs...)
+		}
+	}
+
+	if stmt.ReflectValue.Kind() == reflect.Struct {
+		for _, field := range stmt.Schema.PrimaryFields {
+			if value, isZero := field.ValueOf(stmt.Context, stmt.ReflectValue); !isZero {
+				conditions = append(conditions, clause.Eq{Column: clause.Column{Name: field.DBName}, Value: value})
+			}
+		}
+	}
+	return conditions
+}
+
+// primaryColumn 获取主键列名
+func primaryColumn(stmt *gorm.Statement) string {
+	if stmt.Schema != nil && stmt.Schema.PrioritizedPrimaryField != nil {
+		return stmt.Schema.PrioritizedPrimaryField.DBName
+	}
+	return "id"
+}
+
+// structRow 将模型转换为以列名为键的数据
+func structRow(stmt *gorm.Statement, value reflect.Value) map[string]interface{} {
+	row := make(map[string]interface{})
+	for _, field := range stmt.Schema.Fields {
+		if field.DBName == "" {
+			continue
+		}
+		fieldValue, _ := field.ValueOf(stmt.Context, value)
+		if valuer, ok := fieldValue.(driver.Valuer); ok {
+			fieldValue, _ = valuer.Value()
+		}
+		row[field.DBName] = fieldValue
+	}
+	return row
+}
+
+// groupRows 按实体ID分组，返回分组和实体ID的出现顺序
+func groupRows(rows []map[string]interface{}, idColumn string) (map[string][]map[string]interface{}, []string) {
+	groups := make(map[string][]map[string]interface{})
+	var order []string
+	for _, row := range rows {
+		id := fmt.Sprint(row[idColumn])
+		if _, ok := groups[id]; !ok {
+			order = append(order, id)
+		}
+		groups[id] = append(groups[id], row)
+	}
+	return groups, order
+}
+
+// diffRow 比较单行数据的字段差异，before或after为nil时分别表示新增和删除
+func diffRow(before, after map[string]interface{}, primaryKey string) map[string]FieldChange {
+	redactor := OperationLogRedactor()
+	columns := make(map[string]bool)
+	for column := range before {
+		columns[column] = true
+	}
+	for column := range after {
+		columns[column] = true
+	}
+
+	diff := make(map[string]FieldChange)
+	for column := range columns {
+		if diffIgnoredColumns[column] || column == primaryKey {
+			continue
+		}
+
+		var beforeValue, afterValue interface{}
+		if before != nil {
+			beforeValue = before[column]
+		}
+		if after != nil {
+			afterValue = after[column]
+		}
+		if beforeValue == nil && afterValue == nil {
+			continue
+		}
+		if before != nil && after != nil && fmt.Sprint(beforeValue) == fmt.Sprint(afterValue) {
+			continue
+		}
+
+		if redactor.IsSensitiveField(column) {
+			beforeValue, afterValue = redactor.Mask(beforeValue, utils.RedactFull), redactor.Mask(afterValue, utils.RedactFull)
+		}
+		diff[column] = FieldChange{Before: beforeValue, After: afterValue}
+	}
+	return diff
+}
+
+// diffItems 比较关联表中一个实体的行集合，返回新增和移除的项
+func diffItems(before, after []map[string]interface{}, itemColumn, action string) map[string][]string {
+	beforeItems := itemSet(before, itemColumn)
+	afterItems := itemSet(after, itemColumn)
+	if action == "DELETE" {
+		afterItems = map[string]bool{}
+	}
+
+	diff := map[string][]string{"added": {}, "removed": {}}
+	for item := range beforeItems {
+		if !afterItems[item] {
+			diff["removed"] = append(diff["removed"], item)
+		}
+	}
+	for item := range afterItems {
+		if !beforeItems[item] {
+			diff["added"] = append(diff["added"], item)
+		}
+	}
+	sort.Strings(diff["added"])
+	sort.Strings(diff["removed"])
+	return diff
+}
+
+func itemSet(rows []map[string]interface{}, itemColumn string) map[string]bool {
+	items := make(map[string]bool)
+	for _, row := range rows {
+		// 已软删除的行视为不存在
+		if deletedAt, ok := row["deleted_at"]; ok && deletedAt != nil {
+			continue
+		}
+		items[fmt.Sprint(row[itemColumn])] = true
+	}
+	return items
+}
+
+// isEmptyDiff 判断差异是否为空
+func isEmptyDiff(diff interface{}) bool {
+	switch d := diff.(type) {
+	case map[string]FieldChange:
+		return len(d) == 0
+	case map[string][]string:
+		return len(d["added"]) == 0 && len(d["removed"]) == 0
+	}
+	return false
+}
+
+// maskRow 遮盖快照中的敏感列，如密码哈希
+func maskRow(row map[string]interface{}) map[string]interface{} {
+	redactor := OperationLogRedactor()
+	masked := make(map[string]interface{}, len(row))
+	for column, value := range row {
+		if redactor.IsSensitiveField(column) {
+			value = redactor.Mask(value, utils.RedactFull)
+		}
+		masked[column] = value
+	}
+	return masked
+}
+
+func maskRows(rows []map[string]interface{}) []map[string]interface{} {
+	masked := make([]map[string]interface{}, 0, len(rows))
+	for _, row := range rows {
+		masked = append(masked, maskRow(row))
+	}
+	return masked
+}
+
+func marshalJSON(value interface{}) string {
+	if value == nil {
+		return ""
+	}
+	bytes, err := json.Marshal(value)
+	if err != nil {
+		return ""
+	}
+	return string(bytes)
+}
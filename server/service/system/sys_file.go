@@ -0,0 +1,300 @@
+package system
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
+	"server/global"
+	"server/model/system"
+	"server/utils/upload"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// FilePrefix 文件管理的文件在存储中的目录
+const FilePrefix = "file/"
+
+// defaultFileMaxSize 未配置时单个文件的大小上限，单位MB
+const defaultFileMaxSize = 100
+
+var (
+	// ErrFileQuotaExceeded 超出角色的文件存储配额
+	ErrFileQuotaExceeded = errors.New("超出文件存储配额")
+	// ErrFileTooLarge 超出单个文件的大小上限
+	ErrFileTooLarge = errors.New("文件大小超出限制")
+)
+
+type FileService struct{}
+
+// FileMaxSize 单个文件的大小上限，单位字节
+func FileMaxSize() int64 {
+	maxSize := global.CONFIG.Oss.MaxSize
+	if maxSize <= 0 {
+		maxSize = defaultFileMaxSize
+	}
+	return maxSize << 20
+}
+
+// storageType 当前使用的存储类型
+func storageType() string {
+	if global.CONFIG.Oss.Type == "" {
+		return upload.TypeLocal
+	}
+	return global.CONFIG.Oss.Type
+}
+
+// GetFileList 分页获取文件列表
+func (s *FileService) GetFileList(req system.FileRequest) ([]system.SysFile, int64, error) {
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.PageSize <= 0 {
+		req.PageSize = 10
+	}
+
+	db := global.DB.Model(&system.SysFile{})
+	if req.Name != "" {
+		db = db.Where("name LIKE ?", "%"+req.Name+"%")
+	}
+	if req.MimeType != "" {
+		db = db.Where("mime_type LIKE ?", req.MimeType+"%")
+	}
+	if req.UploaderName != "" {
+		db = db.Where("uploader_name LIKE ?", "%"+req.UploaderName+"%")
+	}
+	if req.UploaderId != 0 {
+		db = db.Where("uploader_id = ?", req.UploaderId)
+	}
+	if req.StartTime != "" {
+		db = db.Where("created_at >= ?", req.StartTime)
+	}
+	if req.EndTime != "" {
+		db = db.Where("created_at <= ?", req.EndTime)
+	}
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var files []system.SysFile
+	if err := db.Order("id DESC").Offset((req.Page - 1) * req.PageSize).Limit(req.PageSize).Find(&files).Error; err != nil {
+		return nil, 0, err
+	}
+	return files, total, nil
+}
+
+// GetFileById 根据ID获取文件
+func (s *FileService) GetFileById(id uint) (system.SysFile, error) {
+	var file system.SysFile
+	err := global.DB.First(&file, id).Error
+	return file, err
+}
+
+// GetUserQuota 获取用户的文件存储配额，取用户所有角色中最大的配额，任一角色不限制时不限制
+func (s *FileService) GetUserQuota(userID uint) (system.FileQuota, error) {
+	var quota system.FileQuota
+	if err := global.DB.Model(&system.SysFile{}).
+		Where("uploader_id = ?", userID).
+		Select("COALESCE(SUM(size), 0)").
+		Scan(&quota.Used).Error; err != nil {
+		return quota, err
+	}
+
+	var user system.SysUser
+	if err := global.DB.First(&user, userID).Error; err != nil {
+		return quota, err
+	}
+	authorityIds, err := (&UserService{}).GetUserAuthorityIds(user)
+	if err != nil {
+		return quota, err
+	}
+	var authorities []system.SysAuthority
+	if err := global.DB.Where("authority_id IN ?", authorityIds).Find(&authorities).Error; err != nil {
+		return quota, err
+	}
+	for _, authority := range authorities {
+		if authority.FileQuota == nil || *authority.FileQuota <= 0 {
+			quota.Limit = 0
+			return quota, nil
+		}
+		if *authority.FileQuota > quota.Limit {
+			quota.Limit = *authority.FileQuota
+		}
+	}
+	return quota, nil
+}
+
+// checkQuota 检查用户再上传size字节后是否超出配额
+func (s *FileService) checkQuota(userID uint, size int64) error {
+	quota, err := s.GetUserQuota(userID)
+	if err != nil {
+		return err
+	}
+	if quota.Limit > 0 && quota.Used+size > quota.Limit {
+		return fmt.Errorf("%w: 已使用 %d 字节，配额 %d 字节", ErrFileQuotaExceeded, quota.Used, quota.Limit)
+	}
+	return nil
+}
+
+// detectMimeType 根据扩展名识别文件类型，无法识别时根据文件头识别
+func detectMimeType(ext string, head []byte) string {
+	if mimeType := mime.TypeByExtension(ext); mimeType != "" {
+		return mimeType
+	}
+	return http.DetectContentType(head)
+}
+
+// lockFileHash 锁定同一内容的文件记录，不存在时先创建锁所在的行，需在事务中调用
+// 相同内容的文件共用存储对象，上传和删除都需先加锁，避免删除最后一条记录时有新记录引用即将被删除的对象
+func lockFileHash(tx *gorm.DB, storage, hash string) error {
+	lock := system.SysFileLock{Storage: storage, Hash: hash}
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&lock).Error; err != nil {
+		return err
+	}
+	return tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("storage = ? AND hash = ?", storage, hash).First(&lock).Error
+}
+
+// createFile 保存文件记录，存储中没有相同内容的文件时调用put写入存储
+func (s *FileService) createFile(file *system.SysFile, put func(storage upload.OSS, key string) error) error {
+	file.Storage = storageType()
+
+	return global.DB.Transaction(func(tx *gorm.DB) error {
+		if err := lockFileHash(tx, file.Storage, file.Hash); err != nil {
+			return err
+		}
+
+		var existing system.SysFile
+		err := tx.Where("hash = ? AND storage = ?", file.Hash, file.Storage).First(&existing).Error
+		switch {
+		case err == nil:
+			file.Key = existing.Key
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			storage, err := upload.Storage()
+			if err != nil {
+				return err
+			}
+			file.Key = FilePrefix + file.Hash[:2] + "/" + file.Hash + file.Ext
+			if err := put(storage, file.Key); err != nil {
+				return err
+			}
+		default:
+			return err
+		}
+
+		return tx.Create(file).Error
+	})
+}
+
+// UploadFile 上传文件并记录，存储中已有相同内容的文件时只新增记录
+func (s *FileService) UploadFile(ctx context.Context, header *multipart.FileHeader, userID uint, username string) (system.SysFile, error) {
+	if header.Size > FileMaxSize() {
+		return system.SysFile{}, fmt.Errorf("%w: 单个文件不能超过%dMB", ErrFileTooLarge, FileMaxSize()>>20)
+	}
+	if err := s.checkQuota(userID, header.Size); err != nil {
+		return system.SysFile{}, err
+	}
+
+	src, err := header.Open()
+	if err != nil {
+		return system.SysFile{}, err
+	}
+	defer src.Close()
+
+	// 计算哈希并读取文件头用于识别类型
+	hasher := sha256.New()
+	head := make([]byte, 512)
+	n, err := io.ReadFull(src, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return system.SysFile{}, err
+	}
+	head = head[:n]
+	hasher.Write(head)
+	if _, err := io.Copy(hasher, src); err != nil {
+		return system.SysFile{}, err
+	}
+	if _, err := src.Seek(0, io.SeekStart); err != nil {
+		return system.SysFile{}, err
+	}
+
+	ext := strings.ToLower(filepath.Ext(header.Filename))
+	file := system.SysFile{
+		Name:         filepath.Base(header.Filename),
+		Size:         header.Size,
+		MimeType:     detectMimeType(ext, head),
+		Ext:          ext,
+		Hash:         hex.EncodeToString(hasher.Sum(nil)),
+		UploaderId:   userID,
+		UploaderName: username,
+	}
+	err = s.createFile(&file, func(storage upload.OSS, key string) error {
+		return storage.Put(ctx, key, src, header.Size, file.MimeType)
+	})
+	if err != nil {
+		return system.SysFile{}, err
+	}
+	return file, nil
+}
+
+// OpenFile 读取文件内容
+func (s *FileService) OpenFile(ctx context.Context, file system.SysFile) (io.ReadCloser, error) {
+	if file.Storage != storageType() {
+		return nil, fmt.Errorf("文件保存在 %s 存储中，当前使用 %s 存储", file.Storage, storageType())
+	}
+	storage, err := upload.Storage()
+	if err != nil {
+		return nil, err
+	}
+	return storage.Open(ctx, file.Key)
+}
+
+// DeleteFile 删除文件记录，没有其他记录引用同一存储对象时删除存储中的文件
+// 存储中的文件在持有锁时删除，删除失败时文件记录一并回滚
+func (s *FileService) DeleteFile(ctx context.Context, id uint) error {
+	var file system.SysFile
+	if err := global.DB.First(&file, id).Error; err != nil {
+		return err
+	}
+
+	return global.DB.Transaction(func(tx *gorm.DB) error {
+		if err := lockFileHash(tx, file.Storage, file.Hash); err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Delete(&file).Error; err != nil {
+			return err
+		}
+
+		var refs int64
+		if err := tx.Model(&system.SysFile{}).
+			Where("`key` = ? AND storage = ?", file.Key, file.Storage).
+			Count(&refs).Error; err != nil {
+			return err
+		}
+		if refs > 0 {
+			return nil
+		}
+		if err := tx.Delete(&system.SysFileLock{Storage: file.Storage, Hash: file.Hash}).Error; err != nil {
+			return err
+		}
+
+		if file.Storage != storageType() {
+			fmt.Printf("文件 %s 保存在 %s 存储中，当前使用 %s 存储，未删除存储中的文件\n", file.Key, file.Storage, storageType())
+			return nil
+		}
+		storage, err := upload.Storage()
+		if err != nil {
+			return err
+		}
+		return storage.Delete(ctx, file.Key)
+	})
+}
@@ -0,0 +1,456 @@
+package system
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"server/global"
+	"server/model/system"
+	"server/utils/upload"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	FileUploadUploading = "uploading"
+	FileUploadMerging   = "merging"
+	FileUploadCompleted = "completed"
+)
+
+// ChunkPrefix 分片在存储中的目录
+const ChunkPrefix = "chunk/"
+
+// 未配置时的分片上传参数
+const (
+	defaultChunkSize    = 5
+	defaultChunkMaxSize = 10240
+	defaultChunkExpire  = 24
+	maxChunkSize        = 64
+)
+
+var (
+	// ErrUploadNotFound 上传任务不存在或不属于当前用户
+	ErrUploadNotFound = errors.New("上传任务不存在")
+	// ErrChunkInvalid 分片序号、大小或校验和不正确
+	ErrChunkInvalid = errors.New("分片校验失败")
+	// ErrUploadIncomplete 还有分片未上传
+	ErrUploadIncomplete = errors.New("分片未全部上传")
+	// ErrUploadMerging 上传任务正在合并
+	ErrUploadMerging = errors.New("文件正在合并，请稍后查询")
+)
+
+// ChunkSize 分片大小，单位字节
+func ChunkSize() int64 {
+	size := global.CONFIG.Oss.Chunk.Size
+	if size <= 0 {
+		size = defaultChunkSize
+	}
+	if size > maxChunkSize {
+		size = maxChunkSize
+	}
+	return size << 20
+}
+
+// ChunkMaxSize 分片上传的文件大小上限，单位字节
+func ChunkMaxSize() int64 {
+	size := global.CONFIG.Oss.Chunk.MaxSize
+	if size <= 0 {
+		size = defaultChunkMaxSize
+	}
+	return size << 20
+}
+
+// chunkExpire 未完成的上传的过期时间
+func chunkExpire() time.Duration {
+	hours := global.CONFIG.Oss.Chunk.Expire
+	if hours <= 0 {
+		hours = defaultChunkExpire
+	}
+	return time.Duration(hours) * time.Hour
+}
+
+// chunkKey 分片在存储中的对象键
+func chunkKey(uploadId string, index int) string {
+	return ChunkPrefix + uploadId + "/" + strconv.Itoa(index)
+}
+
+// InitChunkUpload 创建分片上传任务，当前用户有同名、同大小、同哈希的未完成上传时返回该任务用于续传
+func (s *FileService) InitChunkUpload(req system.ChunkInitRequest, userID uint, username string) (system.ChunkUploadStatus, error) {
+	if req.Size > ChunkMaxSize() {
+		return system.ChunkUploadStatus{}, fmt.Errorf("%w: 单个文件不能超过%dMB", ErrFileTooLarge, ChunkMaxSize()>>20)
+	}
+	req.Name = filepath.Base(req.Name)
+	req.Hash = strings.ToLower(strings.TrimSpace(req.Hash))
+	if req.Hash != "" && !isSHA256(req.Hash) {
+		return system.ChunkUploadStatus{}, errors.New("文件哈希必须是SHA-256的十六进制字符串")
+	}
+	if err := s.checkQuota(userID, req.Size); err != nil {
+		return system.ChunkUploadStatus{}, err
+	}
+
+	chunkSize := ChunkSize()
+	var existing system.SysFileUpload
+	err := global.DB.Where("uploader_id = ? AND name = ? AND size = ? AND hash = ? AND chunk_size = ? AND storage = ? AND status = ?",
+		userID, req.Name, req.Size, req.Hash, chunkSize, storageType(), FileUploadUploading).
+		Order("id DESC").First(&existing).Error
+	if err == nil {
+		return s.uploadStatus(existing)
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return system.ChunkUploadStatus{}, err
+	}
+
+	fileUpload := system.SysFileUpload{
+		UploadId:     uuid.New().String(),
+		Name:         req.Name,
+		Size:         req.Size,
+		Hash:         req.Hash,
+		ChunkSize:    chunkSize,
+		TotalChunks:  int((req.Size + chunkSize - 1) / chunkSize),
+		Storage:      storageType(),
+		Status:       FileUploadUploading,
+		UploaderId:   userID,
+		UploaderName: username,
+	}
+	if err := global.DB.Create(&fileUpload).Error; err != nil {
+		return system.ChunkUploadStatus{}, err
+	}
+	return system.ChunkUploadStatus{SysFileUpload: fileUpload, Uploaded: []int{}}, nil
+}
+
+// GetChunkUpload 获取上传任务和已接收的分片
+func (s *FileService) GetChunkUpload(uploadId string, userID uint) (system.ChunkUploadStatus, error) {
+	fileUpload, err := s.findUpload(uploadId, userID)
+	if err != nil {
+		return system.ChunkUploadStatus{}, err
+	}
+	return s.uploadStatus(fileUpload)
+}
+
+// UploadChunk 保存一个分片，checksum为分片内容的SHA-256，重复上传同一分片时覆盖
+func (s *FileService) UploadChunk(ctx context.Context, uploadId string, userID uint, index int, checksum string, reader io.Reader) error {
+	fileUpload, err := s.findUpload(uploadId, userID)
+	if err != nil {
+		return err
+	}
+	switch fileUpload.Status {
+	case FileUploadMerging:
+		return ErrUploadMerging
+	case FileUploadCompleted:
+		return fmt.Errorf("%w: 上传已完成", ErrChunkInvalid)
+	}
+	if index < 0 || index >= fileUpload.TotalChunks {
+		return fmt.Errorf("%w: 分片序号应在 0-%d 之间", ErrChunkInvalid, fileUpload.TotalChunks-1)
+	}
+
+	expected := fileUpload.ChunkSize
+	if index == fileUpload.TotalChunks-1 {
+		expected = fileUpload.Size - fileUpload.ChunkSize*int64(fileUpload.TotalChunks-1)
+	}
+	data, err := io.ReadAll(io.LimitReader(reader, expected+1))
+	if err != nil {
+		return err
+	}
+	if int64(len(data)) != expected {
+		return fmt.Errorf("%w: 分片 %d 应为 %d 字节，实际 %d 字节", ErrChunkInvalid, index, expected, len(data))
+	}
+	sum := sha256.Sum256(data)
+	actual := hex.EncodeToString(sum[:])
+	if !strings.EqualFold(actual, strings.TrimSpace(checksum)) {
+		return fmt.Errorf("%w: 分片 %d 的校验和不匹配", ErrChunkInvalid, index)
+	}
+
+	storage, err := upload.Storage()
+	if err != nil {
+		return err
+	}
+	if err := storage.Put(ctx, chunkKey(uploadId, index), bytes.NewReader(data), expected, "application/octet-stream"); err != nil {
+		return err
+	}
+
+	chunk := system.SysFileChunk{UploadId: uploadId, ChunkIndex: index, Size: expected, Checksum: actual}
+	if err := global.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "upload_id"}, {Name: "chunk_index"}},
+		DoUpdates: clause.AssignmentColumns([]string{"size", "checksum", "created_at"}),
+	}).Create(&chunk).Error; err != nil {
+		return err
+	}
+	// 记录活动时间，清理任务据此判断上传是否已中断
+	return global.DB.Model(&fileUpload).Update("updated_at", time.Now()).Error
+}
+
+// CompleteChunkUpload 校验并合并分片为文件，已完成的上传直接返回合并后的文件，便于客户端重试
+func (s *FileService) CompleteChunkUpload(ctx context.Context, uploadId string, userID uint) (system.SysFile, error) {
+	fileUpload, err := s.findUpload(uploadId, userID)
+	if err != nil {
+		return system.SysFile{}, err
+	}
+	switch fileUpload.Status {
+	case FileUploadCompleted:
+		return s.GetFileById(fileUpload.FileId)
+	case FileUploadMerging:
+		return system.SysFile{}, ErrUploadMerging
+	}
+
+	var chunks []system.SysFileChunk
+	if err := global.DB.Where("upload_id = ?", uploadId).Order("chunk_index").Find(&chunks).Error; err != nil {
+		return system.SysFile{}, err
+	}
+	if len(chunks) != fileUpload.TotalChunks {
+		return system.SysFile{}, fmt.Errorf("%w: 已上传 %d/%d 个分片", ErrUploadIncomplete, len(chunks), fileUpload.TotalChunks)
+	}
+
+	// 标记为合并中，避免并发的完成请求重复合并
+	result := global.DB.Model(&system.SysFileUpload{}).
+		Where("id = ? AND status = ?", fileUpload.ID, FileUploadUploading).
+		Update("status", FileUploadMerging)
+	if result.Error != nil {
+		return system.SysFile{}, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return system.SysFile{}, ErrUploadMerging
+	}
+
+	// 客户端断开时继续合并，之后重试完成请求即可拿到结果
+	file, err := s.mergeChunks(context.WithoutCancel(ctx), fileUpload)
+	if err != nil {
+		if resetErr := global.DB.Model(&system.SysFileUpload{}).Where("id = ?", fileUpload.ID).
+			Update("status", FileUploadUploading).Error; resetErr != nil {
+			fmt.Printf("恢复上传任务 %s 状态失败: %v\n", uploadId, resetErr)
+		}
+		return system.SysFile{}, err
+	}
+
+	if err := global.DB.Model(&system.SysFileUpload{}).Where("id = ?", fileUpload.ID).
+		Updates(map[string]interface{}{"status": FileUploadCompleted, "file_id": file.ID}).Error; err != nil {
+		return system.SysFile{}, err
+	}
+	if err := s.removeChunks(ctx, uploadId); err != nil {
+		fmt.Printf("删除上传任务 %s 的分片失败: %v\n", uploadId, err)
+	}
+	return file, nil
+}
+
+// mergeChunks 先读取一遍分片计算文件哈希，存储中没有相同内容时再按顺序写入存储
+func (s *FileService) mergeChunks(ctx context.Context, fileUpload system.SysFileUpload) (system.SysFile, error) {
+	if err := s.checkQuota(fileUpload.UploaderId, fileUpload.Size); err != nil {
+		return system.SysFile{}, err
+	}
+	storage, err := upload.Storage()
+	if err != nil {
+		return system.SysFile{}, err
+	}
+
+	reader := newChunkReader(ctx, storage, fileUpload)
+	hasher := sha256.New()
+	head := make([]byte, 512)
+	n, err := io.ReadFull(reader, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		reader.Close()
+		return system.SysFile{}, err
+	}
+	head = head[:n]
+	hasher.Write(head)
+	_, err = io.Copy(hasher, reader)
+	reader.Close()
+	if err != nil {
+		return system.SysFile{}, err
+	}
+
+	hash := hex.EncodeToString(hasher.Sum(nil))
+	if fileUpload.Hash != "" && fileUpload.Hash != hash {
+		return system.SysFile{}, fmt.Errorf("%w: 合并后的文件哈希与声明的不一致", ErrChunkInvalid)
+	}
+
+	ext := strings.ToLower(filepath.Ext(fileUpload.Name))
+	file := system.SysFile{
+		Name:         fileUpload.Name,
+		Size:         fileUpload.Size,
+		MimeType:     detectMimeType(ext, head),
+		Ext:          ext,
+		Hash:         hash,
+		UploaderId:   fileUpload.UploaderId,
+		UploaderName: fileUpload.UploaderName,
+	}
+	err = s.createFile(&file, func(storage upload.OSS, key string) error {
+		reader := newChunkReader(ctx, storage, fileUpload)
+		defer reader.Close()
+		return storage.Put(ctx, key, reader, fileUpload.Size, file.MimeType)
+	})
+	if err != nil {
+		return system.SysFile{}, err
+	}
+	return file, nil
+}
+
+// AbortChunkUpload 取消上传并删除已上传的分片
+func (s *FileService) AbortChunkUpload(ctx context.Context, uploadId string, userID uint) error {
+	fileUpload, err := s.findUpload(uploadId, userID)
+	if err != nil {
+		return err
+	}
+	if fileUpload.Status == FileUploadMerging {
+		return ErrUploadMerging
+	}
+	return s.removeUpload(ctx, fileUpload)
+}
+
+// ChunkCleanupReport 分片清理结果
+type ChunkCleanupReport struct {
+	Expired int `json:"expired"` // 清理的未完成上传
+	Purged  int `json:"purged"`  // 清理的已完成上传记录
+}
+
+// CleanupChunkUploads 清理超过过期时间没有新分片的未完成上传，以及过期的已完成上传记录
+func (s *FileService) CleanupChunkUploads(ctx context.Context, expire time.Duration) (ChunkCleanupReport, error) {
+	var report ChunkCleanupReport
+	if expire <= 0 {
+		expire = chunkExpire()
+	}
+	before := time.Now().Add(-expire)
+
+	var uploads []system.SysFileUpload
+	if err := global.DB.Where("updated_at < ?", before).Order("id").Find(&uploads).Error; err != nil {
+		return report, err
+	}
+	for _, fileUpload := range uploads {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+		// 已完成的上传分片在合并后已删除，这里只删除记录
+		if fileUpload.Status == FileUploadCompleted {
+			if err := global.DB.Unscoped().Delete(&fileUpload).Error; err != nil {
+				return report, err
+			}
+			report.Purged++
+			continue
+		}
+		if err := s.removeUpload(ctx, fileUpload); err != nil {
+			return report, err
+		}
+		report.Expired++
+	}
+	return report, nil
+}
+
+// findUpload 获取当前用户的上传任务
+func (s *FileService) findUpload(uploadId string, userID uint) (system.SysFileUpload, error) {
+	var fileUpload system.SysFileUpload
+	err := global.DB.Where("upload_id = ? AND uploader_id = ?", uploadId, userID).First(&fileUpload).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return fileUpload, ErrUploadNotFound
+	}
+	return fileUpload, err
+}
+
+// uploadStatus 查询已接收的分片
+func (s *FileService) uploadStatus(fileUpload system.SysFileUpload) (system.ChunkUploadStatus, error) {
+	status := system.ChunkUploadStatus{SysFileUpload: fileUpload, Uploaded: []int{}}
+	err := global.DB.Model(&system.SysFileChunk{}).
+		Where("upload_id = ?", fileUpload.UploadId).
+		Order("chunk_index").
+		Pluck("chunk_index", &status.Uploaded).Error
+	return status, err
+}
+
+// removeChunks 删除存储中的分片和分片记录
+func (s *FileService) removeChunks(ctx context.Context, uploadId string) error {
+	var indexes []int
+	if err := global.DB.Model(&system.SysFileChunk{}).Where("upload_id = ?", uploadId).
+		Pluck("chunk_index", &indexes).Error; err != nil {
+		return err
+	}
+	if len(indexes) > 0 {
+		storage, err := upload.Storage()
+		if err != nil {
+			return err
+		}
+		for _, index := range indexes {
+			if err := storage.Delete(ctx, chunkKey(uploadId, index)); err != nil {
+				return err
+			}
+		}
+	}
+	return global.DB.Where("upload_id = ?", uploadId).Delete(&system.SysFileChunk{}).Error
+}
+
+// removeUpload 删除上传任务及其分片
+func (s *FileService) removeUpload(ctx context.Context, fileUpload system.SysFileUpload) error {
+	if fileUpload.Storage == storageType() {
+		if err := s.removeChunks(ctx, fileUpload.UploadId); err != nil {
+			return err
+		}
+	} else if err := global.DB.Where("upload_id = ?", fileUpload.UploadId).Delete(&system.SysFileChunk{}).Error; err != nil {
+		return err
+	}
+	return global.DB.Unscoped().Delete(&fileUpload).Error
+}
+
+// chunkReader 按顺序读取上传任务的全部分片
+type chunkReader struct {
+	ctx      context.Context
+	storage  upload.OSS
+	uploadId string
+	total    int
+	next     int
+	current  io.ReadCloser
+}
+
+func newChunkReader(ctx context.Context, storage upload.OSS, fileUpload system.SysFileUpload) *chunkReader {
+	return &chunkReader{ctx: ctx, storage: storage, uploadId: fileUpload.UploadId, total: fileUpload.TotalChunks}
+}
+
+func (r *chunkReader) Read(p []byte) (int, error) {
+	for {
+		if r.current == nil {
+			if r.next >= r.total {
+				return 0, io.EOF
+			}
+			current, err := r.storage.Open(r.ctx, chunkKey(r.uploadId, r.next))
+			if err != nil {
+				return 0, fmt.Errorf("读取分片 %d 失败: %w", r.next, err)
+			}
+			r.current = current
+			r.next++
+		}
+
+		n, err := r.current.Read(p)
+		if errors.Is(err, io.EOF) {
+			r.current.Close()
+			r.current = nil
+			if n > 0 {
+				return n, nil
+			}
+			continue
+		}
+		return n, err
+	}
+}
+
+func (r *chunkReader) Close() error {
+	if r.current == nil {
+		return nil
+	}
+	err := r.current.Close()
+	r.current = nil
+	return err
+}
+
+// isSHA256 是否为SHA-256的十六进制字符串
+func isSHA256(value string) bool {
+	if len(value) != sha256.Size*2 {
+		return false
+	}
+	_, err := hex.DecodeString(value)
+	return err == nil
+}
@@ -0,0 +1,193 @@
+package system
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"server/global"
+	"server/utils/upload"
+	"sync"
+	"time"
+
+	"gorm.io/gorm/schema"
+)
+
+const (
+	HealthStatusUp       = "up"
+	HealthStatusDown     = "down"
+	HealthStatusDisabled = "disabled"
+)
+
+// healthCheckTimeout 单项检查的超时时间
+const healthCheckTimeout = 2 * time.Second
+
+// HealthCheck 单项检查结果
+type HealthCheck struct {
+	Name       string `json:"name"`
+	Status     string `json:"status"`
+	DurationMs int64  `json:"durationMs"`
+	Error      string `json:"error,omitempty"`
+}
+
+// HealthReport 就绪检查结果，任一检查为 down 时整体为 down
+type HealthReport struct {
+	Status    string        `json:"status"`
+	Timestamp time.Time     `json:"timestamp"`
+	Checks    []HealthCheck `json:"checks"`
+}
+
+var (
+	migratedMu     sync.RWMutex
+	migratedTables []interface{}
+	migrated       bool
+	tablesVerified bool // 表已确认全部存在，之后的就绪检查不再逐表查询
+)
+
+// MarkTablesMigrated 数据库表迁移完成后调用，就绪检查据此确认迁移已执行且表仍然存在
+func MarkTablesMigrated(tables []interface{}) {
+	migratedMu.Lock()
+	defer migratedMu.Unlock()
+	migratedTables = tables
+	migrated = true
+	tablesVerified = false
+}
+
+type HealthService struct{}
+
+// CheckReadiness 并发执行各项就绪检查
+func (s *HealthService) CheckReadiness(ctx context.Context) HealthReport {
+	checks := []struct {
+		name string
+		run  func(ctx context.Context) (string, error)
+	}{
+		{"database", s.checkDatabase},
+		{"redis", s.checkRedis},
+		{"migrations", s.checkMigrations},
+		{"storage", s.checkStorage},
+	}
+
+	report := HealthReport{
+		Status:    HealthStatusUp,
+		Timestamp: time.Now(),
+		Checks:    make([]HealthCheck, len(checks)),
+	}
+
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func(i int, name string, run func(ctx context.Context) (string, error)) {
+			defer wg.Done()
+			checkCtx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+			defer cancel()
+
+			start := time.Now()
+			status, err := run(checkCtx)
+			result := HealthCheck{
+				Name:       name,
+				Status:     status,
+				DurationMs: time.Since(start).Milliseconds(),
+			}
+			if err != nil {
+				result.Status = HealthStatusDown
+				result.Error = err.Error()
+			}
+			report.Checks[i] = result
+		}(i, check.name, check.run)
+	}
+	wg.Wait()
+
+	for _, check := range report.Checks {
+		if check.Status == HealthStatusDown {
+			report.Status = HealthStatusDown
+			break
+		}
+	}
+	return report
+}
+
+// checkDatabase 检查数据库连接
+func (s *HealthService) checkDatabase(ctx context.Context) (string, error) {
+	if global.DB == nil {
+		return HealthStatusDown, errors.New("数据库未连接")
+	}
+	db, err := global.DB.DB()
+	if err != nil {
+		return HealthStatusDown, err
+	}
+	if err := db.PingContext(ctx); err != nil {
+		return HealthStatusDown, err
+	}
+	return HealthStatusUp, nil
+}
+
+// checkRedis 检查Redis连接，未启用Redis时跳过
+func (s *HealthService) checkRedis(ctx context.Context) (string, error) {
+	if !global.CONFIG.System.UseRedis {
+		return HealthStatusDisabled, nil
+	}
+	if global.REDIS == nil {
+		return HealthStatusDown, errors.New("Redis未连接")
+	}
+	if err := global.REDIS.Ping(ctx).Err(); err != nil {
+		return HealthStatusDown, err
+	}
+	return HealthStatusUp, nil
+}
+
+// checkMigrations 检查数据库表迁移已执行，且所有表都存在；首次检查通过后缓存结果
+func (s *HealthService) checkMigrations(ctx context.Context) (string, error) {
+	migratedMu.RLock()
+	tables, done, verified := migratedTables, migrated, tablesVerified
+	migratedMu.RUnlock()
+
+	if !done {
+		return HealthStatusDown, errors.New("数据库表迁移未完成")
+	}
+	if verified {
+		return HealthStatusUp, nil
+	}
+	if global.DB == nil {
+		return HealthStatusDown, errors.New("数据库未连接")
+	}
+
+	migrator := global.DB.WithContext(ctx).Migrator()
+	var missing []string
+	for _, table := range tables {
+		if !migrator.HasTable(table) {
+			missing = append(missing, tableName(table))
+		}
+	}
+	if len(missing) > 0 {
+		return HealthStatusDown, fmt.Errorf("缺少数据库表: %v", missing)
+	}
+
+	migratedMu.Lock()
+	tablesVerified = migrated
+	migratedMu.Unlock()
+	return HealthStatusUp, nil
+}
+
+// checkStorage 检查文件存储可用，本地存储检查目录可写，对象存储检查存储桶可访问
+func (s *HealthService) checkStorage(ctx context.Context) (string, error) {
+	storage, err := upload.Storage()
+	if err != nil {
+		return HealthStatusDown, err
+	}
+	if err := storage.Check(ctx); err != nil {
+		return HealthStatusDown, err
+	}
+	return HealthStatusUp, nil
+}
+
+// tableName 获取模型对应的表名
+func tableName(model interface{}) string {
+	if tabler, ok := model.(schema.Tabler); ok {
+		return tabler.TableName()
+	}
+	if global.DB != nil {
+		if parsed, err := schema.Parse(model, &sync.Map{}, global.DB.NamingStrategy); err == nil {
+			return parsed.Table
+		}
+	}
+	return fmt.Sprintf("%T", model)
+}
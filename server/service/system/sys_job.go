@@ -0,0 +1,234 @@
+package system
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"server/global"
+	"server/model/system"
+	"strings"
+	"time"
+)
+
+type JobService struct{}
+
+// GetJobList 分页获取定时任务，并附带下次执行时间
+func (s *JobService) GetJobList(req system.JobRequest) ([]system.SysJob, int64, error) {
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.PageSize <= 0 {
+		req.PageSize = 10
+	}
+
+	db := global.DB.Model(&system.SysJob{})
+	if req.Name != "" {
+		db = db.Where("name LIKE ?", "%"+req.Name+"%")
+	}
+	if req.JobType != "" {
+		db = db.Where("job_type = ?", req.JobType)
+	}
+	if req.Enabled != nil {
+		db = db.Where("enabled = ?", *req.Enabled)
+	}
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var jobs []system.SysJob
+	if err := db.Order("id ASC").Offset((req.Page - 1) * req.PageSize).Limit(req.PageSize).Find(&jobs).Error; err != nil {
+		return nil, 0, err
+	}
+	for i := range jobs {
+		jobs[i].NextRunAt = nextJobRunAt(jobs[i].ID)
+	}
+	return jobs, total, nil
+}
+
+// GetJobById 根据ID获取定时任务
+func (s *JobService) GetJobById(id uint) (system.SysJob, error) {
+	var job system.SysJob
+	if err := global.DB.First(&job, id).Error; err != nil {
+		return job, err
+	}
+	job.NextRunAt = nextJobRunAt(job.ID)
+	return job, nil
+}
+
+// CreateJob 创建定时任务，启用时立即加入调度
+func (s *JobService) CreateJob(job *system.SysJob) error {
+	if err := validateJob(job); err != nil {
+		return err
+	}
+
+	var count int64
+	if err := global.DB.Model(&system.SysJob{}).Where("name = ?", job.Name).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return errors.New("任务名称已存在")
+	}
+
+	if err := global.DB.Create(job).Error; err != nil {
+		return err
+	}
+	if err := scheduleJob(*job); err != nil {
+		return err
+	}
+	job.NextRunAt = nextJobRunAt(job.ID)
+	return nil
+}
+
+// UpdateJob 更新定时任务并重新调度
+func (s *JobService) UpdateJob(id uint, data system.SysJob) (system.SysJob, error) {
+	var job system.SysJob
+	if err := global.DB.First(&job, id).Error; err != nil {
+		return job, err
+	}
+	if err := validateJob(&data); err != nil {
+		return job, err
+	}
+
+	var count int64
+	if err := global.DB.Model(&system.SysJob{}).Where("name = ? AND id <> ?", data.Name, id).Count(&count).Error; err != nil {
+		return job, err
+	}
+	if count > 0 {
+		return job, errors.New("任务名称已存在")
+	}
+
+	if err := global.DB.Model(&job).Select("name", "job_type", "cron", "params", "enabled", "remark").Updates(data).Error; err != nil {
+		return job, err
+	}
+	if err := scheduleJob(job); err != nil {
+		return job, err
+	}
+	job.NextRunAt = nextJobRunAt(job.ID)
+	return job, nil
+}
+
+// DeleteJob 删除定时任务并移除调度，执行记录保留
+func (s *JobService) DeleteJob(id uint) error {
+	result := global.DB.Delete(&system.SysJob{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("任务不存在")
+	}
+	unscheduleJob(id)
+	return nil
+}
+
+// SetJobEnabled 暂停或恢复定时任务
+func (s *JobService) SetJobEnabled(id uint, enabled bool) (system.SysJob, error) {
+	var job system.SysJob
+	if err := global.DB.First(&job, id).Error; err != nil {
+		return job, err
+	}
+	if err := global.DB.Model(&job).Update("enabled", enabled).Error; err != nil {
+		return job, err
+	}
+	if err := scheduleJob(job); err != nil {
+		return job, err
+	}
+	job.NextRunAt = nextJobRunAt(job.ID)
+	return job, nil
+}
+
+// RunJob 立即执行一次定时任务（不受暂停状态影响），异步执行并返回执行记录
+func (s *JobService) RunJob(id uint, operator string) (system.SysJobLog, error) {
+	var job system.SysJob
+	if err := global.DB.First(&job, id).Error; err != nil {
+		return system.SysJobLog{}, err
+	}
+
+	run, err := startJobRun(job, JobTriggerManual, operator, time.Time{})
+	if err != nil {
+		return system.SysJobLog{}, err
+	}
+
+	if scheduler != nil {
+		scheduler.manual.Add(1)
+	}
+	go func() {
+		if scheduler != nil {
+			defer scheduler.manual.Done()
+		}
+		run.execute()
+	}()
+	return run.log, nil
+}
+
+// GetJobLogs 分页获取任务执行记录
+func (s *JobService) GetJobLogs(req system.JobLogRequest) ([]system.SysJobLog, int64, error) {
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.PageSize <= 0 {
+		req.PageSize = 10
+	}
+
+	db := global.DB.Model(&system.SysJobLog{})
+	if req.JobId != 0 {
+		db = db.Where("job_id = ?", req.JobId)
+	}
+	if req.Status != "" {
+		db = db.Where("status = ?", req.Status)
+	}
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var logs []system.SysJobLog
+	if err := db.Order("id DESC").Offset((req.Page - 1) * req.PageSize).Limit(req.PageSize).Find(&logs).Error; err != nil {
+		return nil, 0, err
+	}
+	return logs, total, nil
+}
+
+// EnsureJob 按任务类型创建内置任务，已存在同类型任务时不做修改，避免覆盖管理员的调整
+func (s *JobService) EnsureJob(job system.SysJob) error {
+	var count int64
+	if err := global.DB.Model(&system.SysJob{}).Where("job_type = ?", job.JobType).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+	if err := validateJob(&job); err != nil {
+		return err
+	}
+	return global.DB.Create(&job).Error
+}
+
+// validateJob 校验任务名称、类型、cron表达式和参数
+func validateJob(job *system.SysJob) error {
+	job.Name = strings.TrimSpace(job.Name)
+	job.Cron = strings.TrimSpace(job.Cron)
+	job.Params = strings.TrimSpace(job.Params)
+
+	if job.Name == "" {
+		return errors.New("任务名称不能为空")
+	}
+	jobType, ok := getJobType(job.JobType)
+	if !ok {
+		return fmt.Errorf("任务类型 %s 未注册", job.JobType)
+	}
+	if _, err := ParseCron(job.Cron); err != nil {
+		return fmt.Errorf("cron表达式无效: %v", err)
+	}
+	if job.Params != "" && !json.Valid([]byte(job.Params)) {
+		return errors.New("任务参数必须是JSON")
+	}
+	if jobType.ValidateParams != nil {
+		if err := jobType.ValidateParams(job.Params); err != nil {
+			return fmt.Errorf("任务参数无效: %v", err)
+		}
+	}
+	return nil
+}
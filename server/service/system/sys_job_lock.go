@@ -0,0 +1,114 @@
+package system
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"server/global"
+	"server/model/system"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm/clause"
+)
+
+// jobInstance 当前实例标识，写入执行记录便于排查是哪台机器执行的任务
+var jobInstance = func() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+	return fmt.Sprintf("%s-%d", hostname, os.Getpid())
+}()
+
+// 释放和续期时校验持有者，避免误删其他实例的锁
+var (
+	redisReleaseScript = `if redis.call("get", KEYS[1]) == ARGV[1] then return redis.call("del", KEYS[1]) else return 0 end`
+	redisRefreshScript = `if redis.call("get", KEYS[1]) == ARGV[1] then return redis.call("pexpire", KEYS[1], ARGV[2]) else return 0 end`
+)
+
+// jobLock 定时任务执行锁
+// 启用Redis时使用Redis锁，否则使用数据库 sys_job_locks 表，保证多实例部署时同一任务不会重复执行
+type jobLock struct {
+	name  string
+	owner string
+	ttl   time.Duration
+	redis bool // 获取锁时是否使用Redis，续期和释放使用同一种方式
+}
+
+// acquireJobLock 获取执行锁，锁被其他执行持有时返回nil
+func acquireJobLock(name string, ttl time.Duration) (*jobLock, error) {
+	lock := &jobLock{
+		name:  name,
+		owner: jobInstance + "-" + uuid.New().String(),
+		ttl:   ttl,
+		redis: global.REDIS != nil,
+	}
+
+	if lock.redis {
+		ok, err := global.REDIS.SetNX(context.Background(), lock.redisKey(), lock.owner, ttl).Result()
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, nil
+		}
+		return lock, nil
+	}
+
+	now := time.Now()
+	// 按计划执行时间加的锁不会被同名的锁抢占，过期后在这里清理
+	if err := global.DB.Where("expires_at < ?", now.Add(-ttl)).Delete(&system.SysJobLock{}).Error; err != nil {
+		return nil, err
+	}
+	result := global.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&system.SysJobLock{
+		Name:      name,
+		Owner:     lock.owner,
+		ExpiresAt: now.Add(ttl),
+	})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 1 {
+		return lock, nil
+	}
+
+	// 锁已存在，只有过期时才能抢占
+	result = global.DB.Model(&system.SysJobLock{}).
+		Where("name = ? AND expires_at < ?", name, now).
+		Updates(map[string]interface{}{"owner": lock.owner, "expires_at": now.Add(ttl)})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, nil
+	}
+	return lock, nil
+}
+
+// refresh 延长锁的过期时间，任务执行时间超过锁有效期时定期调用
+func (l *jobLock) refresh() error {
+	if l.redis {
+		return global.REDIS.Eval(context.Background(), redisRefreshScript, []string{l.redisKey()}, l.owner, l.ttl.Milliseconds()).Err()
+	}
+	return global.DB.Model(&system.SysJobLock{}).
+		Where("name = ? AND owner = ?", l.name, l.owner).
+		Update("expires_at", time.Now().Add(l.ttl)).Error
+}
+
+// release 释放锁
+func (l *jobLock) release() {
+	var err error
+	if l.redis {
+		err = global.REDIS.Eval(context.Background(), redisReleaseScript, []string{l.redisKey()}, l.owner).Err()
+	} else {
+		err = global.DB.Where("name = ? AND owner = ?", l.name, l.owner).Delete(&system.SysJobLock{}).Error
+	}
+	if err != nil {
+		fmt.Printf("释放任务锁 %s 失败: %v\n", l.name, err)
+	}
+}
+
+func (l *jobLock) redisKey() string {
+	return "job:lock:" + l.name
+}
@@ -0,0 +1,354 @@
+package system
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"server/global"
+	"server/model/system"
+	"server/utils"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+const (
+	JobTriggerSchedule = "schedule"
+	JobTriggerManual   = "manual"
+
+	JobStatusRunning = "running"
+	JobStatusSuccess = "success"
+	JobStatusFailed  = "failed"
+)
+
+// jobLockTTL 任务锁有效期，执行期间按 jobLockTTL/3 的间隔续期
+const jobLockTTL = 5 * time.Minute
+
+// ErrJobRunning 任务正在其他实例或其他触发中执行
+var ErrJobRunning = errors.New("任务正在执行中")
+
+// errJobFired 本次调度已由其他实例执行
+var errJobFired = errors.New("本次调度已由其他实例执行")
+
+// JobFunc 任务执行函数，params为任务配置的JSON参数，返回的结果写入执行记录
+type JobFunc func(ctx context.Context, params string) (string, error)
+
+// JobParamsValidator 校验任务参数，创建、更新和执行任务时调用
+type JobParamsValidator func(params string) error
+
+// JobType 任务类型，由代码注册，任务定义只能选择已注册的类型
+type JobType struct {
+	Name           string             `json:"name"`
+	Title          string             `json:"title"`
+	Description    string             `json:"description"`
+	DefaultParams  string             `json:"defaultParams"`
+	Run            JobFunc            `json:"-"`
+	ValidateParams JobParamsValidator `json:"-"`
+}
+
+var (
+	jobTypesMu sync.RWMutex
+	jobTypes   = map[string]JobType{}
+)
+
+// cronParser 支持5位标准表达式、可选的秒字段以及 @every 1h 等描述符
+var cronParser = cron.NewParser(cron.SecondOptional | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
+
+// RegisterJobType 注册任务类型，通常在包的 init 中调用
+func RegisterJobType(jobType JobType) {
+	jobTypesMu.Lock()
+	defer jobTypesMu.Unlock()
+	jobTypes[jobType.Name] = jobType
+}
+
+// GetJobTypes 获取已注册的任务类型
+func GetJobTypes() []JobType {
+	jobTypesMu.RLock()
+	defer jobTypesMu.RUnlock()
+
+	list := make([]JobType, 0, len(jobTypes))
+	for _, jobType := range jobTypes {
+		list = append(list, jobType)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Name < list[j].Name
+	})
+	return list
+}
+
+func getJobType(name string) (JobType, bool) {
+	jobTypesMu.RLock()
+	defer jobTypesMu.RUnlock()
+	jobType, ok := jobTypes[name]
+	return jobType, ok
+}
+
+// ParseCron 校验cron表达式
+func ParseCron(spec string) (cron.Schedule, error) {
+	return cronParser.Parse(spec)
+}
+
+// jobScheduler 进程内的任务调度器
+type jobScheduler struct {
+	cron    *cron.Cron
+	mu      sync.Mutex
+	entries map[uint]cron.EntryID
+	manual  sync.WaitGroup // 手动触发的执行
+}
+
+var scheduler *jobScheduler
+
+// StartJobScheduler 加载已启用的任务并启动调度器，需在数据库表初始化后调用
+func StartJobScheduler() error {
+	scheduler = &jobScheduler{
+		cron:    cron.New(cron.WithParser(cronParser)),
+		entries: make(map[uint]cron.EntryID),
+	}
+	global.TIMER.Store("jobScheduler", scheduler.cron)
+
+	var jobs []system.SysJob
+	if err := global.DB.Where("enabled = ?", true).Find(&jobs).Error; err != nil {
+		return err
+	}
+	for _, job := range jobs {
+		if err := scheduleJob(job); err != nil {
+			fmt.Printf("加载定时任务 %s 失败: %v\n", job.Name, err)
+		}
+	}
+
+	scheduler.cron.Start()
+	fmt.Printf("定时任务调度器已启动，共加载 %d 个任务\n", len(scheduler.entries))
+	return nil
+}
+
+// StopJobScheduler 停止调度并等待正在执行的任务结束
+func StopJobScheduler(ctx context.Context) error {
+	if scheduler == nil {
+		return nil
+	}
+
+	done := make(chan struct{})
+	go func() {
+		<-scheduler.cron.Stop().Done()
+		scheduler.manual.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// scheduleJob 按任务定义重新登记调度，未启用的任务只移除
+func scheduleJob(job system.SysJob) error {
+	if scheduler == nil {
+		return nil
+	}
+
+	scheduler.mu.Lock()
+	defer scheduler.mu.Unlock()
+
+	if entryId, ok := scheduler.entries[job.ID]; ok {
+		scheduler.cron.Remove(entryId)
+		delete(scheduler.entries, job.ID)
+	}
+	if !job.Enabled {
+		return nil
+	}
+
+	schedule, err := ParseCron(job.Cron)
+	if err != nil {
+		return err
+	}
+
+	jobId := job.ID
+	entryId := scheduler.cron.Schedule(schedule, cron.FuncJob(func() {
+		firedAt := scheduledFireTime(schedule, time.Now())
+
+		// 每次执行时重新读取任务定义，参数修改后无需重新调度
+		var current system.SysJob
+		if err := global.DB.First(&current, jobId).Error; err != nil {
+			fmt.Printf("读取定时任务 %d 失败: %v\n", jobId, err)
+			return
+		}
+		run, err := startJobRun(current, JobTriggerSchedule, "system", firedAt)
+		if err != nil {
+			if errors.Is(err, ErrJobRunning) || errors.Is(err, errJobFired) {
+				utils.JobRunsTotal.WithLabelValues(current.JobType, JobTriggerSchedule, "skipped").Inc()
+			} else {
+				fmt.Printf("执行定时任务 %s 失败: %v\n", current.Name, err)
+			}
+			return
+		}
+		run.execute()
+	}))
+	scheduler.entries[job.ID] = entryId
+	return nil
+}
+
+// scheduledFireTime 计算本次触发对应的计划执行时间，即不晚于now的最近一次调度时间
+// 各实例按自己的时钟触发，计划执行时间相同，用于识别同一次调度
+func scheduledFireTime(schedule cron.Schedule, now time.Time) time.Time {
+	// @every 的调度时间取决于各实例的启动时间，按间隔对齐
+	if delay, ok := schedule.(cron.ConstantDelaySchedule); ok {
+		return now.Truncate(delay.Delay)
+	}
+
+	firedAt := now.Truncate(time.Second)
+	for next := schedule.Next(now.Add(-jobLockTTL)); !next.After(now); next = schedule.Next(next) {
+		firedAt = next
+	}
+	return firedAt
+}
+
+// unscheduleJob 移除任务调度
+func unscheduleJob(jobId uint) {
+	if scheduler == nil {
+		return
+	}
+	scheduler.mu.Lock()
+	defer scheduler.mu.Unlock()
+	if entryId, ok := scheduler.entries[jobId]; ok {
+		scheduler.cron.Remove(entryId)
+		delete(scheduler.entries, jobId)
+	}
+}
+
+// nextJobRunAt 获取任务下次执行时间，未调度时返回nil
+func nextJobRunAt(jobId uint) *time.Time {
+	if scheduler == nil {
+		return nil
+	}
+	scheduler.mu.Lock()
+	entryId, ok := scheduler.entries[jobId]
+	scheduler.mu.Unlock()
+	if !ok {
+		return nil
+	}
+
+	next := scheduler.cron.Entry(entryId).Next
+	if next.IsZero() {
+		return nil
+	}
+	return &next
+}
+
+// jobRun 一次任务执行，持有执行锁
+type jobRun struct {
+	job     system.SysJob
+	jobType JobType
+	lock    *jobLock
+	log     system.SysJobLog
+}
+
+// startJobRun 获取执行锁并创建执行记录，任务正在执行时返回 ErrJobRunning
+// firedAt 为定时触发的计划执行时间，手动触发时为零值
+func startJobRun(job system.SysJob, trigger, operator string, firedAt time.Time) (*jobRun, error) {
+	jobType, ok := getJobType(job.JobType)
+	if !ok {
+		return nil, fmt.Errorf("任务类型 %s 未注册", job.JobType)
+	}
+
+	// 定时触发按任务ID和计划执行时间加锁，锁不随执行结束释放，只在有效期后过期；
+	// 实例之间存在时钟偏差时，先执行完的实例释放了执行锁，其他实例对同一次调度也不会再执行
+	if !firedAt.IsZero() {
+		fired, err := acquireJobLock(fmt.Sprintf("job:%d:%d", job.ID, firedAt.Unix()), jobLockTTL)
+		if err != nil {
+			return nil, err
+		}
+		if fired == nil {
+			return nil, errJobFired
+		}
+	}
+
+	lock, err := acquireJobLock("job:"+strconv.FormatUint(uint64(job.ID), 10), jobLockTTL)
+	if err != nil {
+		return nil, err
+	}
+	if lock == nil {
+		return nil, ErrJobRunning
+	}
+
+	run := &jobRun{
+		job:     job,
+		jobType: jobType,
+		lock:    lock,
+		log: system.SysJobLog{
+			JobId:     job.ID,
+			JobName:   job.Name,
+			JobType:   job.JobType,
+			Trigger:   trigger,
+			Operator:  operator,
+			Instance:  jobInstance,
+			Status:    JobStatusRunning,
+			StartedAt: time.Now(),
+		},
+	}
+	if err := global.DB.Create(&run.log).Error; err != nil {
+		lock.release()
+		return nil, err
+	}
+	return run, nil
+}
+
+// execute 执行任务并记录结果，执行结束后释放锁
+func (r *jobRun) execute() {
+	defer r.lock.release()
+
+	stopRefresh := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(jobLockTTL / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := r.lock.refresh(); err != nil {
+					fmt.Printf("任务锁续期失败: %v\n", err)
+				}
+			case <-stopRefresh:
+				return
+			}
+		}
+	}()
+
+	result, err := r.call()
+	close(stopRefresh)
+
+	finishedAt := time.Now()
+	r.log.FinishedAt = finishedAt
+	r.log.Duration = finishedAt.Sub(r.log.StartedAt).Milliseconds()
+	r.log.Result = result
+	r.log.Status = JobStatusSuccess
+	if err != nil {
+		r.log.Status = JobStatusFailed
+		r.log.Error = err.Error()
+	}
+
+	utils.JobRunsTotal.WithLabelValues(r.job.JobType, r.log.Trigger, r.log.Status).Inc()
+	utils.JobRunDuration.WithLabelValues(r.job.JobType, r.log.Status).Observe(finishedAt.Sub(r.log.StartedAt).Seconds())
+
+	if err := global.DB.Model(&r.log).Select("status", "finished_at", "duration", "result", "error").Updates(&r.log).Error; err != nil {
+		fmt.Printf("更新任务执行记录失败: %v\n", err)
+	}
+	if err := global.DB.Model(&system.SysJob{}).Where("id = ?", r.job.ID).
+		Updates(map[string]interface{}{"last_run_at": r.log.StartedAt, "last_status": r.log.Status}).Error; err != nil {
+		fmt.Printf("更新任务执行状态失败: %v\n", err)
+	}
+}
+
+// call 调用任务函数，任务panic时记录为失败，不影响调度器
+func (r *jobRun) call() (result string, err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("任务异常: %v\n%s", recovered, debug.Stack())
+		}
+	}()
+	return r.jobType.Run(context.Background(), r.job.Params)
+}
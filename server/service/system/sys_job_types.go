@@ -0,0 +1,158 @@
+package system
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"server/config"
+	"server/global"
+	"strconv"
+	"time"
+)
+
+// 内置任务类型
+const (
+	JobTypeOperationLogRetention = "operationLogRetention"
+	JobTypeFileChunkCleanup      = "fileChunkCleanup"
+)
+
+func init() {
+	RegisterJobType(JobType{
+		Name:           JobTypeOperationLogRetention,
+		Title:          "操作日志保留策略",
+		Description:    "按保留天数和最大条数归档并清理操作日志，参数为空时使用配置文件中的 operation-log.retention，参数不能少于配置的保留范围",
+		DefaultParams:  `{"keepDays":180,"maxRows":1000000}`,
+		Run:            runOperationLogRetentionJob,
+		ValidateParams: validateRetentionParams,
+	})
+	RegisterJobType(JobType{
+		Name:           JobTypeFileChunkCleanup,
+		Title:          "分片上传清理",
+		Description:    "删除超过过期时间没有新分片的未完成上传及其分片，参数为空时使用配置文件中的 oss.chunk.expire，参数不能短于配置的过期时间",
+		DefaultParams:  `{"expireHours":24}`,
+		Run:            runFileChunkCleanupJob,
+		ValidateParams: validateChunkCleanupParams,
+	})
+}
+
+// retentionPolicy 解析保留策略任务的参数，参数中的 keepDays、maxRows 覆盖配置
+// 操作日志是审计记录，参数只能保留得比配置更多，不能借任务绕过配置清理更多日志
+func retentionPolicy(params string) (config.LogRetention, error) {
+	configured := global.CONFIG.OperationLog.Retention
+	policy := configured
+	if params == "" {
+		return policy, nil
+	}
+
+	var override struct {
+		KeepDays *int `json:"keepDays"`
+		MaxRows  *int `json:"maxRows"`
+	}
+	if err := json.Unmarshal([]byte(params), &override); err != nil {
+		return policy, err
+	}
+	if override.KeepDays != nil {
+		policy.KeepDays = *override.KeepDays
+	}
+	if override.MaxRows != nil {
+		policy.MaxRows = *override.MaxRows
+	}
+
+	if !keepsAtLeast(policy.KeepDays, configured.KeepDays) {
+		return policy, fmt.Errorf("keepDays 不能小于配置的 %s", retentionLimitText(configured.KeepDays, "天"))
+	}
+	if !keepsAtLeast(policy.MaxRows, configured.MaxRows) {
+		return policy, fmt.Errorf("maxRows 不能小于配置的 %s", retentionLimitText(configured.MaxRows, "条"))
+	}
+	return policy, nil
+}
+
+// keepsAtLeast 判断保留上限value是否不少于configured，0表示不限制
+func keepsAtLeast(value, configured int) bool {
+	if value < 0 {
+		return false
+	}
+	if value == 0 {
+		return true
+	}
+	return configured > 0 && value >= configured
+}
+
+// retentionLimitText 保留上限的文字说明
+func retentionLimitText(value int, unit string) string {
+	if value == 0 {
+		return "不限制"
+	}
+	return strconv.Itoa(value) + unit
+}
+
+// validateRetentionParams 校验保留策略任务的参数
+func validateRetentionParams(params string) error {
+	_, err := retentionPolicy(params)
+	return err
+}
+
+// runOperationLogRetentionJob 执行操作日志保留策略，执行前按当前配置重新校验参数
+func runOperationLogRetentionJob(ctx context.Context, params string) (string, error) {
+	policy, err := retentionPolicy(params)
+	if err != nil {
+		return "", err
+	}
+
+	report, err := (&OperationLogService{}).RunOperationLogRetention(policy, false, "system")
+	if err != nil {
+		return "", err
+	}
+	result, err := json.Marshal(report)
+	if err != nil {
+		return "", err
+	}
+	return string(result), nil
+}
+
+// chunkCleanupExpire 解析分片清理任务的参数，参数中的 expireHours 覆盖配置，不能短于配置的过期时间
+func chunkCleanupExpire(params string) (time.Duration, error) {
+	expire := chunkExpire()
+	if params == "" {
+		return expire, nil
+	}
+
+	var override struct {
+		ExpireHours *int `json:"expireHours"`
+	}
+	if err := json.Unmarshal([]byte(params), &override); err != nil {
+		return expire, err
+	}
+	if override.ExpireHours == nil {
+		return expire, nil
+	}
+	hours := time.Duration(*override.ExpireHours) * time.Hour
+	if hours < expire {
+		return expire, fmt.Errorf("expireHours 不能小于配置的 %d 小时", int(expire/time.Hour))
+	}
+	return hours, nil
+}
+
+// validateChunkCleanupParams 校验分片清理任务的参数
+func validateChunkCleanupParams(params string) error {
+	_, err := chunkCleanupExpire(params)
+	return err
+}
+
+// runFileChunkCleanupJob 清理中断的分片上传
+func runFileChunkCleanupJob(ctx context.Context, params string) (string, error) {
+	expire, err := chunkCleanupExpire(params)
+	if err != nil {
+		return "", err
+	}
+
+	report, err := (&FileService{}).CleanupChunkUploads(ctx, expire)
+	if err != nil {
+		return "", err
+	}
+	result, err := json.Marshal(report)
+	if err != nil {
+		return "", err
+	}
+	return string(result), nil
+}
@@ -0,0 +1,45 @@
+package system
+
+import (
+	"context"
+	"server/config"
+	"server/global"
+	"testing"
+)
+
+func TestRetentionParamsCannotGoBelowPolicy(t *testing.T) {
+	previous := global.CONFIG.OperationLog.Retention
+	t.Cleanup(func() { global.CONFIG.OperationLog.Retention = previous })
+
+	tests := []struct {
+		name       string
+		configured config.LogRetention
+		params     string
+		wantErr    bool
+	}{
+		{"empty params use policy", config.LogRetention{KeepDays: 180, MaxRows: 1000000}, "", false},
+		{"default params", config.LogRetention{KeepDays: 180, MaxRows: 1000000}, `{"keepDays":180,"maxRows":1000000}`, false},
+		{"keep longer", config.LogRetention{KeepDays: 180, MaxRows: 1000000}, `{"keepDays":365,"maxRows":2000000}`, false},
+		{"unlimited", config.LogRetention{KeepDays: 180, MaxRows: 1000000}, `{"keepDays":0,"maxRows":0}`, false},
+		{"fewer rows", config.LogRetention{KeepDays: 180, MaxRows: 1000000}, `{"maxRows":1}`, true},
+		{"fewer days", config.LogRetention{KeepDays: 180, MaxRows: 1000000}, `{"keepDays":1}`, true},
+		{"negative days", config.LogRetention{KeepDays: 180}, `{"keepDays":-1}`, true},
+		{"limit where policy is unlimited", config.LogRetention{KeepDays: 180}, `{"maxRows":5000000}`, true},
+		{"invalid json type", config.LogRetention{KeepDays: 180}, `{"keepDays":"1"}`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			global.CONFIG.OperationLog.Retention = tt.configured
+			err := validateRetentionParams(tt.params)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateRetentionParams(%s) error = %v, wantErr %v", tt.params, err, tt.wantErr)
+			}
+			// 执行时按当前配置再次校验，参数不合法时在访问数据库之前返回
+			if tt.wantErr {
+				if _, err := runOperationLogRetentionJob(context.Background(), tt.params); err == nil {
+					t.Errorf("runOperationLogRetentionJob(%s) ran with params below the policy", tt.params)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,227 @@
+package system
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"server/global"
+	"server/model/system"
+	"server/utils"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	LoginActionLogin   = "login"
+	LoginActionLogout  = "logout"
+	LoginActionRefresh = "refresh"
+)
+
+// loginLogExportBatchSize 导出时每批查询的日志数
+const loginLogExportBatchSize = 1000
+
+type LoginLogService struct{}
+
+// RecordLoginLog 记录一次登录、登出或刷新token，写入失败只打印错误，不影响登录流程
+func (s *LoginLogService) RecordLoginLog(log system.SysLoginLog) {
+	result := "success"
+	if !log.Success {
+		result = "failed"
+	}
+	utils.LoginAttemptsTotal.WithLabelValues(log.Action, result).Inc()
+
+	log.Browser, log.OS = utils.ParseUserAgent(log.UserAgent)
+	if log.LoginTime.IsZero() {
+		log.LoginTime = time.Now()
+	}
+
+	// 登录失败时用户名来自请求，可能超出列长度，按列长度截断，避免写入失败而丢失日志
+	log.Username = truncateRunes(log.Username, 64)
+	log.Reason = truncateRunes(log.Reason, 191)
+	log.IP = truncateRunes(log.IP, 64)
+	log.UserAgent = truncateRunes(log.UserAgent, 191)
+	log.Browser = truncateRunes(log.Browser, 64)
+	log.OS = truncateRunes(log.OS, 64)
+	if err := global.DB.Create(&log).Error; err != nil {
+		fmt.Printf("记录登录日志失败: %v\n", err)
+	}
+}
+
+// truncateRunes 按字符数截断字符串
+func truncateRunes(value string, size int) string {
+	runes := []rune(value)
+	if len(runes) <= size {
+		return value
+	}
+	return string(runes[:size])
+}
+
+// buildQuery 根据查询条件构造登录日志查询
+func (s *LoginLogService) buildQuery(req system.LoginLogRequest) *gorm.DB {
+	db := global.DB.Model(&system.SysLoginLog{})
+	if req.Username != "" {
+		db = db.Where("username LIKE ?", "%"+req.Username+"%")
+	}
+	if req.Action != "" {
+		db = db.Where("action = ?", req.Action)
+	}
+	if req.Success != nil {
+		db = db.Where("success = ?", *req.Success)
+	}
+	if req.IP != "" {
+		db = db.Where("ip LIKE ?", "%"+req.IP+"%")
+	}
+	if req.StartTime != "" {
+		db = db.Where("login_time >= ?", req.StartTime)
+	}
+	if req.EndTime != "" {
+		db = db.Where("login_time <= ?", req.EndTime)
+	}
+	return db
+}
+
+// GetLoginLogList 分页获取登录日志
+func (s *LoginLogService) GetLoginLogList(req system.LoginLogRequest) ([]system.SysLoginLog, int64, error) {
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.PageSize <= 0 {
+		req.PageSize = 10
+	}
+
+	db := s.buildQuery(req)
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var logs []system.SysLoginLog
+	if err := db.Order("id DESC").Offset((req.Page - 1) * req.PageSize).Limit(req.PageSize).Find(&logs).Error; err != nil {
+		return nil, 0, err
+	}
+	return logs, total, nil
+}
+
+// GetUserLoginLogs 获取指定用户最近的登录记录
+func (s *LoginLogService) GetUserLoginLogs(userID uint, limit int) ([]system.SysLoginLog, error) {
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	var logs []system.SysLoginLog
+	err := global.DB.Where("user_id = ?", userID).Order("id DESC").Limit(limit).Find(&logs).Error
+	return logs, err
+}
+
+// ExportLoginLogs 按ID游标分批查询并逐条写出登录日志
+func (s *LoginLogService) ExportLoginLogs(req system.LoginLogRequest, format string, w io.Writer) error {
+	if format != utils.TableFormatCSV && format != utils.TableFormatXLSX {
+		return errors.New("不支持的导出格式: " + format)
+	}
+	writer, err := utils.NewTableWriter(format, w, "登录日志")
+	if err != nil {
+		return err
+	}
+	if err := writer.WriteRow([]string{"ID", "用户名", "动作", "结果", "失败原因", "IP地址", "浏览器", "操作系统", "时间", "用户代理"}); err != nil {
+		return err
+	}
+
+	var lastId uint
+	for {
+		db := s.buildQuery(req)
+		if lastId != 0 {
+			db = db.Where("id < ?", lastId)
+		}
+
+		var logs []system.SysLoginLog
+		if err := db.Order("id DESC").Limit(loginLogExportBatchSize).Find(&logs).Error; err != nil {
+			return err
+		}
+
+		for _, log := range logs {
+			result := "成功"
+			if !log.Success {
+				result = "失败"
+			}
+			if err := writer.WriteRow([]string{
+				strconv.FormatUint(uint64(log.ID), 10),
+				log.Username,
+				log.Action,
+				result,
+				log.Reason,
+				log.IP,
+				log.Browser,
+				log.OS,
+				log.LoginTime.Format("2006-01-02 15:04:05"),
+				log.UserAgent,
+			}); err != nil {
+				return err
+			}
+		}
+		if flusher, ok := w.(http.Flusher); ok {
+			flusher.Flush()
+		}
+
+		if len(logs) < loginLogExportBatchSize {
+			break
+		}
+		lastId = logs[len(logs)-1].ID
+	}
+
+	return writer.Close()
+}
+
+// GetLoginStats 获取登录统计：最近N天每天的成功、失败次数，以及失败次数最多的用户名和IP
+func (s *LoginLogService) GetLoginStats(days int) (map[string]interface{}, error) {
+	if days <= 0 || days > 90 {
+		days = 7
+	}
+	now := time.Now()
+	since := time.Date(now.Year(), now.Month(), now.Day()-days+1, 0, 0, 0, 0, now.Location())
+	stats := make(map[string]interface{})
+
+	var dailyStats []struct {
+		Date    string `json:"date"`
+		Success int64  `json:"success"`
+		Failed  int64  `json:"failed"`
+	}
+	err := global.DB.Model(&system.SysLoginLog{}).
+		Select("DATE(login_time) AS date, SUM(CASE WHEN success THEN 1 ELSE 0 END) AS success, SUM(CASE WHEN success THEN 0 ELSE 1 END) AS failed").
+		Where("action = ? AND login_time >= ?", LoginActionLogin, since).
+		Group("DATE(login_time)").
+		Order("date").
+		Find(&dailyStats).Error
+	if err != nil {
+		return nil, err
+	}
+	stats["dailyStats"] = dailyStats
+
+	type topItem struct {
+		Value string `json:"value"`
+		Count int64  `json:"count"`
+	}
+	var topUsernames, topIPs []topItem
+	failed := global.DB.Model(&system.SysLoginLog{}).Where("action = ? AND success = ? AND login_time >= ?", LoginActionLogin, false, since)
+	if err := failed.Session(&gorm.Session{}).Select("username AS value, COUNT(*) AS count").
+		Group("username").Order("count DESC").Limit(10).Find(&topUsernames).Error; err != nil {
+		return nil, err
+	}
+	if err := failed.Session(&gorm.Session{}).Select("ip AS value, COUNT(*) AS count").
+		Group("ip").Order("count DESC").Limit(10).Find(&topIPs).Error; err != nil {
+		return nil, err
+	}
+	stats["failedUsernames"] = topUsernames
+	stats["failedIps"] = topIPs
+
+	var todayFailed int64
+	if err := global.DB.Model(&system.SysLoginLog{}).
+		Where("action = ? AND success = ? AND DATE(login_time) = ?", LoginActionLogin, false, now.Format("2006-01-02")).
+		Count(&todayFailed).Error; err != nil {
+		return nil, err
+	}
+	stats["todayFailed"] = todayFailed
+
+	return stats, nil
+}
@@ -0,0 +1,46 @@
+package system
+
+import (
+	"server/global"
+	"server/utils"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+)
+
+// RegisterMetricsCollectors 登记依赖运行时状态的指标：数据库连接池和操作日志写入队列，需在数据库初始化后调用
+func RegisterMetricsCollectors() {
+	if global.DB != nil {
+		if db, err := global.DB.DB(); err == nil {
+			utils.MetricsRegistry.MustRegister(collectors.NewDBStatsCollector(db, global.CONFIG.Mysql.DbName))
+		}
+	}
+
+	pipelineGauge := func(name, help string, value func(OperationLogPipelineStats) float64) prometheus.Collector {
+		return prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: utils.MetricsNamespace,
+			Name:      name,
+			Help:      help,
+		}, func() float64 {
+			return value(GetOperationLogPipelineStats())
+		})
+	}
+	pipelineCounter := func(name, help string, value func(OperationLogPipelineStats) float64) prometheus.Collector {
+		return prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace: utils.MetricsNamespace,
+			Name:      name,
+			Help:      help,
+		}, func() float64 {
+			return value(GetOperationLogPipelineStats())
+		})
+	}
+
+	utils.MetricsRegistry.MustRegister(
+		pipelineGauge("operation_log_queue_length", "操作日志写入队列当前排队数", func(s OperationLogPipelineStats) float64 { return float64(s.QueueLength) }),
+		pipelineGauge("operation_log_queue_capacity", "操作日志写入队列容量", func(s OperationLogPipelineStats) float64 { return float64(s.QueueSize) }),
+		pipelineCounter("operation_log_written_total", "写入数据库的操作日志数", func(s OperationLogPipelineStats) float64 { return float64(s.Written) }),
+		pipelineCounter("operation_log_dropped_total", "丢弃的操作日志数", func(s OperationLogPipelineStats) float64 { return float64(s.Dropped) }),
+		pipelineCounter("operation_log_spooled_total", "写入暂存文件的操作日志数", func(s OperationLogPipelineStats) float64 { return float64(s.Spooled) }),
+		pipelineCounter("operation_log_failed_batches_total", "写入失败的批次数", func(s OperationLogPipelineStats) float64 { return float64(s.FailedBatches) }),
+	)
+}
@@ -0,0 +1,239 @@
+package system
+
+import (
+	"bufio"
+	"context"
+	"runtime"
+	"runtime/debug"
+	"server/global"
+	"server/utils"
+	"strings"
+	"time"
+)
+
+// BuildInfo 构建信息
+type BuildInfo struct {
+	Version      string            `json:"version"`
+	GitCommit    string            `json:"gitCommit"`
+	BuildTime    string            `json:"buildTime"`
+	GoVersion    string            `json:"goVersion"`
+	Module       string            `json:"module"`
+	Dependencies map[string]string `json:"dependencies"` // 主要依赖的版本
+}
+
+// RuntimeStats Go运行时状态
+type RuntimeStats struct {
+	StartTime    time.Time `json:"startTime"`
+	Uptime       int64     `json:"uptime"` // 单位秒
+	Goroutines   int       `json:"goroutines"`
+	GOMAXPROCS   int       `json:"gomaxprocs"`
+	HeapAlloc    uint64    `json:"heapAlloc"` // 字节
+	HeapInuse    uint64    `json:"heapInuse"`
+	HeapObjects  uint64    `json:"heapObjects"`
+	Sys          uint64    `json:"sys"`
+	TotalAlloc   uint64    `json:"totalAlloc"`
+	NumGC        uint32    `json:"numGC"`
+	PauseTotalMs float64   `json:"pauseTotalMs"`
+	LastGC       time.Time `json:"lastGC"`
+}
+
+// DatabaseStats 数据库连接池状态
+type DatabaseStats struct {
+	Available         bool   `json:"available"`
+	Error             string `json:"error,omitempty"`
+	MaxOpen           int    `json:"maxOpen"`
+	Open              int    `json:"open"`
+	InUse             int    `json:"inUse"`
+	Idle              int    `json:"idle"`
+	WaitCount         int64  `json:"waitCount"`
+	WaitDurationMs    int64  `json:"waitDurationMs"`
+	MaxIdleClosed     int64  `json:"maxIdleClosed"`
+	MaxLifetimeClosed int64  `json:"maxLifetimeClosed"`
+}
+
+// RedisStats Redis状态，只在启用Redis时返回
+type RedisStats struct {
+	Enabled bool              `json:"enabled"`
+	Error   string            `json:"error,omitempty"`
+	Info    map[string]string `json:"info,omitempty"`
+}
+
+// ServerMonitor 服务监控信息
+type ServerMonitor struct {
+	Build    BuildInfo       `json:"build"`
+	Runtime  RuntimeStats    `json:"runtime"`
+	Host     utils.HostStats `json:"host"`
+	Database DatabaseStats   `json:"database"`
+	Redis    RedisStats      `json:"redis"`
+}
+
+// monitorDependencies 构建信息中需要展示版本的依赖
+var monitorDependencies = map[string]string{
+	"github.com/gin-gonic/gin":       "gin",
+	"gorm.io/gorm":                   "gorm",
+	"gorm.io/driver/mysql":           "mysql",
+	"github.com/go-redis/redis/v8":   "redis",
+	"github.com/casbin/casbin/v2":    "casbin",
+	"github.com/golang-jwt/jwt/v4":   "jwt",
+	"github.com/robfig/cron/v3":      "cron",
+	"github.com/spf13/viper":         "viper",
+	"github.com/xuri/excelize/v2":    "excelize",
+	"github.com/go-sql-driver/mysql": "mysqlDriver",
+}
+
+// redisInfoKeys 从 INFO 中返回的字段
+var redisInfoKeys = map[string]bool{
+	"redis_version":              true,
+	"redis_mode":                 true,
+	"uptime_in_seconds":          true,
+	"connected_clients":          true,
+	"blocked_clients":            true,
+	"used_memory":                true,
+	"used_memory_human":          true,
+	"used_memory_peak_human":     true,
+	"maxmemory_human":            true,
+	"mem_fragmentation_ratio":    true,
+	"total_connections_received": true,
+	"total_commands_processed":   true,
+	"instantaneous_ops_per_sec":  true,
+	"keyspace_hits":              true,
+	"keyspace_misses":            true,
+	"evicted_keys":               true,
+	"role":                       true,
+}
+
+type MonitorService struct{}
+
+// GetBuildInfo 获取构建信息，ldflags未注入提交信息时使用Go构建时记录的VCS信息
+func (s *MonitorService) GetBuildInfo() BuildInfo {
+	info := BuildInfo{
+		Version:      global.Version,
+		GitCommit:    global.GitCommit,
+		BuildTime:    global.BuildTime,
+		GoVersion:    runtime.Version(),
+		Dependencies: make(map[string]string),
+	}
+
+	buildInfo, ok := debug.ReadBuildInfo()
+	if !ok {
+		return info
+	}
+	info.Module = buildInfo.Main.Path
+	for _, dep := range buildInfo.Deps {
+		if name, ok := monitorDependencies[dep.Path]; ok {
+			info.Dependencies[name] = dep.Version
+		}
+	}
+	for _, setting := range buildInfo.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			if info.GitCommit == "" {
+				info.GitCommit = setting.Value
+			}
+		case "vcs.time":
+			if info.BuildTime == "" {
+				info.BuildTime = setting.Value
+			}
+		}
+	}
+	return info
+}
+
+// GetRuntimeStats 获取Go运行时状态
+func (s *MonitorService) GetRuntimeStats() RuntimeStats {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	stats := RuntimeStats{
+		StartTime:    global.StartTime,
+		Uptime:       int64(time.Since(global.StartTime).Seconds()),
+		Goroutines:   runtime.NumGoroutine(),
+		GOMAXPROCS:   runtime.GOMAXPROCS(0),
+		HeapAlloc:    mem.HeapAlloc,
+		HeapInuse:    mem.HeapInuse,
+		HeapObjects:  mem.HeapObjects,
+		Sys:          mem.Sys,
+		TotalAlloc:   mem.TotalAlloc,
+		NumGC:        mem.NumGC,
+		PauseTotalMs: float64(mem.PauseTotalNs) / float64(time.Millisecond),
+	}
+	if mem.LastGC > 0 {
+		stats.LastGC = time.Unix(0, int64(mem.LastGC))
+	}
+	return stats
+}
+
+// GetDatabaseStats 获取数据库连接池状态
+func (s *MonitorService) GetDatabaseStats() DatabaseStats {
+	if global.DB == nil {
+		return DatabaseStats{Error: "数据库未连接"}
+	}
+	db, err := global.DB.DB()
+	if err != nil {
+		return DatabaseStats{Error: err.Error()}
+	}
+
+	pool := db.Stats()
+	stats := DatabaseStats{
+		Available:         true,
+		MaxOpen:           pool.MaxOpenConnections,
+		Open:              pool.OpenConnections,
+		InUse:             pool.InUse,
+		Idle:              pool.Idle,
+		WaitCount:         pool.WaitCount,
+		WaitDurationMs:    pool.WaitDuration.Milliseconds(),
+		MaxIdleClosed:     pool.MaxIdleClosed,
+		MaxLifetimeClosed: pool.MaxLifetimeClosed,
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := db.PingContext(ctx); err != nil {
+		stats.Available = false
+		stats.Error = err.Error()
+	}
+	return stats
+}
+
+// GetRedisStats 获取Redis状态
+func (s *MonitorService) GetRedisStats() RedisStats {
+	if global.REDIS == nil {
+		return RedisStats{}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	raw, err := global.REDIS.Info(ctx).Result()
+	if err != nil {
+		return RedisStats{Enabled: true, Error: err.Error()}
+	}
+
+	info := make(map[string]string)
+	scanner := bufio.NewScanner(strings.NewReader(raw))
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(strings.TrimSpace(scanner.Text()), ":")
+		if !ok {
+			continue
+		}
+		// 各数据库的键数量，如 db0:keys=1,expires=0
+		if redisInfoKeys[key] || strings.HasPrefix(key, "db") {
+			info[key] = value
+		}
+	}
+	return RedisStats{Enabled: true, Info: info}
+}
+
+// GetServerMonitor 获取完整的服务监控信息
+func (s *MonitorService) GetServerMonitor() ServerMonitor {
+	host, err := utils.ReadHostStats(".")
+	if err != nil {
+		host.Unsupported = true
+	}
+
+	return ServerMonitor{
+		Build:    s.GetBuildInfo(),
+		Runtime:  s.GetRuntimeStats(),
+		Host:     host,
+		Database: s.GetDatabaseStats(),
+		Redis:    s.GetRedisStats(),
+	}
+}
@@ -2,17 +2,33 @@ package system
 
 import (
 	"encoding/json"
-	"fmt"
 	"server/global"
 	"server/model/system"
+	"server/utils"
+	"sync/atomic"
 	"time"
+
+	"gorm.io/gorm"
 )
 
 type OperationLogService struct{}
 
-// CreateOperationLog 创建操作日志
-func (s *OperationLogService) CreateOperationLog(log *system.SysOperationLog) error {
-	return global.DB.Create(log).Error
+// operationLogRedactor 操作日志使用的脱敏器，配置加载时构建，避免每条日志重复解析配置
+var operationLogRedactor atomic.Pointer[utils.Redactor]
+
+// ReloadOperationLogRedactor 按当前配置重新构建脱敏器，配置文件变更后调用
+func ReloadOperationLogRedactor() *utils.Redactor {
+	redactor := utils.NewRedactor(global.CONFIG.OperationLog.Redact)
+	operationLogRedactor.Store(redactor)
+	return redactor
+}
+
+// OperationLogRedactor 获取操作日志使用的脱敏器，尚未构建时按当前配置构建
+func OperationLogRedactor() *utils.Redactor {
+	if redactor := operationLogRedactor.Load(); redactor != nil {
+		return redactor
+	}
+	return ReloadOperationLogRedactor()
 }
 
 // GetOperationLogList 获取操作日志列表
@@ -20,33 +36,7 @@ func (s *OperationLogService) GetOperationLogList(req system.OperationLogRequest
 	var logs []system.SysOperationLog
 	var total int64
 
-	db := global.DB.Model(&system.SysOperationLog{})
-
-	// 构建查询条件
-	if req.UserID != 0 {
-		db = db.Where("user_id = ?", req.UserID)
-	}
-	if req.Username != "" {
-		db = db.Where("username LIKE ?", "%"+req.Username+"%")
-	}
-	if req.Method != "" {
-		db = db.Where("method = ?", req.Method)
-	}
-	if req.Path != "" {
-		db = db.Where("path LIKE ?", "%"+req.Path+"%")
-	}
-	if req.OperationType != "" {
-		db = db.Where("operation_type = ?", req.OperationType)
-	}
-	if req.Status != 0 {
-		db = db.Where("status = ?", req.Status)
-	}
-	if req.StartTime != "" {
-		db = db.Where("operation_time >= ?", req.StartTime)
-	}
-	if req.EndTime != "" {
-		db = db.Where("operation_time <= ?", req.EndTime)
-	}
+	db := s.buildQuery(req)
 
 	// 获取总数
 	err := db.Count(&total).Error
@@ -76,25 +66,38 @@ func (s *OperationLogService) GetOperationLogList(req system.OperationLogRequest
 	}, nil
 }
 
-// DeleteOperationLog 删除操作日志
-func (s *OperationLogService) DeleteOperationLog(id uint) error {
-	return global.DB.Delete(&system.SysOperationLog{}, id).Error
-}
-
-// DeleteOperationLogsByIds 批量删除操作日志
-func (s *OperationLogService) DeleteOperationLogsByIds(ids []uint) error {
-	return global.DB.Delete(&system.SysOperationLog{}, ids).Error
-}
-
-// ClearOperationLogs 清空操作日志
-func (s *OperationLogService) ClearOperationLogs() error {
-	return global.DB.Exec("TRUNCATE TABLE sys_operation_logs").Error
-}
+// buildQuery 根据查询条件构建操作日志查询
+func (s *OperationLogService) buildQuery(req system.OperationLogRequest) *gorm.DB {
+	db := global.DB.Model(&system.SysOperationLog{})
+	if req.UserID != 0 {
+		db = db.Where("user_id = ?", req.UserID)
+	}
+	if req.Username != "" {
+		db = db.Where("username LIKE ?", "%"+req.Username+"%")
+	}
+	if req.Method != "" {
+		db = db.Where("method = ?", req.Method)
+	}
+	if req.Path != "" {
+		db = db.Where("path LIKE ?", "%"+req.Path+"%")
+	}
+	if req.Module != "" {
+		db = db.Where("module = ?", req.Module)
+	}
+	if req.OperationType != "" {
+		db = db.Where("operation_type = ?", req.OperationType)
+	}
+	if req.Status != 0 {
+		db = db.Where("status = ?", req.Status)
+	}
+	if req.StartTime != "" {
+		db = db.Where("operation_time >= ?", req.StartTime)
+	}
+	if req.EndTime != "" {
+		db = db.Where("operation_time <= ?", req.EndTime)
+	}
 
-// ClearOperationLogsByDays 清理指定天数前的操作日志
-func (s *OperationLogService) ClearOperationLogsByDays(days int) error {
-	cutoffTime := time.Now().AddDate(0, 0, -days)
-	return global.DB.Where("operation_time < ?", cutoffTime).Delete(&system.SysOperationLog{}).Error
+	return db
 }
 
 // GetOperationLogById 根据ID获取操作日志
@@ -105,9 +108,15 @@ func (s *OperationLogService) GetOperationLogById(id uint) (system.SysOperationL
 }
 
 // LogOperation 记录操作日志的便捷方法
-func (s *OperationLogService) LogOperation(userID uint, username, method, path, operationType, description string, requestBody, responseBody interface{}, ip, userAgent string, status int, latency int64, errorMsg string) {
+func (s *OperationLogService) LogOperation(userID uint, username, method, path, module, operationType, description string, requestBody, responseBody interface{}, ip, userAgent string, status int, latency int64, errorMsg string, snapshot interface{}, requestId string) {
+	// 持久化前对敏感字段脱敏，避免密码、令牌等明文写入日志表
+	redactor := OperationLogRedactor()
+	requestBody = redactor.Redact(method, path, "request", requestBody)
+	responseBody = redactor.Redact(method, path, "response", responseBody)
+	snapshot = redactor.Redact(method, path, "snapshot", snapshot)
+
 	// 序列化请求和响应数据
-	var reqBodyStr, respBodyStr string
+	var reqBodyStr, respBodyStr, snapshotStr string
 
 	if requestBody != nil {
 		if reqBytes, err := json.Marshal(requestBody); err == nil {
@@ -121,6 +130,12 @@ func (s *OperationLogService) LogOperation(userID uint, username, method, path,
 		}
 	}
 
+	if snapshot != nil {
+		if snapshotBytes, err := json.Marshal(snapshot); err == nil {
+			snapshotStr = string(snapshotBytes)
+		}
+	}
+
 	// 限制字段长度，避免数据过大
 	if len(reqBodyStr) > 5000 {
 		reqBodyStr = reqBodyStr[:5000] + "...[truncated]"
@@ -134,10 +149,13 @@ func (s *OperationLogService) LogOperation(userID uint, username, method, path,
 		Username:      username,
 		Method:        method,
 		Path:          path,
+		Module:        module,
 		OperationType: operationType,
 		Description:   description,
 		RequestBody:   reqBodyStr,
 		ResponseBody:  respBodyStr,
+		Snapshot:      snapshotStr,
+		RequestId:     requestId,
 		IP:            ip,
 		UserAgent:     userAgent,
 		Status:        status,
@@ -146,12 +164,8 @@ func (s *OperationLogService) LogOperation(userID uint, username, method, path,
 		OperationTime: time.Now(),
 	}
 
-	// 异步记录日志，避免影响主业务
-	go func() {
-		if err := s.CreateOperationLog(log); err != nil {
-			fmt.Printf("记录操作日志失败: %v\n", err)
-		}
-	}()
+	// 放入写入队列，由后台批量写入，避免影响主业务
+	enqueueOperationLog(log)
 }
 
 // GetOperationStats 获取操作统计信息
@@ -177,6 +191,9 @@ func (s *OperationLogService) GetOperationStats() (map[string]interface{}, error
 	}
 	stats["totalCount"] = totalCount
 
+	// 写入队列运行指标
+	stats["pipeline"] = GetOperationLogPipelineStats()
+
 	// 操作类型统计
 	var typeStats []struct {
 		OperationType string `json:"operationType"`
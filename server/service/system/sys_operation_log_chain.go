@@ -0,0 +1,454 @@
+package system
+
+import (
+	"compress/gzip"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"server/global"
+	"server/model/system"
+	"server/utils/upload"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// maxReportedBreaks 校验报告中最多列出的断点数
+const maxReportedBreaks = 100
+
+// operationLogChainBatchSize 校验和归档时每批读取的日志数
+const operationLogChainBatchSize = 1000
+
+// OperationLogChainBreak 哈希链断点
+type OperationLogChainBreak struct {
+	Id     uint   `json:"id"`
+	Reason string `json:"reason"`
+}
+
+// OperationLogVerifyReport 哈希链校验报告
+type OperationLogVerifyReport struct {
+	Valid              bool                     `json:"valid"`
+	Checked            int64                    `json:"checked"`            // 校验的日志数
+	Legacy             int64                    `json:"legacy"`             // 启用哈希链之前的日志数
+	BrokenCount        int                      `json:"brokenCount"`        // 断点总数
+	Breaks             []OperationLogChainBreak `json:"breaks"`             // 断点明细（最多100条）
+	Checkpoints        int                      `json:"checkpoints"`        // 归档检查点数
+	InvalidCheckpoints []OperationLogChainBreak `json:"invalidCheckpoints"` // 签名或归档文件校验失败的检查点
+	StartAfterId       uint                     `json:"startAfterId"`       // 从该ID之后开始校验（之前的日志已归档）
+}
+
+// chainVerifier 按ID顺序逐条校验哈希链
+type chainVerifier struct {
+	prev       string
+	seenHashed bool
+	report     *OperationLogVerifyReport
+}
+
+func (v *chainVerifier) check(log system.SysOperationLog) {
+	v.report.Checked++
+
+	if log.Hash == "" {
+		if !v.seenHashed {
+			v.report.Legacy++
+		} else {
+			v.addBreak(log.ID, "缺少哈希")
+		}
+		v.prev = ""
+		return
+	}
+	v.seenHashed = true
+
+	if log.PrevHash != v.prev {
+		v.addBreak(log.ID, "与上一条日志的哈希不连续，日志可能被删除或插入")
+	}
+	if operationLogHash(log, log.PrevHash) != log.Hash {
+		v.addBreak(log.ID, "内容与哈希不一致，日志可能被修改")
+	}
+	v.prev = log.Hash
+}
+
+func (v *chainVerifier) addBreak(id uint, reason string) {
+	v.report.BrokenCount++
+	if len(v.report.Breaks) < maxReportedBreaks {
+		v.report.Breaks = append(v.report.Breaks, OperationLogChainBreak{Id: id, Reason: reason})
+	}
+}
+
+// operationLogHash 计算日志哈希：HMAC-SHA256(上一条哈希 + 日志内容)
+// 使用与检查点签名相同的密钥，没有密钥时无法在改写日志后重新计算出有效的哈希链
+func operationLogHash(log system.SysOperationLog, prevHash string) string {
+	content, _ := json.Marshal([]interface{}{
+		log.UserID,
+		log.Username,
+		log.Method,
+		log.Path,
+		log.Module,
+		log.OperationType,
+		log.Description,
+		log.RequestBody,
+		log.ResponseBody,
+		log.Snapshot,
+		log.RequestId,
+		log.IP,
+		log.UserAgent,
+		log.Status,
+		log.ErrorMessage,
+		log.Latency,
+		log.OperationTime.UTC().Format(time.RFC3339Nano),
+	})
+
+	mac := hmac.New(sha256.New, operationLogChainKey())
+	mac.Write([]byte(prevHash))
+	mac.Write([]byte("\n"))
+	mac.Write(content)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// insertChainedOperationLogs 计算哈希链并写入日志
+// 在事务中锁定哈希链写入锁，多个实例同时写入时也能保证链的顺序
+func insertChainedOperationLogs(logs []*system.SysOperationLog) error {
+	return global.DB.Transaction(func(tx *gorm.DB) error {
+		if err := lockChainHead(tx); err != nil {
+			return err
+		}
+		prev, err := lastChainHash(tx)
+		if err != nil {
+			return err
+		}
+
+		for _, log := range logs {
+			// 数据库时间精确到毫秒，截断后再计算哈希，保证读取后可以复算
+			log.OperationTime = log.OperationTime.Truncate(time.Millisecond)
+			log.PrevHash = prev
+			log.Hash = operationLogHash(*log, prev)
+			prev = log.Hash
+		}
+		return tx.CreateInBatches(logs, len(logs)).Error
+	})
+}
+
+// lockChainHead 锁定哈希链写入锁所在的行，不存在时先创建
+// 锁定最后一条日志在日志表为空时锁不到任何行，无法让并发写入排队，因此使用单独的一行加锁
+func lockChainHead(tx *gorm.DB) error {
+	var head system.SysOperationLogChainHead
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", 1).Find(&head).Error; err != nil {
+		return err
+	}
+	if head.ID != 0 {
+		return nil
+	}
+
+	// 并发创建时只有一个成功，其余等待后忽略冲突，再重新加锁
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&system.SysOperationLogChainHead{ID: 1}).Error; err != nil {
+		return err
+	}
+	return tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", 1).First(&head).Error
+}
+
+// lastChainHash 获取链尾哈希，日志表为空时使用最近一次归档检查点的哈希，需在锁定写入锁后调用
+func lastChainHash(tx *gorm.DB) (string, error) {
+	var last system.SysOperationLog
+	if err := tx.Unscoped().Select("id", "hash").Order("id DESC").Limit(1).Find(&last).Error; err != nil {
+		return "", err
+	}
+	if last.ID != 0 {
+		return last.Hash, nil
+	}
+
+	checkpoint, err := latestCheckpoint(tx)
+	if err != nil || checkpoint == nil {
+		return "", err
+	}
+	return checkpoint.LastHash, nil
+}
+
+// latestCheckpoint 获取最近一次归档检查点
+func latestCheckpoint(db *gorm.DB) (*system.SysOperationLogCheckpoint, error) {
+	var checkpoint system.SysOperationLogCheckpoint
+	if err := db.Order("last_id DESC").Limit(1).Find(&checkpoint).Error; err != nil {
+		return nil, err
+	}
+	if checkpoint.ID == 0 {
+		return nil, nil
+	}
+	return &checkpoint, nil
+}
+
+// VerifyOperationLogChain 校验全部操作日志的哈希链和归档检查点
+func (s *OperationLogService) VerifyOperationLogChain() (*OperationLogVerifyReport, error) {
+	report := &OperationLogVerifyReport{
+		Breaks:             []OperationLogChainBreak{},
+		InvalidCheckpoints: []OperationLogChainBreak{},
+	}
+
+	var checkpoints []system.SysOperationLogCheckpoint
+	if err := global.DB.Order("last_id ASC").Find(&checkpoints).Error; err != nil {
+		return nil, err
+	}
+	report.Checkpoints = len(checkpoints)
+	for _, checkpoint := range checkpoints {
+		if reason := verifyCheckpoint(checkpoint); reason != "" {
+			report.InvalidCheckpoints = append(report.InvalidCheckpoints, OperationLogChainBreak{Id: checkpoint.ID, Reason: reason})
+		}
+	}
+
+	// 已归档的日志从检查点之后开始校验
+	verifier := &chainVerifier{report: report}
+	if len(checkpoints) > 0 {
+		last := checkpoints[len(checkpoints)-1]
+		report.StartAfterId = last.LastId
+		verifier.prev = last.LastHash
+		verifier.seenHashed = last.LastHash != ""
+	}
+
+	lastId := report.StartAfterId
+	for {
+		var logs []system.SysOperationLog
+		if err := global.DB.Unscoped().Where("id > ?", lastId).Order("id ASC").
+			Limit(operationLogChainBatchSize).Find(&logs).Error; err != nil {
+			return nil, err
+		}
+		for _, log := range logs {
+			verifier.check(log)
+		}
+		if len(logs) < operationLogChainBatchSize {
+			break
+		}
+		lastId = logs[len(logs)-1].ID
+	}
+
+	report.Valid = report.BrokenCount == 0 && len(report.InvalidCheckpoints) == 0
+	return report, nil
+}
+
+// ArchiveOperationLogs 将最早的一段日志归档为gzip压缩的NDJSON文件，记录签名检查点后再从表中清理
+// before 为空时归档全部日志，否则归档操作时间早于before的日志；为保持哈希链连续，只清理ID连续的最早一段
+func (s *OperationLogService) ArchiveOperationLogs(before *time.Time, operator, reason string) (*system.SysOperationLogCheckpoint, error) {
+	boundary, err := archiveBoundary(before)
+	if err != nil {
+		return nil, err
+	}
+	return s.archiveOperationLogsUpTo(boundary, operator, reason)
+}
+
+// archiveOperationLogsUpTo 归档并清理上次检查点之后、ID不超过boundary的日志
+func (s *OperationLogService) archiveOperationLogsUpTo(boundary uint, operator, reason string) (*system.SysOperationLogCheckpoint, error) {
+	startAfter := uint(0)
+	checkpoint, err := latestCheckpoint(global.DB)
+	if err != nil {
+		return nil, err
+	}
+	report := &OperationLogVerifyReport{}
+	verifier := &chainVerifier{report: report}
+	if checkpoint != nil {
+		startAfter = checkpoint.LastId
+		verifier.prev = checkpoint.LastHash
+		verifier.seenHashed = checkpoint.LastHash != ""
+	}
+	if boundary <= startAfter {
+		return nil, errors.New("没有需要归档的日志")
+	}
+
+	storage, err := upload.Storage()
+	if err != nil {
+		return nil, fmt.Errorf("文件存储不可用: %w", err)
+	}
+
+	// 先写入临时文件计算哈希和大小，再保存到文件存储，多个实例都能读取归档
+	file, err := os.CreateTemp("", "operation-log-archive-*")
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		file.Close()
+		os.Remove(file.Name())
+	}()
+
+	now := time.Now()
+	archiveKey := operationLogArchivePrefix + fmt.Sprintf("operation_logs_%d_%d_%s%s", startAfter+1, boundary, now.Format("20060102150405"), operationLogArchiveSuffix)
+
+	hash := sha256.New()
+	gz := gzip.NewWriter(io.MultiWriter(file, hash))
+	encoder := json.NewEncoder(gz)
+
+	var firstId uint
+	var lastHash string
+	lastId := startAfter
+	writeErr := func() error {
+		for {
+			var logs []system.SysOperationLog
+			if err := global.DB.Unscoped().Where("id > ? AND id <= ?", lastId, boundary).Order("id ASC").
+				Limit(operationLogChainBatchSize).Find(&logs).Error; err != nil {
+				return err
+			}
+			for _, log := range logs {
+				if firstId == 0 {
+					firstId = log.ID
+				}
+				verifier.check(log)
+				if err := encoder.Encode(log); err != nil {
+					return err
+				}
+				lastHash = log.Hash
+			}
+			if len(logs) < operationLogChainBatchSize {
+				return nil
+			}
+			lastId = logs[len(logs)-1].ID
+		}
+	}()
+	if writeErr == nil {
+		writeErr = gz.Close()
+	}
+	if writeErr != nil {
+		return nil, writeErr
+	}
+	if report.Checked == 0 {
+		return nil, errors.New("没有需要归档的日志")
+	}
+
+	size, err := file.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	ctx := context.Background()
+	if err := storage.Put(ctx, archiveKey, file, size, "application/gzip"); err != nil {
+		return nil, fmt.Errorf("保存归档文件失败: %w", err)
+	}
+
+	newCheckpoint := &system.SysOperationLogCheckpoint{
+		FirstId:       firstId,
+		LastId:        boundary,
+		Count:         report.Checked,
+		LastHash:      lastHash,
+		BrokenCount:   report.BrokenCount,
+		ArchiveFile:   archiveKey,
+		ArchiveSha256: hex.EncodeToString(hash.Sum(nil)),
+		ArchiveSize:   size,
+		ArchivedAt:    now.Truncate(time.Second),
+		Operator:      operator,
+		Reason:        reason,
+	}
+	newCheckpoint.Signature = checkpointSignature(*newCheckpoint)
+
+	// 先保存检查点，再分批清理；清理中断时剩余日志已在归档范围内，校验时会被跳过，可再次清理
+	if err := global.DB.Create(newCheckpoint).Error; err != nil {
+		storage.Delete(ctx, archiveKey)
+		return nil, err
+	}
+	for {
+		result := global.DB.Unscoped().Where("id <= ?", boundary).Limit(5000).Delete(&system.SysOperationLog{})
+		if result.Error != nil {
+			return newCheckpoint, result.Error
+		}
+		if result.RowsAffected == 0 {
+			break
+		}
+	}
+
+	return newCheckpoint, nil
+}
+
+// GetOperationLogCheckpoints 获取全部归档检查点
+func (s *OperationLogService) GetOperationLogCheckpoints() ([]system.SysOperationLogCheckpoint, error) {
+	var checkpoints []system.SysOperationLogCheckpoint
+	err := global.DB.Order("last_id DESC").Find(&checkpoints).Error
+	return checkpoints, err
+}
+
+// openOperationLogArchive 打开归档文件，旧版本的归档保存在本地目录，记录的是文件路径
+func openOperationLogArchive(ctx context.Context, archiveFile string) (io.ReadCloser, error) {
+	if !strings.HasPrefix(archiveFile, operationLogArchivePrefix) {
+		return os.Open(archiveFile)
+	}
+	storage, err := upload.Storage()
+	if err != nil {
+		return nil, err
+	}
+	return storage.Open(ctx, archiveFile)
+}
+
+// archiveBoundary 计算本次可以清理到的最大日志ID
+func archiveBoundary(before *time.Time) (uint, error) {
+	var maxId uint
+	if err := global.DB.Unscoped().Model(&system.SysOperationLog{}).Select("COALESCE(MAX(id), 0)").Scan(&maxId).Error; err != nil {
+		return 0, err
+	}
+	if before == nil {
+		return maxId, nil
+	}
+
+	// 第一条需要保留的日志之前的日志都可以清理
+	var firstKeepId uint
+	if err := global.DB.Unscoped().Model(&system.SysOperationLog{}).Where("operation_time >= ?", *before).
+		Select("COALESCE(MIN(id), 0)").Scan(&firstKeepId).Error; err != nil {
+		return 0, err
+	}
+	if firstKeepId == 0 {
+		return maxId, nil
+	}
+	return firstKeepId - 1, nil
+}
+
+// operationLogChainKey 哈希链和检查点签名使用的密钥，未配置时使用JWT签名密钥
+func operationLogChainKey() []byte {
+	if key := global.CONFIG.OperationLog.CheckpointKey; key != "" {
+		return []byte(key)
+	}
+	return []byte(global.CONFIG.JWT.SigningKey)
+}
+
+// checkpointSignature 使用HMAC-SHA256对检查点签名
+func checkpointSignature(checkpoint system.SysOperationLogCheckpoint) string {
+
+	content := fmt.Sprintf("%d|%d|%d|%s|%d|%s|%s|%s",
+		checkpoint.FirstId,
+		checkpoint.LastId,
+		checkpoint.Count,
+		checkpoint.LastHash,
+		checkpoint.BrokenCount,
+		checkpoint.ArchiveSha256,
+		checkpoint.ArchivedAt.UTC().Format(time.RFC3339),
+		checkpoint.Operator,
+	)
+	mac := hmac.New(sha256.New, operationLogChainKey())
+	mac.Write([]byte(content))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// verifyCheckpoint 校验检查点签名和归档文件，返回失败原因
+func verifyCheckpoint(checkpoint system.SysOperationLogCheckpoint) string {
+	if !hmac.Equal([]byte(checkpointSignature(checkpoint)), []byte(checkpoint.Signature)) {
+		return "检查点签名无效"
+	}
+
+	file, err := openOperationLogArchive(context.Background(), checkpoint.ArchiveFile)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return "归档文件不存在"
+		}
+		return "无法读取归档文件: " + err.Error()
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "无法读取归档文件: " + err.Error()
+	}
+	if hex.EncodeToString(hash.Sum(nil)) != checkpoint.ArchiveSha256 {
+		return "归档文件与检查点记录的哈希不一致"
+	}
+	return ""
+}
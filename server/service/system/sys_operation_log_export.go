@@ -0,0 +1,112 @@
+package system
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"server/model/system"
+	"server/utils"
+	"strconv"
+)
+
+// OperationLogFormatNDJSON 每行一个JSON对象的导出格式
+const OperationLogFormatNDJSON = "ndjson"
+
+// operationLogExportBatchSize 导出时每批查询的日志数
+const operationLogExportBatchSize = 1000
+
+// OperationLogExportContentType 获取导出格式对应的Content-Type
+func OperationLogExportContentType(format string) string {
+	if format == OperationLogFormatNDJSON {
+		return "application/x-ndjson; charset=utf-8"
+	}
+	return utils.TableContentType(format)
+}
+
+// ExportOperationLogs 按ID游标分批查询并逐条写出操作日志，不限制导出行数
+func (s *OperationLogService) ExportOperationLogs(req system.OperationLogRequest, format string, includeBody bool, w io.Writer) error {
+	var write func(log system.SysOperationLog) error
+	var closeWriter func() error
+
+	switch format {
+	case OperationLogFormatNDJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetEscapeHTML(false)
+		write = func(log system.SysOperationLog) error {
+			if !includeBody {
+				log.RequestBody = ""
+				log.ResponseBody = ""
+			}
+			return encoder.Encode(log)
+		}
+		closeWriter = func() error { return nil }
+	case utils.TableFormatCSV, utils.TableFormatXLSX:
+		writer, err := utils.NewTableWriter(format, w, "操作日志")
+		if err != nil {
+			return err
+		}
+		header := []string{"ID", "用户名", "操作类型", "请求方法", "请求路径", "操作描述", "状态码", "IP地址", "耗时(ms)", "操作时间", "错误信息"}
+		if includeBody {
+			header = append(header, "请求参数", "响应结果")
+		}
+		if err := writer.WriteRow(header); err != nil {
+			return err
+		}
+		write = func(log system.SysOperationLog) error {
+			row := []string{
+				strconv.FormatUint(uint64(log.ID), 10),
+				log.Username,
+				log.OperationType,
+				log.Method,
+				log.Path,
+				log.Description,
+				strconv.Itoa(log.Status),
+				log.IP,
+				strconv.FormatInt(log.Latency, 10),
+				log.OperationTime.Format("2006-01-02 15:04:05"),
+				log.ErrorMessage,
+			}
+			if includeBody {
+				row = append(row, log.RequestBody, log.ResponseBody)
+			}
+			return writer.WriteRow(row)
+		}
+		closeWriter = writer.Close
+	default:
+		return errors.New("不支持的导出格式: " + format)
+	}
+
+	// 使用ID游标代替偏移分页，导出过程中新增日志不会导致重复或遗漏
+	var lastId uint
+	for {
+		db := s.buildQuery(req)
+		if lastId != 0 {
+			db = db.Where("id < ?", lastId)
+		}
+		if !includeBody {
+			db = db.Omit("request_body", "response_body")
+		}
+
+		var logs []system.SysOperationLog
+		if err := db.Order("id DESC").Limit(operationLogExportBatchSize).Find(&logs).Error; err != nil {
+			return err
+		}
+
+		for _, log := range logs {
+			if err := write(log); err != nil {
+				return err
+			}
+		}
+		if flusher, ok := w.(http.Flusher); ok {
+			flusher.Flush()
+		}
+
+		if len(logs) < operationLogExportBatchSize {
+			break
+		}
+		lastId = logs[len(logs)-1].ID
+	}
+
+	return closeWriter()
+}
@@ -0,0 +1,197 @@
+package system
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"server/config"
+	"server/global"
+	"server/model/system"
+	"strings"
+	"time"
+)
+
+const (
+	// operationLogArchivePrefix 归档文件在文件存储中的目录
+	operationLogArchivePrefix = "operation-log/archive/"
+	// operationLogArchiveSuffix 归档文件后缀
+	operationLogArchiveSuffix = ".ndjson.gz"
+)
+
+// ErrOperationLogArchiveNotFound 归档文件不存在或文件名无效
+var ErrOperationLogArchiveNotFound = errors.New("归档文件不存在")
+
+// OperationLogRetentionReport 保留策略执行报告
+type OperationLogRetentionReport struct {
+	DryRun     bool                              `json:"dryRun"`
+	KeepDays   int                               `json:"keepDays"`
+	MaxRows    int                               `json:"maxRows"`
+	Total      int64                             `json:"total"`      // 当前未归档的日志数
+	PruneCount int64                             `json:"pruneCount"` // 将被归档清理的日志数
+	FirstId    uint                              `json:"firstId"`
+	LastId     uint                              `json:"lastId"`
+	OldestTime *time.Time                        `json:"oldestTime"`
+	NewestTime *time.Time                        `json:"newestTime"`
+	Reasons    []string                          `json:"reasons"`
+	Checkpoint *system.SysOperationLogCheckpoint `json:"checkpoint"`
+}
+
+// OperationLogArchive 归档文件信息
+type OperationLogArchive struct {
+	Name         string    `json:"name"`
+	Size         int64     `json:"size"`
+	ModTime      time.Time `json:"modTime"`
+	CheckpointId uint      `json:"checkpointId"`
+	FirstId      uint      `json:"firstId"`
+	LastId       uint      `json:"lastId"`
+	Count        int64     `json:"count"`
+}
+
+// RunOperationLogRetention 按保留策略归档并清理日志，dryRun为true时只返回将被清理的范围
+func (s *OperationLogService) RunOperationLogRetention(policy config.LogRetention, dryRun bool, operator string) (*OperationLogRetentionReport, error) {
+	report := &OperationLogRetentionReport{
+		DryRun:   dryRun,
+		KeepDays: policy.KeepDays,
+		MaxRows:  policy.MaxRows,
+		Reasons:  []string{},
+	}
+	if policy.KeepDays <= 0 && policy.MaxRows <= 0 {
+		return nil, errors.New("保留策略未设置保留天数或最大条数")
+	}
+
+	startAfter := uint(0)
+	checkpoint, err := latestCheckpoint(global.DB)
+	if err != nil {
+		return nil, err
+	}
+	if checkpoint != nil {
+		startAfter = checkpoint.LastId
+	}
+
+	pending := global.DB.Unscoped().Model(&system.SysOperationLog{}).Where("id > ?", startAfter)
+	if err := pending.Count(&report.Total).Error; err != nil {
+		return nil, err
+	}
+
+	boundary := startAfter
+	if policy.KeepDays > 0 {
+		cutoff := time.Now().AddDate(0, 0, -policy.KeepDays)
+		dayBoundary, err := archiveBoundary(&cutoff)
+		if err != nil {
+			return nil, err
+		}
+		if dayBoundary > startAfter {
+			report.Reasons = append(report.Reasons, fmt.Sprintf("超过%d天", policy.KeepDays))
+			boundary = dayBoundary
+		}
+	}
+	if policy.MaxRows > 0 && report.Total > int64(policy.MaxRows) {
+		// 超出条数上限时，清理最早的 Total-MaxRows 条
+		var rowBoundary uint
+		if err := global.DB.Unscoped().Model(&system.SysOperationLog{}).Where("id > ?", startAfter).
+			Order("id ASC").Offset(int(report.Total)-policy.MaxRows-1).Limit(1).
+			Pluck("id", &rowBoundary).Error; err != nil {
+			return nil, err
+		}
+		report.Reasons = append(report.Reasons, fmt.Sprintf("超过%d条", policy.MaxRows))
+		if rowBoundary > boundary {
+			boundary = rowBoundary
+		}
+	}
+
+	if boundary <= startAfter {
+		return report, nil
+	}
+
+	var summary struct {
+		Count      int64
+		FirstId    uint
+		LastId     uint
+		OldestTime *time.Time
+		NewestTime *time.Time
+	}
+	if err := global.DB.Unscoped().Model(&system.SysOperationLog{}).
+		Select("COUNT(*) AS count, MIN(id) AS first_id, MAX(id) AS last_id, MIN(operation_time) AS oldest_time, MAX(operation_time) AS newest_time").
+		Where("id > ? AND id <= ?", startAfter, boundary).Scan(&summary).Error; err != nil {
+		return nil, err
+	}
+	report.PruneCount = summary.Count
+	report.FirstId = summary.FirstId
+	report.LastId = summary.LastId
+	report.OldestTime = summary.OldestTime
+	report.NewestTime = summary.NewestTime
+
+	if dryRun || report.PruneCount == 0 {
+		return report, nil
+	}
+
+	report.Checkpoint, err = s.archiveOperationLogsUpTo(boundary, operator, "保留策略: "+strings.Join(report.Reasons, "，"))
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// ListOperationLogArchives 按检查点列出归档文件
+func (s *OperationLogService) ListOperationLogArchives() ([]OperationLogArchive, error) {
+	var checkpoints []system.SysOperationLogCheckpoint
+	if err := global.DB.Where("archive_file <> ''").Order("archived_at DESC").Find(&checkpoints).Error; err != nil {
+		return nil, err
+	}
+
+	archives := make([]OperationLogArchive, 0, len(checkpoints))
+	for _, checkpoint := range checkpoints {
+		archive := OperationLogArchive{
+			Name:         path.Base(filepath.ToSlash(checkpoint.ArchiveFile)),
+			Size:         checkpoint.ArchiveSize,
+			ModTime:      checkpoint.ArchivedAt,
+			CheckpointId: checkpoint.ID,
+			FirstId:      checkpoint.FirstId,
+			LastId:       checkpoint.LastId,
+			Count:        checkpoint.Count,
+		}
+		// 旧版本的检查点没有记录大小，归档在本地目录
+		if archive.Size == 0 && !strings.HasPrefix(checkpoint.ArchiveFile, operationLogArchivePrefix) {
+			if info, err := os.Stat(checkpoint.ArchiveFile); err == nil {
+				archive.Size = info.Size()
+			}
+		}
+		archives = append(archives, archive)
+	}
+	return archives, nil
+}
+
+// OpenOperationLogArchive 按文件名打开检查点记录的归档文件，返回文件内容和大小（未知时为-1）
+func (s *OperationLogService) OpenOperationLogArchive(ctx context.Context, name string) (io.ReadCloser, int64, error) {
+	if name == "" || name != path.Base(name) || !strings.HasSuffix(name, operationLogArchiveSuffix) {
+		return nil, 0, ErrOperationLogArchiveNotFound
+	}
+
+	var checkpoints []system.SysOperationLogCheckpoint
+	if err := global.DB.Where("archive_file LIKE ?", "%"+name).Find(&checkpoints).Error; err != nil {
+		return nil, 0, err
+	}
+	for _, checkpoint := range checkpoints {
+		if path.Base(filepath.ToSlash(checkpoint.ArchiveFile)) != name {
+			continue
+		}
+		reader, err := openOperationLogArchive(ctx, checkpoint.ArchiveFile)
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil, 0, ErrOperationLogArchiveNotFound
+			}
+			return nil, 0, err
+		}
+		size := checkpoint.ArchiveSize
+		if size == 0 {
+			size = -1
+		}
+		return reader, size, nil
+	}
+	return nil, 0, ErrOperationLogArchiveNotFound
+}
@@ -0,0 +1,104 @@
+package system
+
+import (
+	"encoding/json"
+	"server/config"
+	"server/global"
+	"server/model/system"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+// captureOperationLogs 用只入队不写库的写入器替换全局写入器，返回入队的日志
+func captureOperationLogs(t *testing.T) <-chan *system.SysOperationLog {
+	t.Helper()
+	previous := logWriter
+	logWriter = &operationLogWriter{
+		cfg:   config.OperationLogQueue{Overflow: OperationLogOverflowDrop},
+		queue: make(chan *system.SysOperationLog, 10),
+		done:  make(chan struct{}),
+	}
+	t.Cleanup(func() { logWriter = previous })
+	return logWriter.queue
+}
+
+// useRedactConfig 使用指定的脱敏配置，测试结束后恢复
+func useRedactConfig(t *testing.T, cfg config.Redact) {
+	t.Helper()
+	previous := global.CONFIG.OperationLog.Redact
+	global.CONFIG.OperationLog.Redact = cfg
+	ReloadOperationLogRedactor()
+	t.Cleanup(func() {
+		global.CONFIG.OperationLog.Redact = previous
+		ReloadOperationLogRedactor()
+	})
+}
+
+func TestLogOperationRedactsSecrets(t *testing.T) {
+	v := viper.New()
+	v.SetConfigFile("../../config/config.yaml")
+	if err := v.ReadInConfig(); err != nil {
+		t.Fatal(err)
+	}
+	var cfg config.Server
+	if err := v.Unmarshal(&cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		redact config.Redact
+	}{
+		{"config.yaml", cfg.OperationLog.Redact},
+		{"default fields", config.Redact{}},
+		{"hash strategy", config.Redact{Strategy: "hash", HashSalt: "salt"}},
+		{"partial strategy", config.Redact{Strategy: "partial"}},
+	}
+
+	const (
+		password = "Secret#Password123"
+		token    = "eyJhbGciOiJIUzI1NiJ9.secret-token"
+	)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useRedactConfig(t, tt.redact)
+			logs := captureOperationLogs(t)
+
+			request := map[string]interface{}{"username": "admin", "password": password}
+			response := map[string]interface{}{
+				"code": 0,
+				"data": map[string]interface{}{"token": token, "user": map[string]interface{}{"username": "admin"}},
+			}
+			snapshot := struct {
+				Username    string `json:"username"`
+				NewPassword string `json:"newPassword"`
+			}{"admin", password}
+
+			var service OperationLogService
+			service.LogOperation(1, "admin", "POST", "/api/base/login", "认证", "LOGIN", "用户登录 admin",
+				request, response, "127.0.0.1", "go-test", 200, 1, "", snapshot, "request-id")
+
+			var log *system.SysOperationLog
+			select {
+			case log = <-logs:
+			default:
+				t.Fatal("operation log was not enqueued")
+			}
+
+			record, err := json.Marshal(log)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, secret := range []string{password, token} {
+				if strings.Contains(string(record), secret) {
+					t.Errorf("secret %q persisted in %s", secret, record)
+				}
+			}
+			if !strings.Contains(log.RequestBody, `"username":"admin"`) {
+				t.Errorf("request body lost non-sensitive fields: %s", log.RequestBody)
+			}
+		})
+	}
+}
@@ -0,0 +1,394 @@
+package system
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"server/config"
+	"server/model/system"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	OperationLogOverflowSpool = "spool"
+	OperationLogOverflowDrop  = "drop"
+)
+
+// OperationLogPipelineStats 操作日志写入队列的运行指标
+type OperationLogPipelineStats struct {
+	QueueLength   int   `json:"queueLength"`   // 当前排队数量
+	QueueSize     int   `json:"queueSize"`     // 队列容量
+	Enqueued      int64 `json:"enqueued"`      // 入队总数
+	Written       int64 `json:"written"`       // 写入数据库总数
+	Dropped       int64 `json:"dropped"`       // 丢弃总数
+	Spooled       int64 `json:"spooled"`       // 写入暂存文件总数
+	Replayed      int64 `json:"replayed"`      // 从暂存文件重放总数
+	FailedBatches int64 `json:"failedBatches"` // 写入失败的批次数
+	SpoolPending  bool  `json:"spoolPending"`  // 暂存文件中是否有待重放的日志
+}
+
+// operationLogWriter 操作日志批量写入器
+// 请求只负责入队，由单个后台协程批量写入数据库；数据库不可用时写入暂存文件，恢复后重放
+type operationLogWriter struct {
+	cfg   config.OperationLogQueue
+	queue chan *system.SysOperationLog
+	done  chan struct{}
+
+	mu     sync.RWMutex // 保护closed，避免关闭后继续入队
+	closed bool
+
+	spoolMu sync.Mutex // 暂存文件读写锁
+
+	enqueued      atomic.Int64
+	written       atomic.Int64
+	dropped       atomic.Int64
+	spooled       atomic.Int64
+	replayed      atomic.Int64
+	failedBatches atomic.Int64
+}
+
+var logWriter *operationLogWriter
+
+// StartOperationLogWriter 启动操作日志写入器，需在数据库初始化后调用
+func StartOperationLogWriter(cfg config.OperationLogQueue) {
+	if cfg.Size <= 0 {
+		cfg.Size = 10000
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 100
+	}
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = 1000
+	}
+	if cfg.ReplayInterval <= 0 {
+		cfg.ReplayInterval = 30
+	}
+	if cfg.Overflow == "" {
+		cfg.Overflow = OperationLogOverflowSpool
+	}
+	if cfg.SpoolPath == "" {
+		cfg.SpoolPath = "log/operation_log.spool"
+	}
+
+	logWriter = &operationLogWriter{
+		cfg:   cfg,
+		queue: make(chan *system.SysOperationLog, cfg.Size),
+		done:  make(chan struct{}),
+	}
+	go logWriter.run()
+}
+
+// StopOperationLogWriter 停止接收日志并将队列中剩余的日志写入数据库或暂存文件
+func StopOperationLogWriter(ctx context.Context) error {
+	if logWriter == nil {
+		return nil
+	}
+
+	logWriter.mu.Lock()
+	if !logWriter.closed {
+		logWriter.closed = true
+		close(logWriter.queue)
+	}
+	logWriter.mu.Unlock()
+
+	select {
+	case <-logWriter.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// GetOperationLogPipelineStats 获取操作日志写入队列的运行指标
+func GetOperationLogPipelineStats() OperationLogPipelineStats {
+	if logWriter == nil {
+		return OperationLogPipelineStats{}
+	}
+	return logWriter.stats()
+}
+
+// enqueueOperationLog 将日志放入写入队列，写入器未启动时直接写入数据库
+func enqueueOperationLog(log *system.SysOperationLog) {
+	if logWriter == nil {
+		if err := insertChainedOperationLogs([]*system.SysOperationLog{log}); err != nil {
+			fmt.Printf("记录操作日志失败: %v\n", err)
+		}
+		return
+	}
+	logWriter.enqueue(log)
+}
+
+func (w *operationLogWriter) enqueue(log *system.SysOperationLog) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	if w.closed {
+		// 已关闭时不再入队，直接暂存，下次启动后重放
+		w.spool([]*system.SysOperationLog{log})
+		return
+	}
+
+	select {
+	case w.queue <- log:
+		w.enqueued.Add(1)
+	default:
+		// 队列已满，不阻塞请求
+		if w.cfg.Overflow == OperationLogOverflowSpool {
+			w.spool([]*system.SysOperationLog{log})
+		} else {
+			w.dropped.Add(1)
+		}
+	}
+}
+
+func (w *operationLogWriter) run() {
+	defer close(w.done)
+
+	ticker := time.NewTicker(time.Duration(w.cfg.FlushInterval) * time.Millisecond)
+	defer ticker.Stop()
+	// 定期重放暂存文件，数据库恢复后即使没有新日志也能及时写回
+	replayTicker := time.NewTicker(time.Duration(w.cfg.ReplayInterval) * time.Second)
+	defer replayTicker.Stop()
+
+	batch := make([]*system.SysOperationLog, 0, w.cfg.BatchSize)
+
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		w.write(batch)
+		batch = make([]*system.SysOperationLog, 0, w.cfg.BatchSize)
+	}
+	replay := func() {
+		if err := w.replay(); err != nil {
+			fmt.Printf("重放操作日志暂存文件失败: %v\n", err)
+		}
+	}
+
+	// 启动时先重放上次未写入的日志
+	replay()
+
+	for {
+		select {
+		case log, ok := <-w.queue:
+			if !ok {
+				flush()
+				return
+			}
+			batch = append(batch, log)
+			if len(batch) >= w.cfg.BatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case <-replayTicker.C:
+			flush()
+			replay()
+		}
+	}
+}
+
+// write 批量写入数据库，失败时写入暂存文件
+func (w *operationLogWriter) write(batch []*system.SysOperationLog) {
+	if err := insertChainedOperationLogs(batch); err != nil {
+		fmt.Printf("批量写入操作日志失败: %v\n", err)
+		w.failedBatches.Add(1)
+		for _, log := range batch {
+			log.ID = 0
+		}
+		w.spool(batch)
+		return
+	}
+	w.written.Add(int64(len(batch)))
+}
+
+// spool 将日志以每行一个JSON的格式追加到暂存文件
+func (w *operationLogWriter) spool(logs []*system.SysOperationLog) {
+	w.spoolMu.Lock()
+	defer w.spoolMu.Unlock()
+
+	if err := appendOperationLogs(w.cfg.SpoolPath, logs); err != nil {
+		fmt.Printf("写入操作日志暂存文件失败: %v\n", err)
+		w.dropped.Add(int64(len(logs)))
+		return
+	}
+	w.spooled.Add(int64(len(logs)))
+}
+
+// replay 将暂存文件中的日志逐行读取，分批写回数据库
+// 先把暂存文件改名为重放文件，重放期间产生的新日志仍写入暂存文件；中途失败时保留未写入的部分
+func (w *operationLogWriter) replay() error {
+	replayPath := w.cfg.SpoolPath + ".replay"
+
+	w.spoolMu.Lock()
+	if _, err := os.Stat(replayPath); errors.Is(err, os.ErrNotExist) {
+		if err := os.Rename(w.cfg.SpoolPath, replayPath); err != nil {
+			w.spoolMu.Unlock()
+			if errors.Is(err, os.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+	}
+	w.spoolMu.Unlock()
+
+	file, err := os.Open(replayPath)
+	if err != nil {
+		return err
+	}
+
+	scanner := newOperationLogScanner(file)
+	batch := make([]*system.SysOperationLog, 0, w.cfg.BatchSize)
+	replayed := false
+	insert := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := insertChainedOperationLogs(batch); err != nil {
+			w.failedBatches.Add(1)
+			return err
+		}
+		w.replayed.Add(int64(len(batch)))
+		w.written.Add(int64(len(batch)))
+		batch = make([]*system.SysOperationLog, 0, w.cfg.BatchSize)
+		replayed = true
+		return nil
+	}
+	fail := func(err error) error {
+		// 第一批就失败时文件未变化，原样保留；否则只保留尚未写入的日志，避免下次重放产生重复数据
+		if !replayed {
+			file.Close()
+			return err
+		}
+		if keepErr := keepUnreplayedLogs(file, replayPath, batch, scanner); keepErr != nil {
+			return keepErr
+		}
+		return err
+	}
+
+	for scanner.Scan() {
+		var log system.SysOperationLog
+		if err := json.Unmarshal(scanner.Bytes(), &log); err != nil {
+			fmt.Printf("跳过无法解析的暂存日志: %v\n", err)
+			continue
+		}
+		log.ID = 0
+		batch = append(batch, &log)
+		if len(batch) >= w.cfg.BatchSize {
+			if err := insert(); err != nil {
+				return fail(err)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fail(err)
+	}
+	if err := insert(); err != nil {
+		return fail(err)
+	}
+
+	file.Close()
+	return os.Remove(replayPath)
+}
+
+func (w *operationLogWriter) stats() OperationLogPipelineStats {
+	pending := false
+	for _, path := range []string{w.cfg.SpoolPath, w.cfg.SpoolPath + ".replay"} {
+		if info, err := os.Stat(path); err == nil && info.Size() > 0 {
+			pending = true
+		}
+	}
+
+	return OperationLogPipelineStats{
+		QueueLength:   len(w.queue),
+		QueueSize:     cap(w.queue),
+		Enqueued:      w.enqueued.Load(),
+		Written:       w.written.Load(),
+		Dropped:       w.dropped.Load(),
+		Spooled:       w.spooled.Load(),
+		Replayed:      w.replayed.Load(),
+		FailedBatches: w.failedBatches.Load(),
+		SpoolPending:  pending,
+	}
+}
+
+// appendOperationLogs 以NDJSON格式追加日志到文件
+func appendOperationLogs(path string, logs []*system.SysOperationLog) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return encodeOperationLogs(file, logs)
+}
+
+// keepUnreplayedLogs 用尚未写入的日志覆盖重放文件：当前批次加上文件中未读取的行
+func keepUnreplayedLogs(file *os.File, path string, batch []*system.SysOperationLog, scanner *bufio.Scanner) error {
+	tmpPath := path + ".tmp"
+	tmp, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	writer := bufio.NewWriter(tmp)
+	encoder := json.NewEncoder(writer)
+	for _, log := range batch {
+		log.ID = 0
+		if err = encoder.Encode(log); err != nil {
+			break
+		}
+	}
+	for err == nil && scanner.Scan() {
+		if _, err = writer.Write(scanner.Bytes()); err == nil {
+			err = writer.WriteByte('\n')
+		}
+	}
+	if err == nil {
+		err = scanner.Err()
+	}
+	if err == nil {
+		err = writer.Flush()
+	}
+	if err == nil {
+		err = tmp.Sync()
+	}
+	tmp.Close()
+	file.Close()
+	if err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
+
+func encodeOperationLogs(file *os.File, logs []*system.SysOperationLog) error {
+	writer := bufio.NewWriter(file)
+	encoder := json.NewEncoder(writer)
+	for _, log := range logs {
+		if err := encoder.Encode(log); err != nil {
+			return err
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+	return file.Sync()
+}
+
+// newOperationLogScanner 按行读取NDJSON格式的日志文件
+func newOperationLogScanner(file *os.File) *bufio.Scanner {
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	return scanner
+}
@@ -0,0 +1,151 @@
+package system
+
+import (
+	"context"
+	"errors"
+	"server/global"
+	"server/model/system"
+	"sort"
+
+	"gorm.io/gorm"
+)
+
+type PermissionTemplateService struct{}
+
+// TemplateApplyResult 模板应用到单个角色的结果
+type TemplateApplyResult struct {
+	AuthorityId    uint             `json:"authorityId"`
+	AuthorityName  string           `json:"authorityName"`
+	MenuCount      int              `json:"menuCount"`
+	SkippedMenuIds []uint           `json:"skippedMenuIds"`
+	Pruned         []AuthorityPrune `json:"pruned"`
+}
+
+var authorityService = AuthorityService{}
+
+// GetTemplateMenuIds 获取模板包含的菜单ID
+func (s *PermissionTemplateService) GetTemplateMenuIds(db *gorm.DB, templateId uint) ([]uint, error) {
+	var menuIds []uint
+	err := db.Model(&system.SysPermissionTemplateMenu{}).Where("template_id = ?", templateId).Pluck("base_menu_id", &menuIds).Error
+	return menuIds, err
+}
+
+// SetTemplateMenus 重新设置模板包含的菜单（需在事务中调用），旧的关联记录物理删除
+func (s *PermissionTemplateService) SetTemplateMenus(tx *gorm.DB, templateId uint, menuIds []uint) error {
+	if err := tx.Unscoped().Where("template_id = ?", templateId).Delete(&system.SysPermissionTemplateMenu{}).Error; err != nil {
+		return err
+	}
+
+	var templateMenus []system.SysPermissionTemplateMenu
+	for _, menuId := range uniqueIds(menuIds) {
+		templateMenus = append(templateMenus, system.SysPermissionTemplateMenu{
+			TemplateId: templateId,
+			BaseMenuId: menuId,
+		})
+	}
+	if len(templateMenus) == 0 {
+		return nil
+	}
+	return tx.Create(&templateMenus).Error
+}
+
+// CheckMenusExist 校验菜单是否全部存在
+func (s *PermissionTemplateService) CheckMenusExist(menuIds []uint) error {
+	menuIds = uniqueIds(menuIds)
+	if len(menuIds) == 0 {
+		return nil
+	}
+
+	var count int64
+	if err := global.DB.Model(&system.SysBaseMenu{}).Where("id IN ?", menuIds).Count(&count).Error; err != nil {
+		return err
+	}
+	if int(count) != len(menuIds) {
+		return errors.New("菜单不存在")
+	}
+	return nil
+}
+
+// ApplyTemplate 在一个事务中将模板应用到多个角色
+// mode 为 replace 时用模板覆盖角色权限，为 merge 时在原有权限基础上追加
+// ctx 用于在实体变更记录中关联请求
+func (s *PermissionTemplateService) ApplyTemplate(ctx context.Context, templateId uint, authorityIds []uint, mode string) ([]TemplateApplyResult, error) {
+	if mode == "" {
+		mode = "replace"
+	}
+	if mode != "replace" && mode != "merge" {
+		return nil, errors.New("不支持的应用模式")
+	}
+
+	authorityIds = uniqueIds(authorityIds)
+	if len(authorityIds) == 0 {
+		return nil, errors.New("请选择要应用的角色")
+	}
+
+	var authorities []system.SysAuthority
+	if err := global.DB.Where("authority_id IN ?", authorityIds).Find(&authorities).Error; err != nil {
+		return nil, err
+	}
+	if len(authorities) != len(authorityIds) {
+		return nil, errors.New("角色不存在")
+	}
+
+	// 父角色先于子角色处理，保证子角色按父角色的新权限进行限制
+	depths := make(map[uint]int, len(authorities))
+	for _, authority := range authorities {
+		ancestors, err := authorityService.GetAncestors(authority)
+		if err != nil {
+			return nil, err
+		}
+		depths[authority.AuthorityId] = len(ancestors)
+	}
+	sort.SliceStable(authorities, func(i, j int) bool {
+		return depths[authorities[i].AuthorityId] < depths[authorities[j].AuthorityId]
+	})
+
+	var results []TemplateApplyResult
+	err := global.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		templateMenuIds, err := s.GetTemplateMenuIds(tx, templateId)
+		if err != nil {
+			return err
+		}
+
+		for _, authority := range authorities {
+			menuIds := templateMenuIds
+			if mode == "merge" {
+				existing, err := authorityService.LoadAuthorityMenuIds(tx, authority.AuthorityId)
+				if err != nil {
+					return err
+				}
+				menuIds = append(existing, templateMenuIds...)
+			}
+
+			kept, skipped, err := authorityService.ConstrainToParent(tx, authority.ParentId, menuIds)
+			if err != nil {
+				return err
+			}
+			if err := authorityService.SetAuthorityMenus(tx, authority.AuthorityId, kept); err != nil {
+				return err
+			}
+
+			pruned, err := authorityService.PruneDescendantMenus(tx, authority.AuthorityId)
+			if err != nil {
+				return err
+			}
+
+			results = append(results, TemplateApplyResult{
+				AuthorityId:    authority.AuthorityId,
+				AuthorityName:  authority.AuthorityName,
+				MenuCount:      len(kept),
+				SkippedMenuIds: skipped,
+				Pruned:         pruned,
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
@@ -0,0 +1,144 @@
+package system
+
+import (
+	"errors"
+	"server/global"
+	"server/model/system"
+	"server/utils"
+
+	"gorm.io/gorm"
+)
+
+type UserService struct{}
+
+// NormalizeAuthorityIds 去重角色ID并确保当前角色包含在角色列表中，返回整理后的角色列表和当前角色
+func (s *UserService) NormalizeAuthorityIds(authorityIds []uint, activeId uint) ([]uint, uint) {
+	ids := uniqueIds(authorityIds)
+	if activeId != 0 && !utils.ContainsId(ids, activeId) {
+		ids = append(ids, activeId)
+	}
+	if activeId == 0 && len(ids) > 0 {
+		activeId = ids[0]
+	}
+
+	return ids, activeId
+}
+
+// CheckAuthoritiesExist 校验角色是否全部存在
+func (s *UserService) CheckAuthoritiesExist(authorityIds []uint) error {
+	if len(authorityIds) == 0 {
+		return nil
+	}
+
+	var count int64
+	if err := global.DB.Model(&system.SysAuthority{}).Where("authority_id IN ?", authorityIds).Count(&count).Error; err != nil {
+		return err
+	}
+	if int(count) != len(authorityIds) {
+		return errors.New("角色不存在")
+	}
+	return nil
+}
+
+// SetUserAuthorities 重新设置用户拥有的角色（需在事务中调用）
+func (s *UserService) SetUserAuthorities(tx *gorm.DB, userID uint, authorityIds []uint) error {
+	if err := tx.Where("sys_user_id = ?", userID).Delete(&system.SysUserAuthority{}).Error; err != nil {
+		return err
+	}
+
+	if len(authorityIds) == 0 {
+		return nil
+	}
+
+	userAuthorities := make([]system.SysUserAuthority, 0, len(authorityIds))
+	for _, id := range authorityIds {
+		userAuthorities = append(userAuthorities, system.SysUserAuthority{
+			SysUserId:               userID,
+			SysAuthorityAuthorityId: id,
+		})
+	}
+	return tx.Create(&userAuthorities).Error
+}
+
+// GetUserAuthorityIds 获取用户拥有的全部角色ID（包含当前角色）
+func (s *UserService) GetUserAuthorityIds(user system.SysUser) ([]uint, error) {
+	var authorityIds []uint
+	if err := global.DB.Model(&system.SysUserAuthority{}).
+		Where("sys_user_id = ?", user.ID).
+		Pluck("sys_authority_authority_id", &authorityIds).Error; err != nil {
+		return nil, err
+	}
+
+	ids, _ := s.NormalizeAuthorityIds(authorityIds, user.AuthorityId)
+	return ids, nil
+}
+
+// HasAuthority 判断用户是否拥有指定角色
+func (s *UserService) HasAuthority(user system.SysUser, authorityId uint) (bool, error) {
+	ids, err := s.GetUserAuthorityIds(user)
+	if err != nil {
+		return false, err
+	}
+	return utils.ContainsId(ids, authorityId), nil
+}
+
+// CountAuthorityUsers 统计拥有指定角色的用户数
+func (s *UserService) CountAuthorityUsers(authorityId uint) (int64, error) {
+	var count int64
+	err := global.DB.Model(&system.SysUser{}).
+		Where("authority_id = ? OR id IN (?)", authorityId,
+			global.DB.Model(&system.SysUserAuthority{}).Select("sys_user_id").Where("sys_authority_authority_id = ?", authorityId)).
+		Count(&count).Error
+	return count, err
+}
+
+// CheckPostsExist 校验岗位是否全部存在
+func (s *UserService) CheckPostsExist(postIds []uint) error {
+	postIds = uniqueIds(postIds)
+	if len(postIds) == 0 {
+		return nil
+	}
+
+	var count int64
+	if err := global.DB.Model(&system.SysPost{}).Where("id IN ?", postIds).Count(&count).Error; err != nil {
+		return err
+	}
+	if int(count) != len(postIds) {
+		return errors.New("岗位不存在")
+	}
+	return nil
+}
+
+// SetUserPosts 重新设置用户担任的岗位（需在事务中调用）
+func (s *UserService) SetUserPosts(tx *gorm.DB, userID uint, postIds []uint) error {
+	if err := tx.Where("sys_user_id = ?", userID).Delete(&system.SysUserPost{}).Error; err != nil {
+		return err
+	}
+
+	var userPosts []system.SysUserPost
+	for _, id := range uniqueIds(postIds) {
+		userPosts = append(userPosts, system.SysUserPost{
+			SysUserId: userID,
+			SysPostId: id,
+		})
+	}
+
+	if len(userPosts) == 0 {
+		return nil
+	}
+	return tx.Create(&userPosts).Error
+}
+
+// uniqueIds 去除重复和为0的ID
+func uniqueIds(ids []uint) []uint {
+	seen := make(map[uint]bool)
+	var result []uint
+	for _, id := range ids {
+		if id == 0 || seen[id] {
+			continue
+		}
+		seen[id] = true
+		result = append(result, id)
+	}
+	return result
+}
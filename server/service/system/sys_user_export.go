@@ -0,0 +1,175 @@
+package system
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"server/global"
+	"server/model/system"
+	"server/utils"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// userExportBatchSize 导出时每批查询的用户数
+const userExportBatchSize = 500
+
+// UserListFilter 用户列表查询条件
+type UserListFilter struct {
+	Username string
+	NickName string
+	PostId   uint
+}
+
+// UserExportColumn 用户导出列
+type UserExportColumn struct {
+	Field string `json:"field"`
+	Title string `json:"title"`
+	value func(user system.SysUser, authorityNames map[uint]string) string
+}
+
+// BuildUserQuery 根据查询条件构建用户查询
+func (s *UserService) BuildUserQuery(filter UserListFilter) *gorm.DB {
+	db := global.DB.Model(&system.SysUser{})
+
+	if filter.Username != "" {
+		db = db.Where("username LIKE ?", "%"+filter.Username+"%")
+	}
+	if filter.NickName != "" {
+		db = db.Where("nick_name LIKE ?", "%"+filter.NickName+"%")
+	}
+	if filter.PostId != 0 {
+		db = db.Where("id IN (?)", global.DB.Model(&system.SysUserPost{}).Select("sys_user_id").Where("sys_post_id = ?", filter.PostId))
+	}
+	return db
+}
+
+// UserExportColumns 可导出的用户列，标题取自字段注释
+func (s *UserService) UserExportColumns() []UserExportColumn {
+	comments := modelComments(&system.SysUser{})
+
+	return []UserExportColumn{
+		{Field: "id", Title: "ID", value: func(u system.SysUser, _ map[uint]string) string {
+			return strconv.FormatUint(uint64(u.ID), 10)
+		}},
+		{Field: "username", Title: comments["Username"], value: func(u system.SysUser, _ map[uint]string) string {
+			return u.Username
+		}},
+		{Field: "nickName", Title: comments["NickName"], value: func(u system.SysUser, _ map[uint]string) string {
+			return u.NickName
+		}},
+		{Field: "phone", Title: comments["Phone"], value: func(u system.SysUser, _ map[uint]string) string {
+			return u.Phone
+		}},
+		{Field: "email", Title: comments["Email"], value: func(u system.SysUser, _ map[uint]string) string {
+			return u.Email
+		}},
+		{Field: "authority", Title: "当前角色", value: func(u system.SysUser, names map[uint]string) string {
+			return names[u.AuthorityId]
+		}},
+		{Field: "authorities", Title: "全部角色", value: func(u system.SysUser, names map[uint]string) string {
+			ids := []uint{}
+			for _, authority := range u.Authorities {
+				ids = append(ids, authority.AuthorityId)
+			}
+			ids, _ = s.NormalizeAuthorityIds(ids, u.AuthorityId)
+			var result []string
+			for _, id := range ids {
+				result = append(result, names[id])
+			}
+			return strings.Join(result, ",")
+		}},
+		{Field: "posts", Title: "岗位", value: func(u system.SysUser, _ map[uint]string) string {
+			var result []string
+			for _, post := range u.Posts {
+				result = append(result, post.PostName)
+			}
+			return strings.Join(result, ",")
+		}},
+		{Field: "enable", Title: "状态", value: func(u system.SysUser, _ map[uint]string) string {
+			if u.Enable == 1 {
+				return "正常"
+			}
+			return "冻结"
+		}},
+		{Field: "createdAt", Title: "创建时间", value: func(u system.SysUser, _ map[uint]string) string {
+			return u.CreatedAt.Format("2006-01-02 15:04:05")
+		}},
+	}
+}
+
+// SelectUserExportColumns 根据字段名选择导出列，为空时导出全部列
+func (s *UserService) SelectUserExportColumns(fields []string) ([]UserExportColumn, error) {
+	all := s.UserExportColumns()
+	if len(fields) == 0 {
+		return all, nil
+	}
+
+	byField := make(map[string]UserExportColumn, len(all))
+	for _, column := range all {
+		byField[column.Field] = column
+	}
+
+	var columns []UserExportColumn
+	for _, field := range fields {
+		column, ok := byField[strings.TrimSpace(field)]
+		if !ok {
+			return nil, errors.New("不支持的导出列: " + field)
+		}
+		columns = append(columns, column)
+	}
+	return columns, nil
+}
+
+// ExportUsers 按批查询并逐行写出用户，避免一次性加载全部用户
+func (s *UserService) ExportUsers(filter UserListFilter, columns []UserExportColumn, format string, w io.Writer) error {
+	writer, err := utils.NewTableWriter(format, w, "用户列表")
+	if err != nil {
+		return err
+	}
+
+	var authorities []system.SysAuthority
+	if err := global.DB.Find(&authorities).Error; err != nil {
+		return err
+	}
+	authorityNames := make(map[uint]string, len(authorities))
+	for _, authority := range authorities {
+		authorityNames[authority.AuthorityId] = authority.AuthorityName
+	}
+
+	header := make([]string, len(columns))
+	for i, column := range columns {
+		header[i] = column.Title
+	}
+	if err := writer.WriteRow(header); err != nil {
+		return err
+	}
+
+	var users []system.SysUser
+	var writeErr error
+	result := s.BuildUserQuery(filter).
+		Preload("Authorities").
+		Preload("Posts").
+		FindInBatches(&users, userExportBatchSize, func(tx *gorm.DB, batch int) error {
+			for _, user := range users {
+				row := make([]string, len(columns))
+				for i, column := range columns {
+					row[i] = column.value(user, authorityNames)
+				}
+				if writeErr = writer.WriteRow(row); writeErr != nil {
+					return writeErr
+				}
+			}
+			if flusher, ok := w.(http.Flusher); ok {
+				flusher.Flush()
+			}
+			return nil
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+
+	return writer.Close()
+}
@@ -0,0 +1,289 @@
+package system
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/mail"
+	"server/global"
+	"server/model/system"
+	"server/utils"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// MaxUserImportRows 单次导入的最大行数
+const MaxUserImportRows = 5000
+
+// UserImportColumn 用户导入模板的列
+type UserImportColumn struct {
+	Field    string `json:"field"`
+	Title    string `json:"title"`
+	Required bool   `json:"required"`
+	Example  string `json:"example"`
+}
+
+// UserImportRowResult 单行校验结果
+type UserImportRowResult struct {
+	Row      int      `json:"row"`
+	Username string   `json:"username"`
+	Errors   []string `json:"errors"`
+}
+
+// UserImportReport 导入校验报告
+type UserImportReport struct {
+	Total     int                   `json:"total"`
+	Valid     int                   `json:"valid"`
+	Invalid   int                   `json:"invalid"`
+	Created   int                   `json:"created"`
+	Committed bool                  `json:"committed"`
+	Rows      []UserImportRowResult `json:"rows"`
+}
+
+type userImportRow struct {
+	row          int
+	user         system.SysUser
+	authorityIds []uint
+}
+
+// UserImportColumns 根据SysUser字段生成导入模板列，列标题取自字段注释
+func (s *UserService) UserImportColumns() []UserImportColumn {
+	userComments := modelComments(&system.SysUser{})
+	authorityComments := modelComments(&system.SysAuthority{})
+
+	return []UserImportColumn{
+		{Field: "username", Title: userComments["Username"], Required: true, Example: "zhangsan"},
+		{Field: "nickName", Title: userComments["NickName"], Example: "张三"},
+		{Field: "phone", Title: userComments["Phone"], Example: "13800000000"},
+		{Field: "email", Title: userComments["Email"], Example: "zhangsan@example.com"},
+		{Field: "authorityCode", Title: authorityComments["AuthorityCode"], Required: true, Example: "admin"},
+	}
+}
+
+// WriteUserImportTemplate 写出导入模板（表头和示例行）
+func (s *UserService) WriteUserImportTemplate(format string, w io.Writer) error {
+	writer, err := utils.NewTableWriter(format, w, "用户导入")
+	if err != nil {
+		return err
+	}
+
+	columns := s.UserImportColumns()
+	header := make([]string, len(columns))
+	example := make([]string, len(columns))
+	for i, column := range columns {
+		header[i] = column.Title
+		example[i] = column.Example
+	}
+
+	if err := writer.WriteRow(header); err != nil {
+		return err
+	}
+	if err := writer.WriteRow(example); err != nil {
+		return err
+	}
+	return writer.Close()
+}
+
+// ImportUsers 校验并导入用户，dryRun为true或存在校验错误时不写入数据库
+func (s *UserService) ImportUsers(ctx context.Context, rows [][]string, dryRun bool, defaultPassword string) (*UserImportReport, error) {
+	if len(rows) < 2 {
+		return nil, errors.New("文件中没有数据")
+	}
+	if len(rows)-1 > MaxUserImportRows {
+		return nil, fmt.Errorf("单次最多导入%d行", MaxUserImportRows)
+	}
+
+	// 表头既可以是模板中的中文标题，也可以是字段名
+	columns := s.UserImportColumns()
+	columnIndex := make(map[string]int)
+	for i, title := range rows[0] {
+		title = strings.TrimSpace(title)
+		for _, column := range columns {
+			if strings.EqualFold(title, column.Title) || strings.EqualFold(title, column.Field) {
+				columnIndex[column.Field] = i
+			}
+		}
+	}
+	for _, column := range columns {
+		if _, ok := columnIndex[column.Field]; column.Required && !ok {
+			return nil, fmt.Errorf("缺少必填列: %s", column.Title)
+		}
+	}
+
+	cell := func(values []string, field string) string {
+		i, ok := columnIndex[field]
+		if !ok || i >= len(values) {
+			return ""
+		}
+		return strings.TrimSpace(values[i])
+	}
+
+	// 预先加载角色编码和已存在的用户名
+	var authorities []system.SysAuthority
+	if err := global.DB.Find(&authorities).Error; err != nil {
+		return nil, err
+	}
+	authorityByCode := make(map[string]uint, len(authorities))
+	for _, authority := range authorities {
+		authorityByCode[authority.AuthorityCode] = authority.AuthorityId
+	}
+
+	var usernames []string
+	for _, values := range rows[1:] {
+		if username := cell(values, "username"); username != "" {
+			usernames = append(usernames, username)
+		}
+	}
+	existing := make(map[string]bool)
+	if len(usernames) > 0 {
+		var existingNames []string
+		if err := global.DB.Model(&system.SysUser{}).Where("username IN ?", usernames).Pluck("username", &existingNames).Error; err != nil {
+			return nil, err
+		}
+		for _, name := range existingNames {
+			existing[name] = true
+		}
+	}
+
+	hashedPassword := utils.BcryptHash(defaultPassword)
+	report := &UserImportReport{Rows: []UserImportRowResult{}}
+	seen := make(map[string]int)
+	var validRows []userImportRow
+	for i, values := range rows[1:] {
+		rowNumber := i + 2 // 表格行号，从表头的下一行开始
+		if isBlankRow(values) {
+			continue
+		}
+		report.Total++
+
+		username := cell(values, "username")
+		email := cell(values, "email")
+		result := UserImportRowResult{Row: rowNumber, Username: username, Errors: []string{}}
+
+		if username == "" {
+			result.Errors = append(result.Errors, "用户名不能为空")
+		} else if first, ok := seen[username]; ok {
+			result.Errors = append(result.Errors, fmt.Sprintf("用户名与第%d行重复", first))
+		} else if existing[username] {
+			result.Errors = append(result.Errors, "用户名已存在")
+		}
+		if username != "" {
+			if _, ok := seen[username]; !ok {
+				seen[username] = rowNumber
+			}
+		}
+
+		if email != "" {
+			if address, err := mail.ParseAddress(email); err != nil || address.Address != email {
+				result.Errors = append(result.Errors, "邮箱格式不正确")
+			}
+		}
+
+		// 角色编码支持多个，使用逗号或分号分隔，第一个作为当前角色
+		var authorityIds []uint
+		codes := splitCodes(cell(values, "authorityCode"))
+		if len(codes) == 0 {
+			result.Errors = append(result.Errors, "角色编码不能为空")
+		}
+		for _, code := range codes {
+			if id, ok := authorityByCode[code]; ok {
+				authorityIds = append(authorityIds, id)
+			} else {
+				result.Errors = append(result.Errors, "角色编码不存在: "+code)
+			}
+		}
+
+		if len(result.Errors) > 0 {
+			report.Invalid++
+			report.Rows = append(report.Rows, result)
+			continue
+		}
+
+		report.Valid++
+		authorityIds, activeId := s.NormalizeAuthorityIds(authorityIds, 0)
+		nickName := cell(values, "nickName")
+		if nickName == "" {
+			nickName = username
+		}
+		validRows = append(validRows, userImportRow{
+			row: rowNumber,
+			user: system.SysUser{
+				UUID:        uuid.New(),
+				Username:    username,
+				Password:    hashedPassword,
+				NickName:    nickName,
+				Phone:       cell(values, "phone"),
+				Email:       email,
+				AuthorityId: activeId,
+				Enable:      1,
+			},
+			authorityIds: authorityIds,
+		})
+	}
+
+	if dryRun || report.Invalid > 0 || len(validRows) == 0 {
+		return report, nil
+	}
+
+	err := global.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, item := range validRows {
+			user := item.user
+			if err := tx.Omit(clause.Associations).Create(&user).Error; err != nil {
+				return fmt.Errorf("第%d行导入失败: %v", item.row, err)
+			}
+			if err := s.SetUserAuthorities(tx, user.ID, item.authorityIds); err != nil {
+				return fmt.Errorf("第%d行导入失败: %v", item.row, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	report.Created = len(validRows)
+	report.Committed = true
+	return report, nil
+}
+
+// modelComments 获取模型字段的gorm注释
+func modelComments(model interface{}) map[string]string {
+	comments := make(map[string]string)
+	modelSchema, err := schema.Parse(model, &sync.Map{}, schema.NamingStrategy{})
+	if err != nil {
+		return comments
+	}
+	for _, field := range modelSchema.Fields {
+		comments[field.Name] = field.Comment
+	}
+	return comments
+}
+
+// splitCodes 拆分以逗号或分号分隔的编码
+func splitCodes(value string) []string {
+	var codes []string
+	for _, code := range strings.FieldsFunc(value, func(r rune) bool {
+		return r == ',' || r == '，' || r == ';' || r == '；'
+	}) {
+		if code = strings.TrimSpace(code); code != "" {
+			codes = append(codes, code)
+		}
+	}
+	return codes
+}
+
+// isBlankRow 判断是否为空行
+func isBlankRow(values []string) bool {
+	for _, v := range values {
+		if strings.TrimSpace(v) != "" {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,235 @@
+package system
+
+import (
+	"errors"
+	"fmt"
+	"server/global"
+	"server/model/system"
+	"server/utils"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	SessionRevokeLogout = "logout"
+	SessionRevokeKicked = "kicked"
+)
+
+// ErrSessionInvalid 会话不存在、已过期、已登出或被踢出
+var ErrSessionInvalid = errors.New("登录已失效，请重新登录")
+
+// ErrSessionUnavailable 数据库异常，无法确认会话是否有效
+var ErrSessionUnavailable = errors.New("会话校验失败，请稍后重试")
+
+type SessionService struct{}
+
+// sessionState 会话校验结果缓存，避免每个请求都写数据库
+type sessionState struct {
+	checkedAt time.Time
+	revoked   bool
+}
+
+var (
+	sessionCache     sync.Map // sessionId -> *sessionState
+	sessionSweepMu   sync.Mutex
+	sessionLastSweep time.Time
+)
+
+// sessionIdleTimeout 超过该时间无请求视为离线
+func sessionIdleTimeout() time.Duration {
+	if minutes := global.CONFIG.Session.IdleTimeout; minutes > 0 {
+		return time.Duration(minutes) * time.Minute
+	}
+	return 30 * time.Minute
+}
+
+// sessionTouchInterval 最后活动时间的写入间隔
+func sessionTouchInterval() time.Duration {
+	if seconds := global.CONFIG.Session.TouchInterval; seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	return time.Minute
+}
+
+// CreateSession 登录时创建会话，返回会话ID
+func (s *SessionService) CreateSession(user system.SysUser, ip, userAgent string) (string, error) {
+	now := time.Now()
+	session := system.SysUserSession{
+		SessionId:   uuid.New().String(),
+		UserID:      user.ID,
+		Username:    user.Username,
+		AuthorityId: user.AuthorityId,
+		IP:          ip,
+		UserAgent:   userAgent,
+		LoginAt:     now,
+		LastSeenAt:  now,
+		ExpiresAt:   now.Add(utils.TokenExpiresIn),
+	}
+	if err := global.DB.Create(&session).Error; err != nil {
+		return "", err
+	}
+	sessionCache.Store(session.SessionId, &sessionState{checkedAt: now})
+	return session.SessionId, nil
+}
+
+// RenewSession 刷新token或切换角色后更新会话的过期时间和当前角色
+func (s *SessionService) RenewSession(sessionId string, authorityId uint, expiresAt time.Time) error {
+	now := time.Now()
+	result := global.DB.Model(&system.SysUserSession{}).
+		Where("session_id = ? AND revoked_at IS NULL", sessionId).
+		Updates(map[string]interface{}{"authority_id": authorityId, "expires_at": expiresAt, "last_seen_at": now})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrSessionInvalid
+	}
+	sessionCache.Store(sessionId, &sessionState{checkedAt: now})
+	return nil
+}
+
+// TouchSession 校验会话是否有效并更新最后活动时间
+// 在 touch-interval 内只校验本地缓存；踢出在本实例立即生效，在其他实例最迟一个间隔后生效
+// 缓存过期后数据库异常时拒绝请求，避免已踢出的会话在其他实例继续可用
+func (s *SessionService) TouchSession(sessionId string) error {
+	if sessionId == "" {
+		return ErrSessionInvalid
+	}
+
+	now := time.Now()
+	if value, ok := sessionCache.Load(sessionId); ok {
+		state := value.(*sessionState)
+		if state.revoked {
+			return ErrSessionInvalid
+		}
+		if now.Sub(state.checkedAt) < sessionTouchInterval() {
+			return nil
+		}
+	}
+	sweepSessionCache(now)
+
+	result := global.DB.Model(&system.SysUserSession{}).
+		Where("session_id = ? AND revoked_at IS NULL AND expires_at > ?", sessionId, now).
+		Update("last_seen_at", now)
+	if result.Error != nil {
+		fmt.Printf("更新会话活动时间失败: %v\n", result.Error)
+		return ErrSessionUnavailable
+	}
+	if result.RowsAffected == 0 {
+		// 并发请求在同一时刻更新时影响行数可能为0，再确认一次会话是否存在
+		var count int64
+		if err := global.DB.Model(&system.SysUserSession{}).
+			Where("session_id = ? AND revoked_at IS NULL AND expires_at > ?", sessionId, now).
+			Count(&count).Error; err != nil {
+			fmt.Printf("查询会话失败: %v\n", err)
+			return ErrSessionUnavailable
+		}
+		if count == 0 {
+			sessionCache.Store(sessionId, &sessionState{checkedAt: now, revoked: true})
+			return ErrSessionInvalid
+		}
+	}
+	sessionCache.Store(sessionId, &sessionState{checkedAt: now})
+	return nil
+}
+
+// RevokeSession 登出或踢出会话
+func (s *SessionService) RevokeSession(sessionId, reason, operator string) error {
+	now := time.Now()
+	result := global.DB.Model(&system.SysUserSession{}).
+		Where("session_id = ? AND revoked_at IS NULL", sessionId).
+		Updates(map[string]interface{}{"revoked_at": now, "revoke_reason": reason, "revoked_by": operator})
+	if result.Error != nil {
+		return result.Error
+	}
+	sessionCache.Store(sessionId, &sessionState{checkedAt: now, revoked: true})
+	if result.RowsAffected == 0 {
+		return ErrSessionInvalid
+	}
+	return nil
+}
+
+// RevokeUserSessions 踢出用户的全部会话，返回踢出数量
+func (s *SessionService) RevokeUserSessions(userID uint, reason, operator string) (int64, error) {
+	var sessionIds []string
+	if err := global.DB.Model(&system.SysUserSession{}).
+		Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now()).
+		Pluck("session_id", &sessionIds).Error; err != nil {
+		return 0, err
+	}
+
+	var count int64
+	for _, sessionId := range sessionIds {
+		if err := s.RevokeSession(sessionId, reason, operator); err == nil {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// onlineQuery 在线会话：未失效、未过期且在空闲时间内有活动
+func onlineQuery() *gorm.DB {
+	now := time.Now()
+	return global.DB.Model(&system.SysUserSession{}).
+		Where("revoked_at IS NULL AND expires_at > ? AND last_seen_at > ?", now, now.Add(-sessionIdleTimeout()))
+}
+
+// GetOnlineSessions 分页获取在线会话
+func (s *SessionService) GetOnlineSessions(req system.OnlineUserRequest) ([]system.SysUserSession, int64, error) {
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.PageSize <= 0 {
+		req.PageSize = 10
+	}
+
+	db := onlineQuery()
+	if req.Username != "" {
+		db = db.Where("username LIKE ?", "%"+req.Username+"%")
+	}
+	if req.IP != "" {
+		db = db.Where("ip LIKE ?", "%"+req.IP+"%")
+	}
+	if req.UserId != 0 {
+		db = db.Where("user_id = ?", req.UserId)
+	}
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var sessions []system.SysUserSession
+	if err := db.Order("last_seen_at DESC").Offset((req.Page - 1) * req.PageSize).Limit(req.PageSize).Find(&sessions).Error; err != nil {
+		return nil, 0, err
+	}
+	return sessions, total, nil
+}
+
+// CountOnlineUsers 统计在线用户数，同一用户多端登录只计一次
+func (s *SessionService) CountOnlineUsers() (int64, error) {
+	var count int64
+	err := onlineQuery().Distinct("user_id").Count(&count).Error
+	return count, err
+}
+
+// sweepSessionCache 定期清理长时间未访问的缓存
+func sweepSessionCache(now time.Time) {
+	sessionSweepMu.Lock()
+	if now.Sub(sessionLastSweep) < sessionIdleTimeout() {
+		sessionSweepMu.Unlock()
+		return
+	}
+	sessionLastSweep = now
+	sessionSweepMu.Unlock()
+
+	sessionCache.Range(func(key, value interface{}) bool {
+		if now.Sub(value.(*sessionState).checkedAt) > sessionIdleTimeout() {
+			sessionCache.Delete(key)
+		}
+		return true
+	})
+}
@@ -15,3 +15,13 @@ func RandomString(length int) string {
 	}
 	return string(b)
 }
+
+// ContainsId 判断ID列表中是否包含指定ID
+func ContainsId(ids []uint, target uint) bool {
+	for _, id := range ids {
+		if id == target {
+			return true
+		}
+	}
+	return false
+}
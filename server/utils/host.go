@@ -0,0 +1,28 @@
+package utils
+
+// HostStats 主机资源使用情况
+type HostStats struct {
+	Hostname    string    `json:"hostname"`
+	OS          string    `json:"os"`
+	Arch        string    `json:"arch"`
+	CPUCores    int       `json:"cpuCores"`
+	CPUUsage    float64   `json:"cpuUsage"` // 百分比
+	Load        []float64 `json:"load"`     // 1、5、15分钟平均负载
+	MemTotal    uint64    `json:"memTotal"` // 字节
+	MemUsed     uint64    `json:"memUsed"`
+	MemUsage    float64   `json:"memUsage"`
+	DiskPath    string    `json:"diskPath"`
+	DiskTotal   uint64    `json:"diskTotal"`
+	DiskUsed    uint64    `json:"diskUsed"`
+	DiskUsage   float64   `json:"diskUsage"`
+	Uptime      int64     `json:"uptime"` // 主机运行时间，单位秒
+	Unsupported bool      `json:"unsupported"`
+}
+
+// percent 计算百分比，保留两位小数
+func percent(used, total float64) float64 {
+	if total <= 0 {
+		return 0
+	}
+	return float64(int64(used/total*10000)) / 100
+}
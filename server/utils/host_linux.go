@@ -0,0 +1,130 @@
+//go:build linux
+
+package utils
+
+import (
+	"bufio"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// ReadHostStats 从/proc读取主机CPU、内存、负载和运行时间，磁盘使用情况取diskPath所在分区
+func ReadHostStats(diskPath string) (HostStats, error) {
+	stats := HostStats{
+		OS:       runtime.GOOS,
+		Arch:     runtime.GOARCH,
+		CPUCores: runtime.NumCPU(),
+		DiskPath: diskPath,
+	}
+	stats.Hostname, _ = os.Hostname()
+
+	// CPU使用率需要两次采样
+	idle1, total1, err := readCPUTimes()
+	if err != nil {
+		return stats, err
+	}
+	time.Sleep(200 * time.Millisecond)
+	idle2, total2, err := readCPUTimes()
+	if err != nil {
+		return stats, err
+	}
+	if total2 > total1 {
+		stats.CPUUsage = percent(float64((total2-total1)-(idle2-idle1)), float64(total2-total1))
+	}
+
+	meminfo, err := readMeminfo()
+	if err != nil {
+		return stats, err
+	}
+	stats.MemTotal = meminfo["MemTotal"]
+	available, ok := meminfo["MemAvailable"]
+	if !ok {
+		available = meminfo["MemFree"] + meminfo["Buffers"] + meminfo["Cached"]
+	}
+	stats.MemUsed = stats.MemTotal - available
+	stats.MemUsage = percent(float64(stats.MemUsed), float64(stats.MemTotal))
+
+	if data, err := os.ReadFile("/proc/loadavg"); err == nil {
+		if fields := strings.Fields(string(data)); len(fields) >= 3 {
+			for _, field := range fields[:3] {
+				load, _ := strconv.ParseFloat(field, 64)
+				stats.Load = append(stats.Load, load)
+			}
+		}
+	}
+
+	if data, err := os.ReadFile("/proc/uptime"); err == nil {
+		if fields := strings.Fields(string(data)); len(fields) > 0 {
+			uptime, _ := strconv.ParseFloat(fields[0], 64)
+			stats.Uptime = int64(uptime)
+		}
+	}
+
+	var fs syscall.Statfs_t
+	if err := syscall.Statfs(diskPath, &fs); err == nil {
+		stats.DiskTotal = fs.Blocks * uint64(fs.Bsize)
+		stats.DiskUsed = (fs.Blocks - fs.Bfree) * uint64(fs.Bsize)
+		stats.DiskUsage = percent(float64(stats.DiskUsed), float64(stats.DiskUsed+fs.Bavail*uint64(fs.Bsize)))
+	}
+
+	return stats, nil
+}
+
+// readCPUTimes 读取/proc/stat中所有CPU的空闲时间和总时间
+func readCPUTimes() (idle, total uint64, err error) {
+	file, err := os.Open("/proc/stat")
+	if err != nil {
+		return 0, 0, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 5 || fields[0] != "cpu" {
+			continue
+		}
+		for i, field := range fields[1:] {
+			value, _ := strconv.ParseUint(field, 10, 64)
+			total += value
+			// 第4、5列为idle和iowait
+			if i == 3 || i == 4 {
+				idle += value
+			}
+		}
+		return idle, total, nil
+	}
+	return 0, 0, scanner.Err()
+}
+
+// readMeminfo 读取/proc/meminfo，单位转换为字节
+func readMeminfo() (map[string]uint64, error) {
+	file, err := os.Open("/proc/meminfo")
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	meminfo := make(map[string]uint64)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), ":")
+		if !ok {
+			continue
+		}
+		fields := strings.Fields(value)
+		if len(fields) == 0 {
+			continue
+		}
+		number, _ := strconv.ParseUint(fields[0], 10, 64)
+		if len(fields) > 1 && fields[1] == "kB" {
+			number *= 1024
+		}
+		meminfo[key] = number
+	}
+	return meminfo, scanner.Err()
+}
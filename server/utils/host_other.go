@@ -0,0 +1,21 @@
+//go:build !linux
+
+package utils
+
+import (
+	"os"
+	"runtime"
+)
+
+// ReadHostStats 非Linux系统不读取主机资源使用情况，只返回基本信息
+func ReadHostStats(diskPath string) (HostStats, error) {
+	stats := HostStats{
+		OS:          runtime.GOOS,
+		Arch:        runtime.GOARCH,
+		CPUCores:    runtime.NumCPU(),
+		DiskPath:    diskPath,
+		Unsupported: true,
+	}
+	stats.Hostname, _ = os.Hostname()
+	return stats, nil
+}
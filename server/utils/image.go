@@ -0,0 +1,248 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+
+	"golang.org/x/image/draw"
+)
+
+const (
+	ImageFormatJPEG = "jpeg"
+	ImageFormatPNG  = "png"
+	ImageFormatGIF  = "gif"
+)
+
+// ErrInvalidImage 文件不是受支持的图片或内容不合法
+var ErrInvalidImage = errors.New("无效的图片文件")
+
+var (
+	pngSignature = []byte{0x89, 'P', 'N', 'G', 0x0D, 0x0A, 0x1A, 0x0A}
+	pngIEND      = []byte{0, 0, 0, 0, 'I', 'E', 'N', 'D', 0xAE, 0x42, 0x60, 0x82}
+)
+
+// polyglotSignatures 图片中出现这些内容时视为同时可被当作网页或脚本解析的伪装文件
+var polyglotSignatures = [][]byte{
+	[]byte("<script"),
+	[]byte("<?php"),
+	[]byte("<html"),
+	[]byte("<!doctype"),
+	[]byte("<svg"),
+	[]byte("<iframe"),
+	[]byte("<body"),
+}
+
+// DetectImageFormat 根据文件头识别图片格式，不信任扩展名和客户端声明的类型
+func DetectImageFormat(data []byte) (string, bool) {
+	switch {
+	case len(data) >= 3 && data[0] == 0xFF && data[1] == 0xD8 && data[2] == 0xFF:
+		return ImageFormatJPEG, true
+	case bytes.HasPrefix(data, pngSignature):
+		return ImageFormatPNG, true
+	case bytes.HasPrefix(data, []byte("GIF87a")), bytes.HasPrefix(data, []byte("GIF89a")):
+		return ImageFormatGIF, true
+	}
+	return "", false
+}
+
+// DecodeImage 识别格式并完整解码图片：拒绝结束标记后附加了数据或包含网页、脚本内容的文件，
+// 拒绝像素数超过maxPixels的图片（maxPixels<=0时不限制），JPEG按EXIF方向旋转为正常方向
+func DecodeImage(data []byte, maxPixels int) (image.Image, string, error) {
+	format, ok := DetectImageFormat(data)
+	if !ok {
+		return nil, "", fmt.Errorf("%w: 只支持 JPEG、PNG、GIF 格式", ErrInvalidImage)
+	}
+	if !hasValidTrailer(format, data) {
+		return nil, "", fmt.Errorf("%w: 图片结束标记后存在附加数据", ErrInvalidImage)
+	}
+	lower := bytes.ToLower(data)
+	for _, signature := range polyglotSignatures {
+		if bytes.Contains(lower, signature) {
+			return nil, "", fmt.Errorf("%w: 图片中包含网页或脚本内容", ErrInvalidImage)
+		}
+	}
+
+	config, configFormat, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || configFormat != format {
+		return nil, "", fmt.Errorf("%w: 无法解析图片", ErrInvalidImage)
+	}
+	if config.Width <= 0 || config.Height <= 0 {
+		return nil, "", fmt.Errorf("%w: 图片尺寸无效", ErrInvalidImage)
+	}
+	if maxPixels > 0 && config.Width*config.Height > maxPixels {
+		return nil, "", fmt.Errorf("%w: 图片尺寸 %dx%d 过大", ErrInvalidImage, config.Width, config.Height)
+	}
+
+	var img image.Image
+	switch format {
+	case ImageFormatJPEG:
+		img, err = jpeg.Decode(bytes.NewReader(data))
+	case ImageFormatPNG:
+		img, err = png.Decode(bytes.NewReader(data))
+	case ImageFormatGIF:
+		img, err = gif.Decode(bytes.NewReader(data))
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: 图片数据损坏", ErrInvalidImage)
+	}
+
+	if format == ImageFormatJPEG {
+		img = applyOrientation(img, jpegOrientation(data))
+	}
+	return img, format, nil
+}
+
+// hasValidTrailer 检查文件以格式规定的结束标记结尾，JPEG允许结尾有填充的0字节
+func hasValidTrailer(format string, data []byte) bool {
+	switch format {
+	case ImageFormatJPEG:
+		return bytes.HasSuffix(bytes.TrimRight(data, "\x00"), []byte{0xFF, 0xD9})
+	case ImageFormatPNG:
+		return bytes.HasSuffix(data, pngIEND)
+	case ImageFormatGIF:
+		return len(data) > 0 && data[len(data)-1] == 0x3B
+	}
+	return false
+}
+
+// ResizeSquare 居中裁剪为正方形后缩放到 size×size
+func ResizeSquare(img image.Image, size int) image.Image {
+	bounds := img.Bounds()
+	side := bounds.Dx()
+	if bounds.Dy() < side {
+		side = bounds.Dy()
+	}
+	crop := image.Rect(0, 0, side, side).Add(image.Pt(
+		bounds.Min.X+(bounds.Dx()-side)/2,
+		bounds.Min.Y+(bounds.Dy()-side)/2,
+	))
+
+	dst := image.NewNRGBA(image.Rect(0, 0, size, size))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, crop, draw.Src, nil)
+	return dst
+}
+
+// EncodeImage 重新编码图片，不写入任何元数据；JPEG保持JPEG，PNG和GIF编码为PNG以保留透明度
+func EncodeImage(w io.Writer, img image.Image, format string, quality int) error {
+	if format == ImageFormatJPEG {
+		if quality <= 0 || quality > 100 {
+			quality = jpeg.DefaultQuality
+		}
+		return jpeg.Encode(w, img, &jpeg.Options{Quality: quality})
+	}
+	return png.Encode(w, img)
+}
+
+// EncodedImageExt EncodeImage输出的文件扩展名和类型
+func EncodedImageExt(format string) (string, string) {
+	if format == ImageFormatJPEG {
+		return ".jpg", "image/jpeg"
+	}
+	return ".png", "image/png"
+}
+
+// jpegOrientation 读取JPEG中EXIF记录的方向，没有记录时返回1
+func jpegOrientation(data []byte) int {
+	offset := 2
+	for offset+4 <= len(data) {
+		if data[offset] != 0xFF {
+			return 1
+		}
+		marker := data[offset+1]
+		// 到达图像数据，之后不会再有EXIF
+		if marker == 0xDA || marker == 0xD9 {
+			return 1
+		}
+		length := int(binary.BigEndian.Uint16(data[offset+2:]))
+		if length < 2 || offset+2+length > len(data) {
+			return 1
+		}
+		segment := data[offset+4 : offset+2+length]
+		if marker == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return exifOrientation(segment[6:])
+		}
+		offset += 2 + length
+	}
+	return 1
+}
+
+// exifOrientation 从TIFF结构的IFD0中读取方向标签(0x0112)
+func exifOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+
+	ifd := int(order.Uint32(tiff[4:]))
+	if ifd < 8 || ifd+2 > len(tiff) {
+		return 1
+	}
+	count := int(order.Uint16(tiff[ifd:]))
+	for i := 0; i < count; i++ {
+		entry := ifd + 2 + i*12
+		if entry+12 > len(tiff) {
+			return 1
+		}
+		if order.Uint16(tiff[entry:]) == 0x0112 {
+			orientation := int(order.Uint16(tiff[entry+8:]))
+			if orientation < 1 || orientation > 8 {
+				return 1
+			}
+			return orientation
+		}
+	}
+	return 1
+}
+
+// applyOrientation 按EXIF方向旋转或翻转图片
+func applyOrientation(img image.Image, orientation int) image.Image {
+	if orientation <= 1 || orientation > 8 {
+		return img
+	}
+
+	bounds := img.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+	dst := image.NewNRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		for x := 0; x < dw; x++ {
+			var sx, sy int
+			switch orientation {
+			case 2: // 水平翻转
+				sx, sy = w-1-x, y
+			case 3: // 旋转180度
+				sx, sy = w-1-x, h-1-y
+			case 4: // 垂直翻转
+				sx, sy = x, h-1-y
+			case 5: // 沿左上-右下对角线翻转
+				sx, sy = y, x
+			case 6: // 顺时针旋转90度
+				sx, sy = y, h-1-x
+			case 7: // 沿右上-左下对角线翻转
+				sx, sy = w-1-y, h-1-x
+			case 8: // 逆时针旋转90度
+				sx, sy = w-1-y, x
+			}
+			dst.Set(x, y, img.At(bounds.Min.X+sx, bounds.Min.Y+sy))
+		}
+	}
+	return dst
+}
@@ -16,16 +16,21 @@ type Claims struct {
 
 var jwtSecret = []byte("go-gin-element-admin-secret-key")
 
-// GenerateToken 生成JWT token
-func GenerateToken(userID uint, username string, authorityId uint) (string, error) {
+// TokenExpiresIn token有效期
+const TokenExpiresIn = 7 * 24 * time.Hour // 7天过期
+
+// GenerateToken 生成JWT token，sessionId写入jti，用于在线会话跟踪和踢出
+func GenerateToken(userID uint, username string, authorityId uint, sessionId string) (string, time.Time, error) {
 	nowTime := time.Now()
-	expireTime := nowTime.Add(7 * 24 * time.Hour) // 7天过期
+	expireTime := nowTime.Add(TokenExpiresIn)
 
 	claims := Claims{
 		UserID:      userID,
 		Username:    username,
 		AuthorityId: authorityId,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        sessionId,
+			IssuedAt:  jwt.NewNumericDate(nowTime),
 			ExpiresAt: jwt.NewNumericDate(expireTime),
 			Issuer:    "go-gin-element-admin",
 		},
@@ -34,7 +39,7 @@ func GenerateToken(userID uint, username string, authorityId uint) (string, erro
 	tokenClaims := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	token, err := tokenClaims.SignedString(jwtSecret)
 
-	return token, err
+	return token, expireTime, err
 }
 
 // ParseToken 解析JWT token
@@ -0,0 +1,63 @@
+package utils
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+)
+
+// MetricsNamespace 指标名前缀
+const MetricsNamespace = "gea"
+
+// MetricsRegistry 应用指标注册表，只暴露这里登记的指标
+var MetricsRegistry = prometheus.NewRegistry()
+
+var (
+	// HTTPRequestsTotal HTTP请求数，route为gin的路由模板，未匹配路由时为 unmatched
+	HTTPRequestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: MetricsNamespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP请求数",
+	}, []string{"route", "method", "status"})
+
+	// HTTPRequestDuration HTTP请求耗时
+	HTTPRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: MetricsNamespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP请求耗时（秒）",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"route", "method", "status"})
+
+	// LoginAttemptsTotal 登录、登出、刷新token次数
+	LoginAttemptsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: MetricsNamespace,
+		Name:      "login_attempts_total",
+		Help:      "登录、登出、刷新token次数",
+	}, []string{"action", "result"})
+
+	// JobRunsTotal 定时任务执行次数
+	JobRunsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: MetricsNamespace,
+		Name:      "job_runs_total",
+		Help:      "定时任务执行次数",
+	}, []string{"job_type", "trigger", "status"})
+
+	// JobRunDuration 定时任务执行耗时
+	JobRunDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: MetricsNamespace,
+		Name:      "job_run_duration_seconds",
+		Help:      "定时任务执行耗时（秒）",
+		Buckets:   []float64{0.1, 0.5, 1, 5, 15, 60, 300, 900},
+	}, []string{"job_type", "status"})
+)
+
+func init() {
+	MetricsRegistry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		HTTPRequestsTotal,
+		HTTPRequestDuration,
+		LoginAttemptsTotal,
+		JobRunsTotal,
+		JobRunDuration,
+	)
+}
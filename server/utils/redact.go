@@ -0,0 +1,289 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"server/config"
+	"strconv"
+	"strings"
+)
+
+const (
+	RedactFull    = "full"
+	RedactPartial = "partial"
+	RedactHash    = "hash"
+)
+
+// redactMask 完全遮盖后的值
+const redactMask = "******"
+
+// DefaultRedactFields 未配置脱敏字段时使用的默认字段
+var DefaultRedactFields = []string{
+	"password", "oldPassword", "newPassword", "confirmPassword", "defaultPassword",
+	"token", "accessToken", "refreshToken", "secret",
+}
+
+// credentialKeywords 字段名包含这些关键字时视为凭据，部分遮盖会留下明文片段，只允许完全遮盖或哈希
+var credentialKeywords = []string{"password", "token", "secret"}
+
+// Redactor 敏感字段脱敏器
+type Redactor struct {
+	strategy string
+	salt     string
+	fields   map[string]bool
+	rules    []config.RedactRule
+}
+
+// NewRedactor 根据配置创建脱敏器
+func NewRedactor(cfg config.Redact) *Redactor {
+	r := &Redactor{
+		strategy: cfg.Strategy,
+		salt:     cfg.HashSalt,
+		fields:   make(map[string]bool),
+		rules:    cfg.Rules,
+	}
+	if r.strategy == "" {
+		r.strategy = RedactFull
+	}
+
+	fields := cfg.Fields
+	if len(fields) == 0 {
+		fields = DefaultRedactFields
+	}
+	for _, field := range fields {
+		r.fields[strings.ToLower(field)] = true
+	}
+	return r
+}
+
+// IsSensitiveField 判断字段名是否需要脱敏
+func (r *Redactor) IsSensitiveField(name string) bool {
+	return r.fields[strings.ToLower(name)]
+}
+
+// fieldStrategy 获取字段的脱敏方式，凭据字段不论配置如何都不做部分遮盖
+func fieldStrategy(name, strategy string) string {
+	if strategy != RedactPartial {
+		return strategy
+	}
+	name = strings.ToLower(name)
+	for _, keyword := range credentialKeywords {
+		if strings.Contains(name, keyword) {
+			return RedactFull
+		}
+	}
+	return strategy
+}
+
+// Redact 对请求或响应数据脱敏，target为request或response
+// 返回的数据为通用JSON结构，原数据为结构体时不会被修改
+func (r *Redactor) Redact(method, path, target string, data interface{}) interface{} {
+	if data == nil {
+		return nil
+	}
+
+	data = toGenericJSON(data)
+	data = r.redactFields(data)
+
+	for _, rule := range r.rules {
+		if !r.matchRule(rule, method, path, target) {
+			continue
+		}
+		strategy := rule.Strategy
+		if strategy == "" {
+			strategy = r.strategy
+		}
+		for _, jsonPath := range rule.Paths {
+			data = r.redactPath(data, parseJSONPath(jsonPath), strategy)
+		}
+	}
+	return data
+}
+
+// Mask 按指定方式遮盖值
+func (r *Redactor) Mask(value interface{}, strategy string) interface{} {
+	if value == nil {
+		return nil
+	}
+
+	var text string
+	switch v := value.(type) {
+	case string:
+		text = v
+	case map[string]interface{}, []interface{}:
+		// 对象和数组无法部分保留，统一完全遮盖
+		if strategy != RedactHash {
+			return redactMask
+		}
+		bytes, _ := json.Marshal(v)
+		text = string(bytes)
+	default:
+		text = fmt.Sprint(v)
+	}
+
+	switch strategy {
+	case RedactPartial:
+		runes := []rune(text)
+		keep := len(runes) / 4
+		if keep > 4 {
+			keep = 4
+		}
+		if keep == 0 {
+			return redactMask
+		}
+		return string(runes[:keep]) + "****" + string(runes[len(runes)-keep:])
+	case RedactHash:
+		mac := hmac.New(sha256.New, []byte(r.salt))
+		mac.Write([]byte(text))
+		return "sha256:" + hex.EncodeToString(mac.Sum(nil))
+	default:
+		return redactMask
+	}
+}
+
+// redactFields 递归遮盖敏感字段
+func (r *Redactor) redactFields(data interface{}) interface{} {
+	switch v := data.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			if r.IsSensitiveField(key) {
+				v[key] = r.Mask(value, fieldStrategy(key, r.strategy))
+			} else {
+				v[key] = r.redactFields(value)
+			}
+		}
+	case []interface{}:
+		for i, value := range v {
+			v[i] = r.redactFields(value)
+		}
+	}
+	return data
+}
+
+// redactPath 遮盖JSON路径指向的值，* 匹配数组的所有元素或对象的所有字段
+func (r *Redactor) redactPath(data interface{}, segments []string, strategy string) interface{} {
+	if len(segments) == 0 {
+		return r.Mask(data, strategy)
+	}
+
+	segment, rest := segments[0], segments[1:]
+	switch v := data.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			if segment == "*" || strings.EqualFold(key, segment) {
+				v[key] = r.redactPath(value, rest, fieldStrategy(key, strategy))
+			}
+		}
+	case []interface{}:
+		if segment == "*" {
+			for i, value := range v {
+				v[i] = r.redactPath(value, rest, strategy)
+			}
+		} else if index, err := strconv.Atoi(segment); err == nil && index >= 0 && index < len(v) {
+			v[index] = r.redactPath(v[index], rest, strategy)
+		}
+	}
+	return data
+}
+
+// matchRule 判断规则是否适用于当前请求
+func (r *Redactor) matchRule(rule config.RedactRule, method, path, target string) bool {
+	if rule.Method != "" && !strings.EqualFold(rule.Method, method) {
+		return false
+	}
+	if rule.Target != "" && rule.Target != target {
+		return false
+	}
+	return matchRoutePath(rule.Path, path)
+}
+
+// matchRoutePath 匹配路由路径，* 和 :param 匹配任意单段路径
+func matchRoutePath(pattern, path string) bool {
+	if pattern == "" {
+		return true
+	}
+
+	patternParts := strings.Split(strings.Trim(pattern, "/"), "/")
+	pathParts := strings.Split(strings.Trim(path, "/"), "/")
+	if len(patternParts) != len(pathParts) {
+		return false
+	}
+	for i, part := range patternParts {
+		if part == "*" || strings.HasPrefix(part, ":") {
+			continue
+		}
+		if part != pathParts[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// parseJSONPath 解析JSON路径，支持 $.data.list[*].phone 形式
+func parseJSONPath(path string) []string {
+	path = strings.TrimPrefix(strings.TrimPrefix(path, "$"), ".")
+	path = strings.NewReplacer("[", ".", "]", "").Replace(path)
+
+	var segments []string
+	for _, segment := range strings.Split(path, ".") {
+		if segment != "" {
+			segments = append(segments, segment)
+		}
+	}
+	return segments
+}
+
+// toGenericJSON 将任意数据转换为通用JSON结构，便于按字段处理
+func toGenericJSON(data interface{}) interface{} {
+	switch data.(type) {
+	case map[string]interface{}, []interface{}:
+		return data
+	}
+
+	bytes, err := json.Marshal(data)
+	if err != nil {
+		return data
+	}
+	var generic interface{}
+	if err := json.Unmarshal(bytes, &generic); err != nil {
+		return data
+	}
+	return generic
+}
+
+// FindUnredacted 查找仍为明文的敏感字段，返回其JSON路径
+func (r *Redactor) FindUnredacted(data interface{}) []string {
+	var found []string
+	var walk func(value interface{}, path string)
+	walk = func(value interface{}, path string) {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			for key, child := range v {
+				childPath := strings.TrimPrefix(path+"."+key, ".")
+				if r.IsSensitiveField(key) && !isRedacted(child) {
+					found = append(found, childPath)
+					continue
+				}
+				walk(child, childPath)
+			}
+		case []interface{}:
+			for i, child := range v {
+				walk(child, fmt.Sprintf("%s[%d]", path, i))
+			}
+		}
+	}
+	walk(toGenericJSON(data), "")
+	return found
+}
+
+// isRedacted 判断值是否已经脱敏
+func isRedacted(value interface{}) bool {
+	text, ok := value.(string)
+	if !ok {
+		return value == nil
+	}
+	return text == "" || strings.Contains(text, "****") || strings.HasPrefix(text, "sha256:")
+}
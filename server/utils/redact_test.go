@@ -0,0 +1,264 @@
+package utils
+
+import (
+	"encoding/json"
+	"server/config"
+	"strings"
+	"testing"
+)
+
+func TestRedactorMask(t *testing.T) {
+	r := NewRedactor(config.Redact{HashSalt: "salt"})
+
+	tests := []struct {
+		name     string
+		value    interface{}
+		strategy string
+		want     interface{}
+	}{
+		{"full", "Secret#123", RedactFull, redactMask},
+		{"default strategy is full", "Secret#123", "", redactMask},
+		{"partial", "13812345678", RedactPartial, "13****78"},
+		{"partial keeps at most four runes", "abcdefghijklmnopqrstuvwxyz", RedactPartial, "abcd****wxyz"},
+		{"partial short value", "abc", RedactPartial, redactMask},
+		{"partial object", map[string]interface{}{"a": "b"}, RedactPartial, redactMask},
+		{"number", 123456, RedactFull, redactMask},
+		{"nil", nil, RedactFull, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := r.Mask(tt.value, tt.strategy); got != tt.want {
+				t.Errorf("Mask(%v, %q) = %v, want %v", tt.value, tt.strategy, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRedactorMaskHash(t *testing.T) {
+	r := NewRedactor(config.Redact{HashSalt: "salt"})
+
+	first := r.Mask("Secret#123", RedactHash).(string)
+	if !strings.HasPrefix(first, "sha256:") || strings.Contains(first, "Secret") {
+		t.Fatalf("unexpected hash %q", first)
+	}
+	if second := r.Mask("Secret#123", RedactHash); second != first {
+		t.Errorf("hash is not stable: %q != %q", second, first)
+	}
+	if other := r.Mask("Secret#124", RedactHash); other == first {
+		t.Error("different values got the same hash")
+	}
+	if salted := NewRedactor(config.Redact{HashSalt: "other"}).Mask("Secret#123", RedactHash); salted == first {
+		t.Error("hash does not depend on salt")
+	}
+}
+
+func TestRedactorRedactFields(t *testing.T) {
+	r := NewRedactor(config.Redact{})
+
+	data := map[string]interface{}{
+		"username": "admin",
+		"Password": "Secret#123",
+		"data": map[string]interface{}{
+			"token": "jwt-token",
+			"list": []interface{}{
+				map[string]interface{}{"refreshToken": "refresh-token", "name": "a"},
+			},
+		},
+	}
+	got := marshalString(t, r.Redact("POST", "/api/base/login", "request", data))
+
+	for _, secret := range []string{"Secret#123", "jwt-token", "refresh-token"} {
+		if strings.Contains(got, secret) {
+			t.Errorf("secret %q not redacted: %s", secret, got)
+		}
+	}
+	for _, kept := range []string{`"username":"admin"`, `"name":"a"`} {
+		if !strings.Contains(got, kept) {
+			t.Errorf("%s missing from %s", kept, got)
+		}
+	}
+}
+
+func TestRedactorPartialKeepsNoCredentialRunes(t *testing.T) {
+	r := NewRedactor(config.Redact{
+		Strategy: RedactPartial,
+		Fields:   append([]string{"phone"}, DefaultRedactFields...),
+		Rules: []config.RedactRule{
+			{Paths: []string{"data.apiSecret"}, Strategy: RedactPartial},
+		},
+	})
+
+	const password = "abcdefghijklmnopqrstuvwxyz"
+	data := map[string]interface{}{
+		"password":    password,
+		"newPassword": password,
+		"phone":       "13812345678",
+		"data":        map[string]interface{}{"accessToken": password, "apiSecret": password},
+	}
+	got := marshalString(t, r.Redact("POST", "/api/system/user/password", "request", data))
+
+	for _, field := range []string{"password", "newPassword"} {
+		if data[field] != redactMask {
+			t.Errorf("%s = %v, want %q", field, data[field], redactMask)
+		}
+	}
+	nested := data["data"].(map[string]interface{})
+	for _, field := range []string{"accessToken", "apiSecret"} {
+		if nested[field] != redactMask {
+			t.Errorf("data.%s = %v, want %q", field, nested[field], redactMask)
+		}
+	}
+	for _, part := range []string{"abcd", "wxyz"} {
+		if strings.Contains(got, part) {
+			t.Errorf("credential runes %q persisted in %s", part, got)
+		}
+	}
+	if data["phone"] != "13****78" {
+		t.Errorf("phone = %v, want partially masked", data["phone"])
+	}
+}
+
+func TestRedactorRedactStructIsNotModified(t *testing.T) {
+	r := NewRedactor(config.Redact{})
+
+	type loginRequest struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+	}
+	req := loginRequest{Username: "admin", Password: "Secret#123"}
+	got := marshalString(t, r.Redact("POST", "/api/base/login", "request", req))
+
+	if strings.Contains(got, "Secret#123") {
+		t.Errorf("password not redacted: %s", got)
+	}
+	if req.Password != "Secret#123" {
+		t.Errorf("original struct modified: %+v", req)
+	}
+}
+
+func TestRedactorRules(t *testing.T) {
+	r := NewRedactor(config.Redact{
+		Fields: []string{"password"},
+		Rules: []config.RedactRule{
+			{Method: "POST", Path: "/api/base/login", Target: "response", Paths: []string{"data.token"}},
+			{Path: "/api/system/user/:id", Paths: []string{"$.data.list[*].phone", "data.list[0].email"}, Strategy: RedactPartial},
+		},
+	})
+
+	tests := []struct {
+		name    string
+		method  string
+		path    string
+		target  string
+		data    string
+		want    []string
+		notWant []string
+	}{
+		{
+			name:    "method path and target match",
+			method:  "POST",
+			path:    "/api/base/login",
+			target:  "response",
+			data:    `{"code":0,"data":{"token":"jwt-token","expiresAt":1}}`,
+			want:    []string{`"token":"******"`, `"expiresAt":1`},
+			notWant: []string{"jwt-token"},
+		},
+		{
+			name:   "target mismatch",
+			method: "POST",
+			path:   "/api/base/login",
+			target: "request",
+			data:   `{"data":{"token":"jwt-token"}}`,
+			want:   []string{"jwt-token"},
+		},
+		{
+			name:   "method mismatch",
+			method: "GET",
+			path:   "/api/base/login",
+			target: "response",
+			data:   `{"data":{"token":"jwt-token"}}`,
+			want:   []string{"jwt-token"},
+		},
+		{
+			name:    "wildcard and index",
+			method:  "GET",
+			path:    "/api/system/user/list",
+			target:  "response",
+			data:    `{"data":{"list":[{"phone":"13812345678","email":"admin@example.com"},{"phone":"13987654321","email":"user@example.com"}]}}`,
+			want:    []string{`"phone":"13****78"`, `"phone":"13****21"`, `"email":"admi****.com"`, `"email":"user@example.com"`},
+			notWant: []string{"13812345678", "13987654321", "admin@example.com"},
+		},
+		{
+			name:   "path segment count mismatch",
+			method: "GET",
+			path:   "/api/system/user/list/all",
+			target: "response",
+			data:   `{"data":{"list":[{"phone":"13812345678"}]}}`,
+			want:   []string{"13812345678"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var data interface{}
+			if err := json.Unmarshal([]byte(tt.data), &data); err != nil {
+				t.Fatal(err)
+			}
+			got := marshalString(t, r.Redact(tt.method, tt.path, tt.target, data))
+			for _, want := range tt.want {
+				if !strings.Contains(got, want) {
+					t.Errorf("%s missing from %s", want, got)
+				}
+			}
+			for _, notWant := range tt.notWant {
+				if strings.Contains(got, notWant) {
+					t.Errorf("%s not redacted: %s", notWant, got)
+				}
+			}
+		})
+	}
+}
+
+func TestParseJSONPath(t *testing.T) {
+	tests := map[string]string{
+		"$.data.list[*].phone": "data/list/*/phone",
+		"data.token":           "data/token",
+		"list[0]":              "list/0",
+		"$":                    "",
+	}
+	for path, want := range tests {
+		if got := strings.Join(parseJSONPath(path), "/"); got != want {
+			t.Errorf("parseJSONPath(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestRedactorFindUnredacted(t *testing.T) {
+	r := NewRedactor(config.Redact{})
+
+	data := map[string]interface{}{
+		"password": "******",
+		"data": map[string]interface{}{
+			"token": "jwt-token",
+			"list":  []interface{}{map[string]interface{}{"secret": "plain"}},
+		},
+	}
+	got := r.FindUnredacted(data)
+	want := map[string]bool{"data.token": true, "data.list[0].secret": true}
+	if len(got) != len(want) {
+		t.Fatalf("FindUnredacted = %v, want %v", got, want)
+	}
+	for _, path := range got {
+		if !want[path] {
+			t.Errorf("unexpected path %q", path)
+		}
+	}
+}
+
+func marshalString(t *testing.T, data interface{}) string {
+	t.Helper()
+	bytes, err := json.Marshal(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(bytes)
+}
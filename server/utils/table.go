@@ -0,0 +1,168 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+const (
+	TableFormatCSV  = "csv"
+	TableFormatXLSX = "xlsx"
+)
+
+// TableWriter 表格写入器，逐行写出CSV或XLSX
+type TableWriter interface {
+	WriteRow(values []string) error
+	Close() error
+}
+
+// NewTableWriter 根据格式创建表格写入器，sheet仅对XLSX有效
+func NewTableWriter(format string, w io.Writer, sheet string) (TableWriter, error) {
+	switch format {
+	case TableFormatCSV:
+		// 写入UTF-8 BOM，保证Excel打开中文不乱码
+		if _, err := w.Write([]byte("\xEF\xBB\xBF")); err != nil {
+			return nil, err
+		}
+		return &csvTableWriter{writer: csv.NewWriter(w)}, nil
+	case TableFormatXLSX:
+		return newXlsxTableWriter(w, sheet)
+	default:
+		return nil, errors.New("不支持的文件格式: " + format)
+	}
+}
+
+// TableContentType 获取表格格式对应的Content-Type
+func TableContentType(format string) string {
+	if format == TableFormatXLSX {
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// TableFormatFromFilename 根据文件扩展名判断表格格式
+func TableFormatFromFilename(filename string) (string, error) {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".csv":
+		return TableFormatCSV, nil
+	case ".xlsx":
+		return TableFormatXLSX, nil
+	default:
+		return "", errors.New("只支持 CSV、XLSX 格式的文件")
+	}
+}
+
+// ReadTable 读取CSV或XLSX（第一个工作表）的全部行
+func ReadTable(format string, r io.Reader) ([][]string, error) {
+	switch format {
+	case TableFormatCSV:
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return nil, err
+		}
+		reader := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte("\xEF\xBB\xBF"))))
+		reader.FieldsPerRecord = -1
+		reader.TrimLeadingSpace = true
+		return reader.ReadAll()
+	case TableFormatXLSX:
+		f, err := excelize.OpenReader(r)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+
+		sheets := f.GetSheetList()
+		if len(sheets) == 0 {
+			return nil, errors.New("文件中没有工作表")
+		}
+		return f.GetRows(sheets[0])
+	default:
+		return nil, errors.New("不支持的文件格式: " + format)
+	}
+}
+
+type csvTableWriter struct {
+	writer *csv.Writer
+}
+
+func (w *csvTableWriter) WriteRow(values []string) error {
+	escaped := make([]string, len(values))
+	for i, value := range values {
+		escaped[i] = escapeFormula(value)
+	}
+	if err := w.writer.Write(escaped); err != nil {
+		return err
+	}
+	// 及时刷新，避免大数据量导出时占用内存
+	w.writer.Flush()
+	return w.writer.Error()
+}
+
+// escapeFormula 以 = + - @ 或制表符、回车开头的单元格在Excel中会被当作公式执行，加 ' 前缀按文本处理
+func escapeFormula(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+func (w *csvTableWriter) Close() error {
+	w.writer.Flush()
+	return w.writer.Error()
+}
+
+type xlsxTableWriter struct {
+	file   *excelize.File
+	stream *excelize.StreamWriter
+	output io.Writer
+	row    int
+}
+
+func newXlsxTableWriter(w io.Writer, sheet string) (*xlsxTableWriter, error) {
+	if sheet == "" {
+		sheet = "Sheet1"
+	}
+
+	f := excelize.NewFile()
+	if sheet != "Sheet1" {
+		if err := f.SetSheetName("Sheet1", sheet); err != nil {
+			return nil, err
+		}
+	}
+
+	// StreamWriter按行写入临时文件，避免整张表驻留内存
+	stream, err := f.NewStreamWriter(sheet)
+	if err != nil {
+		return nil, err
+	}
+	return &xlsxTableWriter{file: f, stream: stream, output: w}, nil
+}
+
+func (w *xlsxTableWriter) WriteRow(values []string) error {
+	w.row++
+	cell, err := excelize.CoordinatesToCellName(1, w.row)
+	if err != nil {
+		return err
+	}
+
+	row := make([]interface{}, len(values))
+	for i, v := range values {
+		row[i] = v
+	}
+	return w.stream.SetRow(cell, row)
+}
+
+func (w *xlsxTableWriter) Close() error {
+	defer w.file.Close()
+	if err := w.stream.Flush(); err != nil {
+		return err
+	}
+	_, err := w.file.WriteTo(w.output)
+	return err
+}
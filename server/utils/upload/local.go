@@ -0,0 +1,142 @@
+package upload
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"server/config"
+	"strings"
+)
+
+// Local 本地磁盘存储
+type Local struct {
+	root string
+	base string
+}
+
+// NewLocal 创建本地磁盘存储，未配置时使用 ./uploads 目录和 /uploads 访问前缀
+func NewLocal(cfg config.LocalOss) *Local {
+	root := cfg.Path
+	if root == "" {
+		root = "./uploads"
+	}
+	base := strings.TrimRight(cfg.BaseURL, "/")
+	if base == "" {
+		base = "/uploads"
+	}
+	return &Local{root: root, base: base}
+}
+
+// Root 存储目录
+func (l *Local) Root() string {
+	return l.root
+}
+
+// BaseURL 访问地址前缀
+func (l *Local) BaseURL() string {
+	return l.base
+}
+
+func (l *Local) path(key string) (string, error) {
+	key, err := CleanKey(key)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(l.root, filepath.FromSlash(key)), nil
+}
+
+// Put 先写入临时文件再重命名，避免读到写了一半的文件
+func (l *Local) Put(ctx context.Context, key string, reader io.Reader, size int64, contentType string) error {
+	name, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(name), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, reader); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), name)
+}
+
+func (l *Local) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	name, err := l.path(key)
+	if err != nil {
+		return nil, err
+	}
+	return os.Open(name)
+}
+
+func (l *Local) Delete(ctx context.Context, key string) error {
+	name, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(name); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (l *Local) URL(ctx context.Context, key string) (string, error) {
+	key, err := CleanKey(key)
+	if err != nil {
+		return "", err
+	}
+	return l.base + "/" + key, nil
+}
+
+// KeyFromURL 相对前缀时只比较路径，地址可以带有协议和域名
+func (l *Local) KeyFromURL(rawURL string) (string, bool) {
+	if strings.HasPrefix(l.base, "/") {
+		parsed, err := url.Parse(rawURL)
+		if err != nil {
+			return "", false
+		}
+		return keyWithPrefix(parsed.Path, l.base)
+	}
+	return keyWithPrefix(rawURL, l.base)
+}
+
+func (l *Local) Private() bool {
+	return false
+}
+
+// Check 检查存储目录可写
+func (l *Local) Check(ctx context.Context) error {
+	if err := os.MkdirAll(l.root, 0755); err != nil {
+		return err
+	}
+	file, err := os.CreateTemp(l.root, ".health-*")
+	if err != nil {
+		return err
+	}
+	name := file.Name()
+	_, err = file.Write([]byte("ok"))
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if removeErr := os.Remove(name); err == nil {
+		err = removeErr
+	}
+	return err
+}
@@ -0,0 +1,128 @@
+package upload
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"server/config"
+	"server/global"
+	"strings"
+	"sync"
+)
+
+const (
+	TypeLocal = "local"
+	TypeS3    = "s3"
+	TypeMinio = "minio"
+)
+
+// ObjectRoute 私有存储的对象通过该路由跳转到预签名地址，保存到数据库的是这个稳定地址
+const ObjectRoute = "/api/upload/object/"
+
+// ErrInvalidKey 对象键为空或包含非法路径
+var ErrInvalidKey = errors.New("无效的文件路径")
+
+// OSS 文件存储，key为 avatar/xxx.png 形式的相对路径
+type OSS interface {
+	// Put 写入对象，size未知时传-1
+	Put(ctx context.Context, key string, reader io.Reader, size int64, contentType string) error
+	// Open 读取对象，对象不存在时返回 fs.ErrNotExist
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete 删除对象，对象不存在时不返回错误
+	Delete(ctx context.Context, key string) error
+	// URL 获取可直接访问的地址，私有存储返回有时效的预签名地址
+	URL(ctx context.Context, key string) (string, error)
+	// Private 对象是否只能通过预签名地址访问
+	Private() bool
+	// Check 检查存储是否可用
+	Check(ctx context.Context) error
+	// KeyFromURL 从URL返回的访问地址中解析对象键，不属于该存储的地址返回false
+	KeyFromURL(rawURL string) (string, bool)
+}
+
+var (
+	storageMu sync.Mutex
+	storage   OSS
+)
+
+// NewOss 按配置创建文件存储
+func NewOss(cfg config.Oss) (OSS, error) {
+	switch cfg.Type {
+	case "", TypeLocal:
+		return NewLocal(cfg.Local), nil
+	case TypeS3:
+		return NewS3(cfg.S3)
+	case TypeMinio:
+		// MinIO 默认只支持路径风格访问
+		cfg.S3.PathStyle = true
+		return NewS3(cfg.S3)
+	default:
+		return nil, fmt.Errorf("不支持的存储类型: %s", cfg.Type)
+	}
+}
+
+// Storage 获取按全局配置创建的文件存储
+func Storage() (OSS, error) {
+	storageMu.Lock()
+	defer storageMu.Unlock()
+	if storage != nil {
+		return storage, nil
+	}
+	oss, err := NewOss(global.CONFIG.Oss)
+	if err != nil {
+		return nil, err
+	}
+	storage = oss
+	return storage, nil
+}
+
+// CleanKey 规范化对象键，拒绝绝对路径和跳出存储目录的路径
+func CleanKey(key string) (string, error) {
+	key = strings.TrimLeft(strings.ReplaceAll(key, "\\", "/"), "/")
+	if key == "" {
+		return "", ErrInvalidKey
+	}
+	cleaned := path.Clean(key)
+	if cleaned == "." || cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return "", ErrInvalidKey
+	}
+	return cleaned, nil
+}
+
+// LinkURL 获取可以长期保存的访问地址，私有存储返回经由服务端跳转的地址
+func LinkURL(ctx context.Context, oss OSS, key string) (string, error) {
+	if oss.Private() {
+		key, err := CleanKey(key)
+		if err != nil {
+			return "", err
+		}
+		return ObjectRoute + key, nil
+	}
+	return oss.URL(ctx, key)
+}
+
+// KeyFromURL 从 LinkURL 返回的地址中解析对象键，地址可以带有协议和域名
+func KeyFromURL(oss OSS, rawURL string) (string, bool) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return "", false
+	}
+	if key, ok := strings.CutPrefix(parsed.Path, ObjectRoute); ok {
+		key, err := CleanKey(key)
+		return key, err == nil
+	}
+	return oss.KeyFromURL(rawURL)
+}
+
+// keyWithPrefix 地址以base开头时返回去掉base后的对象键
+func keyWithPrefix(rawURL, base string) (string, bool) {
+	key, ok := strings.CutPrefix(rawURL, strings.TrimRight(base, "/")+"/")
+	if !ok {
+		return "", false
+	}
+	key, err := CleanKey(key)
+	return key, err == nil
+}
@@ -0,0 +1,177 @@
+package upload
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"path"
+	"server/config"
+	"strings"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// defaultPresignExpires 未配置时预签名地址的有效期
+const defaultPresignExpires = time.Hour
+
+// S3 S3兼容存储，同时用于AWS S3和MinIO
+type S3 struct {
+	client  *minio.Client
+	cfg     config.S3Oss
+	expires time.Duration
+}
+
+// NewS3 创建S3兼容存储
+func NewS3(cfg config.S3Oss) (*S3, error) {
+	if cfg.Endpoint == "" || cfg.Bucket == "" {
+		return nil, errors.New("S3存储需要配置 endpoint 和 bucket")
+	}
+
+	lookup := minio.BucketLookupAuto
+	if cfg.PathStyle {
+		lookup = minio.BucketLookupPath
+	}
+	client, err := minio.New(cfg.Endpoint, &minio.Options{
+		Creds:        credentials.NewStaticV4(cfg.AccessKey, cfg.SecretKey, ""),
+		Secure:       cfg.UseSSL,
+		Region:       cfg.Region,
+		BucketLookup: lookup,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	expires := time.Duration(cfg.PresignExpires) * time.Second
+	if expires <= 0 {
+		expires = defaultPresignExpires
+	}
+	return &S3{client: client, cfg: cfg, expires: expires}, nil
+}
+
+// objectName 对象键加上配置的前缀
+func (s *S3) objectName(key string) (string, error) {
+	key, err := CleanKey(key)
+	if err != nil {
+		return "", err
+	}
+	if prefix := strings.Trim(s.cfg.Prefix, "/"); prefix != "" {
+		key = prefix + "/" + key
+	}
+	return key, nil
+}
+
+func (s *S3) Put(ctx context.Context, key string, reader io.Reader, size int64, contentType string) error {
+	name, err := s.objectName(key)
+	if err != nil {
+		return err
+	}
+	_, err = s.client.PutObject(ctx, s.cfg.Bucket, name, reader, size, minio.PutObjectOptions{
+		ContentType: contentType,
+	})
+	return err
+}
+
+// Open 读取对象，对象不存在时在首次读取前就返回错误
+func (s *S3) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	name, err := s.objectName(key)
+	if err != nil {
+		return nil, err
+	}
+	object, err := s.client.GetObject(ctx, s.cfg.Bucket, name, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, err
+	}
+	if _, err := object.Stat(); err != nil {
+		object.Close()
+		if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+			return nil, fs.ErrNotExist
+		}
+		return nil, err
+	}
+	return object, nil
+}
+
+func (s *S3) Delete(ctx context.Context, key string) error {
+	name, err := s.objectName(key)
+	if err != nil {
+		return err
+	}
+	return s.client.RemoveObject(ctx, s.cfg.Bucket, name, minio.RemoveObjectOptions{})
+}
+
+func (s *S3) URL(ctx context.Context, key string) (string, error) {
+	name, err := s.objectName(key)
+	if err != nil {
+		return "", err
+	}
+
+	if s.cfg.Presign {
+		signed, err := s.client.PresignedGetObject(ctx, s.cfg.Bucket, name, s.expires, url.Values{})
+		if err != nil {
+			return "", err
+		}
+		return signed.String(), nil
+	}
+
+	if s.cfg.BaseURL != "" {
+		return strings.TrimRight(s.cfg.BaseURL, "/") + "/" + name, nil
+	}
+	endpoint := *s.client.EndpointURL()
+	if s.cfg.PathStyle {
+		endpoint.Path = path.Join("/", s.cfg.Bucket, name)
+	} else {
+		endpoint.Host = s.cfg.Bucket + "." + endpoint.Host
+		endpoint.Path = "/" + name
+	}
+	return endpoint.String(), nil
+}
+
+// KeyFromURL 解析公开访问地址，预签名地址不会保存，不做解析
+func (s *S3) KeyFromURL(rawURL string) (string, bool) {
+	var name string
+	var ok bool
+	if s.cfg.BaseURL != "" {
+		name, ok = keyWithPrefix(rawURL, s.cfg.BaseURL)
+	} else {
+		parsed, err := url.Parse(rawURL)
+		if err != nil {
+			return "", false
+		}
+		endpoint := s.client.EndpointURL()
+		switch {
+		case s.cfg.PathStyle && parsed.Host == endpoint.Host:
+			name, ok = keyWithPrefix(parsed.Path, "/"+s.cfg.Bucket)
+		case !s.cfg.PathStyle && parsed.Host == s.cfg.Bucket+"."+endpoint.Host:
+			name, ok = keyWithPrefix(parsed.Path, "")
+		}
+	}
+	if !ok {
+		return "", false
+	}
+
+	if prefix := strings.Trim(s.cfg.Prefix, "/"); prefix != "" {
+		return keyWithPrefix(name, prefix)
+	}
+	return name, true
+}
+
+func (s *S3) Private() bool {
+	return s.cfg.Presign
+}
+
+// Check 检查存储桶存在且可访问
+func (s *S3) Check(ctx context.Context) error {
+	exists, err := s.client.BucketExists(ctx, s.cfg.Bucket)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("存储桶 %s 不存在", s.cfg.Bucket)
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package utils
+
+import (
+	"regexp"
+	"strings"
+)
+
+// uaBrowsers 浏览器识别规则，按顺序匹配，Edge、Opera等基于Chrome内核的浏览器需排在Chrome之前
+var uaBrowsers = []struct {
+	name    string
+	pattern *regexp.Regexp
+}{
+	{"微信", regexp.MustCompile(`MicroMessenger/([\d.]+)`)},
+	{"Edge", regexp.MustCompile(`Edg(?:e|A|iOS)?/([\d.]+)`)},
+	{"Opera", regexp.MustCompile(`(?:OPR|Opera)/([\d.]+)`)},
+	{"Firefox", regexp.MustCompile(`(?:Firefox|FxiOS)/([\d.]+)`)},
+	{"Chrome", regexp.MustCompile(`(?:Chrome|CriOS)/([\d.]+)`)},
+	{"Safari", regexp.MustCompile(`Version/([\d.]+).*Safari/`)},
+	{"IE", regexp.MustCompile(`(?:MSIE |Trident/.*rv:)([\d.]+)`)},
+	{"curl", regexp.MustCompile(`^curl/([\d.]+)`)},
+	{"Postman", regexp.MustCompile(`PostmanRuntime/([\d.]+)`)},
+	{"Go", regexp.MustCompile(`Go-http-client/([\d.]+)`)},
+}
+
+var (
+	uaWindows = regexp.MustCompile(`Windows NT ([\d.]+)`)
+	uaAndroid = regexp.MustCompile(`Android ([\d.]+)`)
+	uaIOS     = regexp.MustCompile(`(?:iPhone|CPU) OS ([\d_]+)`)
+	uaMac     = regexp.MustCompile(`Mac OS X ([\d_.]+)`)
+)
+
+var windowsVersions = map[string]string{
+	"10.0": "10",
+	"6.3":  "8.1",
+	"6.2":  "8",
+	"6.1":  "7",
+	"6.0":  "Vista",
+	"5.1":  "XP",
+}
+
+// ParseUserAgent 从User-Agent中解析浏览器和操作系统，无法识别时返回"未知"
+func ParseUserAgent(userAgent string) (browser string, os string) {
+	browser, os = "未知", "未知"
+	if userAgent == "" {
+		return
+	}
+
+	for _, rule := range uaBrowsers {
+		if match := rule.pattern.FindStringSubmatch(userAgent); match != nil {
+			browser = rule.name + " " + majorVersion(match[1])
+			break
+		}
+	}
+
+	switch {
+	case uaWindows.MatchString(userAgent):
+		version := uaWindows.FindStringSubmatch(userAgent)[1]
+		if name, ok := windowsVersions[version]; ok {
+			os = "Windows " + name
+		} else {
+			os = "Windows"
+		}
+	case uaAndroid.MatchString(userAgent):
+		os = "Android " + uaAndroid.FindStringSubmatch(userAgent)[1]
+	case strings.Contains(userAgent, "iPad"):
+		os = "iPadOS"
+		if match := uaIOS.FindStringSubmatch(userAgent); match != nil {
+			os += " " + strings.ReplaceAll(match[1], "_", ".")
+		}
+	case uaIOS.MatchString(userAgent) && strings.Contains(userAgent, "iPhone"):
+		os = "iOS " + strings.ReplaceAll(uaIOS.FindStringSubmatch(userAgent)[1], "_", ".")
+	case uaMac.MatchString(userAgent):
+		os = "macOS " + strings.ReplaceAll(uaMac.FindStringSubmatch(userAgent)[1], "_", ".")
+	case strings.Contains(userAgent, "CrOS"):
+		os = "Chrome OS"
+	case strings.Contains(userAgent, "Linux"):
+		os = "Linux"
+	}
+	return
+}
+
+// majorVersion 只保留主版本号，避免统计时版本过于分散
+func majorVersion(version string) string {
+	if i := strings.Index(version, "."); i > 0 {
+		return version[:i]
+	}
+	return version
+}
@@ -0,0 +1,10 @@
+import request from '@/utils/request'
+
+// 获取实体的变更历史（entityType: user、authority、menu、authority_menu）
+export function getEntityChanges(params) {
+  return request({
+    url: '/system/entity-change/list',
+    method: 'get',
+    params
+  })
+}
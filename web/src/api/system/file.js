@@ -0,0 +1,118 @@
+import request from '@/utils/request'
+
+// 获取文件列表
+export function getFileList(params) {
+  return request({
+    url: '/system/file/list',
+    method: 'get',
+    params
+  })
+}
+
+// 获取当前用户的文件存储配额
+export function getMyFileQuota() {
+  return request({
+    url: '/system/file/quota',
+    method: 'get'
+  })
+}
+
+// 上传文件
+export function uploadFile(file) {
+  const formData = new FormData()
+  formData.append('file', file)
+  return request({
+    url: '/system/file/upload',
+    method: 'post',
+    data: formData,
+    headers: {
+      'Content-Type': 'multipart/form-data'
+    }
+  })
+}
+
+// 下载文件
+export function downloadFile(id) {
+  return request({
+    url: `/system/file/${id}/download`,
+    method: 'get',
+    responseType: 'blob'
+  })
+}
+
+// 删除文件
+export function deleteFile(id) {
+  return request({
+    url: `/system/file/${id}`,
+    method: 'delete'
+  })
+}
+
+// 初始化分片上传，已有相同文件的未完成上传时返回已接收的分片
+export function initChunkUpload(data) {
+  return request({
+    url: '/system/file/chunk/init',
+    method: 'post',
+    data
+  })
+}
+
+// 查询分片上传进度
+export function getChunkUpload(uploadId) {
+  return request({
+    url: `/system/file/chunk/${uploadId}`,
+    method: 'get'
+  })
+}
+
+// 上传分片，checksum为分片的SHA-256
+export function uploadChunk(uploadId, index, chunk, checksum) {
+  return request({
+    url: `/system/file/chunk/${uploadId}/${index}`,
+    method: 'put',
+    data: chunk,
+    timeout: 60000,
+    headers: {
+      'Content-Type': 'application/octet-stream',
+      'X-Chunk-Checksum': checksum
+    }
+  })
+}
+
+// 完成分片上传，合并为文件
+export function completeChunkUpload(uploadId) {
+  return request({
+    url: `/system/file/chunk/${uploadId}/complete`,
+    method: 'post',
+    timeout: 300000 // 大文件合并耗时较长
+  })
+}
+
+// 取消分片上传
+export function abortChunkUpload(uploadId) {
+  return request({
+    url: `/system/file/chunk/${uploadId}`,
+    method: 'delete'
+  })
+}
+
+// 计算分片的SHA-256
+async function sha256Hex(blob) {
+  const digest = await crypto.subtle.digest('SHA-256', await blob.arrayBuffer())
+  return Array.from(new Uint8Array(digest)).map(b => b.toString(16).padStart(2, '0')).join('')
+}
+
+// 分片上传文件，跳过服务端已接收的分片，中断后重新调用即可续传
+export async function uploadFileInChunks(file, onProgress) {
+  const { data: upload } = await initChunkUpload({ name: file.name, size: file.size })
+  const uploaded = new Set(upload.uploaded)
+  for (let index = 0; index < upload.totalChunks; index++) {
+    if (!uploaded.has(index)) {
+      const chunk = file.slice(index * upload.chunkSize, (index + 1) * upload.chunkSize)
+      await uploadChunk(upload.uploadId, index, chunk, await sha256Hex(chunk))
+      uploaded.add(index)
+    }
+    onProgress && onProgress(Math.round(uploaded.size * 100 / upload.totalChunks))
+  }
+  return completeChunkUpload(upload.uploadId)
+}
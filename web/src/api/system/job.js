@@ -0,0 +1,85 @@
+import request from '@/utils/request'
+
+// 获取定时任务列表
+export function getJobList(params) {
+  return request({
+    url: '/system/job/list',
+    method: 'get',
+    params
+  })
+}
+
+// 获取已注册的任务类型
+export function getJobTypes() {
+  return request({
+    url: '/system/job/types',
+    method: 'get'
+  })
+}
+
+// 获取任务执行记录
+export function getJobLogs(params) {
+  return request({
+    url: '/system/job/logs',
+    method: 'get',
+    params
+  })
+}
+
+// 根据ID获取定时任务
+export function getJobById(id) {
+  return request({
+    url: `/system/job/${id}`,
+    method: 'get'
+  })
+}
+
+// 创建定时任务
+export function createJob(data) {
+  return request({
+    url: '/system/job',
+    method: 'post',
+    data
+  })
+}
+
+// 更新定时任务
+export function updateJob(id, data) {
+  return request({
+    url: `/system/job/${id}`,
+    method: 'put',
+    data
+  })
+}
+
+// 删除定时任务
+export function deleteJob(id) {
+  return request({
+    url: `/system/job/${id}`,
+    method: 'delete'
+  })
+}
+
+// 暂停定时任务
+export function pauseJob(id) {
+  return request({
+    url: `/system/job/${id}/pause`,
+    method: 'post'
+  })
+}
+
+// 恢复定时任务
+export function resumeJob(id) {
+  return request({
+    url: `/system/job/${id}/resume`,
+    method: 'post'
+  })
+}
+
+// 立即执行一次定时任务
+export function runJob(id) {
+  return request({
+    url: `/system/job/${id}/run`,
+    method: 'post'
+  })
+}
@@ -0,0 +1,38 @@
+import request from '@/utils/request'
+
+// 获取登录日志列表
+export function getLoginLogList(params) {
+  return request({
+    url: '/system/login-log/list',
+    method: 'get',
+    params
+  })
+}
+
+// 获取登录统计
+export function getLoginStats(params) {
+  return request({
+    url: '/system/login-log/stats',
+    method: 'get',
+    params
+  })
+}
+
+// 获取当前用户最近的登录记录
+export function getMyLoginLogs(params) {
+  return request({
+    url: '/system/login-log/mine',
+    method: 'get',
+    params
+  })
+}
+
+// 导出登录日志
+export function exportLoginLogs(params) {
+  return request({
+    url: '/system/login-log/export',
+    method: 'get',
+    params,
+    responseType: 'blob'
+  })
+}
@@ -0,0 +1,9 @@
+import request from '@/utils/request'
+
+// 获取服务监控信息
+export function getServerMonitor() {
+  return request({
+    url: '/system/monitor/server',
+    method: 'get'
+  })
+}
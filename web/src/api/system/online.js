@@ -0,0 +1,26 @@
+import request from '@/utils/request'
+
+// 获取在线用户列表
+export function getOnlineUsers(params) {
+  return request({
+    url: '/system/online/list',
+    method: 'get',
+    params
+  })
+}
+
+// 踢出指定会话
+export function kickSession(sessionId) {
+  return request({
+    url: `/system/online/${sessionId}`,
+    method: 'delete'
+  })
+}
+
+// 踢出用户的全部会话
+export function kickUser(userId) {
+  return request({
+    url: `/system/online/user/${userId}`,
+    method: 'delete'
+  })
+}
@@ -59,7 +59,7 @@ export function getOperationStats() {
   })
 }
 
-// 导出操作日志
+// 导出操作日志（format: csv、xlsx、ndjson，includeBody为true时包含请求和响应内容）
 export function exportOperationLogs(params) {
   return request({
     url: '/system/operation-log/export',
@@ -68,3 +68,62 @@ export function exportOperationLogs(params) {
     responseType: 'blob'
   })
 }
+
+// 获取已登记操作日志的路由（模块、操作类型、描述模板）
+export function getOperationRoutes() {
+  return request({
+    url: '/system/operation-log/routes',
+    method: 'get'
+  })
+}
+
+// 校验操作日志哈希链
+export function verifyOperationLogs() {
+  return request({
+    url: '/system/operation-log/verify',
+    method: 'get'
+  })
+}
+
+// 获取操作日志归档检查点
+export function getOperationLogCheckpoints() {
+  return request({
+    url: '/system/operation-log/checkpoints',
+    method: 'get'
+  })
+}
+
+// 预览保留策略将要归档清理的日志
+export function getOperationLogRetention(params) {
+  return request({
+    url: '/system/operation-log/retention',
+    method: 'get',
+    params
+  })
+}
+
+// 立即执行保留策略
+export function runOperationLogRetention(data) {
+  return request({
+    url: '/system/operation-log/retention/run',
+    method: 'post',
+    data
+  })
+}
+
+// 获取操作日志归档文件列表
+export function getOperationLogArchives() {
+  return request({
+    url: '/system/operation-log/archives',
+    method: 'get'
+  })
+}
+
+// 下载操作日志归档文件
+export function downloadOperationLogArchive(name) {
+  return request({
+    url: `/system/operation-log/archives/${encodeURIComponent(name)}/download`,
+    method: 'get',
+    responseType: 'blob'
+  })
+}
@@ -0,0 +1,56 @@
+import request from '@/utils/request'
+
+// 获取权限模板列表
+export function getPermissionTemplateList(params) {
+  return request({
+    url: '/system/permission-template/list',
+    method: 'get',
+    params
+  })
+}
+
+// 根据ID获取权限模板
+export function getPermissionTemplateById(id) {
+  return request({
+    url: `/system/permission-template/${id}`,
+    method: 'get'
+  })
+}
+
+// 创建权限模板
+export function createPermissionTemplate(data) {
+  return request({
+    url: '/system/permission-template',
+    method: 'post',
+    data
+  })
+}
+
+// 更新权限模板
+export function updatePermissionTemplate(id, data) {
+  return request({
+    url: `/system/permission-template/${id}`,
+    method: 'put',
+    data
+  })
+}
+
+// 删除权限模板
+export function deletePermissionTemplate(id) {
+  return request({
+    url: `/system/permission-template/${id}`,
+    method: 'delete'
+  })
+}
+
+// 将权限模板应用到角色
+export function applyPermissionTemplate(id, authorityIds, mode = 'replace') {
+  return request({
+    url: `/system/permission-template/${id}/apply`,
+    method: 'post',
+    data: {
+      authorityIds,
+      mode
+    }
+  })
+}
@@ -17,4 +17,23 @@ export function assignRoleMenus(roleId, menuIds) {
       menuIds
     }
   })
-} 
\ No newline at end of file
+} 
+// 获取角色的有效权限及来源
+export function getRoleEffectivePermissions(roleId) {
+  return request({
+    url: `/system/role/${roleId}/effective`,
+    method: 'get'
+  })
+}
+
+// 预览角色菜单权限变更（不提交）
+export function previewAssignRoleMenus(roleId, menuIds) {
+  return request({
+    url: `/system/role/${roleId}/menus`,
+    method: 'post',
+    data: {
+      menuIds,
+      dryRun: true
+    }
+  })
+}
@@ -0,0 +1,52 @@
+import request from '@/utils/request'
+
+// 获取岗位列表
+export function getPostList(params) {
+  return request({
+    url: '/system/post/list',
+    method: 'get',
+    params
+  })
+}
+
+// 获取所有启用的岗位（下拉选项用）
+export function getAllPosts() {
+  return request({
+    url: '/system/post/all',
+    method: 'get'
+  })
+}
+
+// 根据ID获取岗位
+export function getPostById(id) {
+  return request({
+    url: `/system/post/${id}`,
+    method: 'get'
+  })
+}
+
+// 创建岗位
+export function createPost(data) {
+  return request({
+    url: '/system/post',
+    method: 'post',
+    data
+  })
+}
+
+// 更新岗位
+export function updatePost(id, data) {
+  return request({
+    url: `/system/post/${id}`,
+    method: 'put',
+    data
+  })
+}
+
+// 删除岗位
+export function deletePost(id) {
+  return request({
+    url: `/system/post/${id}`,
+    method: 'delete'
+  })
+}
@@ -49,4 +49,20 @@ export function deleteRole(id) {
     url: `/system/role/${id}`,
     method: 'delete'
   })
-} 
\ No newline at end of file
+} 
+// 获取角色树
+export function getRoleTree() {
+  return request({
+    url: '/system/role/tree',
+    method: 'get'
+  })
+}
+
+// 复制角色（包含菜单权限）
+export function copyRole(id, data) {
+  return request({
+    url: `/system/role/${id}/copy`,
+    method: 'post',
+    data
+  })
+}
@@ -86,4 +86,48 @@ export function getUserMenus() {
     url: '/system/user/menus',
     method: 'get'
   })
-} 
\ No newline at end of file
+} 
+// 切换当前角色（重新签发token）
+export function switchAuthority(authorityId) {
+  return request({
+    url: '/system/user/switch-authority',
+    method: 'post',
+    data: { authorityId }
+  })
+}
+
+// 批量导入用户（dryRun为true时仅校验）
+export function importUsers(file, dryRun = false) {
+  const formData = new FormData()
+  formData.append('file', file)
+  formData.append('dryRun', dryRun ? 'true' : 'false')
+
+  return request({
+    url: '/system/user/import',
+    method: 'post',
+    data: formData,
+    headers: {
+      'Content-Type': 'multipart/form-data'
+    }
+  })
+}
+
+// 下载用户导入模板
+export function downloadUserImportTemplate(format = 'xlsx') {
+  return request({
+    url: '/system/user/import/template',
+    method: 'get',
+    params: { format },
+    responseType: 'blob'
+  })
+}
+
+// 导出用户（支持与列表相同的筛选条件，columns为导出列字段，逗号分隔）
+export function exportUsers(params) {
+  return request({
+    url: '/system/user/export',
+    method: 'get',
+    params,
+    responseType: 'blob'
+  })
+}
@@ -9,6 +9,22 @@ export function login(data) {
   })
 }
 
+// 登出，使当前会话失效
+export function logout() {
+  return request({
+    url: '/base/logout',
+    method: 'post'
+  })
+}
+
+// 刷新token，沿用当前会话
+export function refreshToken() {
+  return request({
+    url: '/base/refresh',
+    method: 'post'
+  })
+}
+
 // 获取用户信息
 export function getUserInfo() {
   return request({
@@ -1,6 +1,6 @@
 import { defineStore } from 'pinia'
 import { ref } from 'vue'
-import { login as loginApi } from '@/api/user'
+import { login as loginApi, logout as logoutApi } from '@/api/user'
 import { getUserInfo as getUserInfoApi, getUserMenus as getUserMenusApi } from '@/api/system/user'
 import { getToken, setToken, removeToken } from '@/utils/auth'
 import router from '@/router'
@@ -105,6 +105,10 @@ export const useUserStore = defineStore('user', () => {
 
   // 退出登录
   const logout = () => {
+    if (token.value) {
+      // 通知服务端使会话失效，失败不影响本地登出
+      logoutApi().catch(() => {})
+    }
     token.value = ''
     userInfo.value = {}
     userMenus.value = []